@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"hey-bobik/internal/config"
+	"hey-bobik/internal/history"
+	"hey-bobik/internal/orchestrator"
+	"os"
+	"strings"
+)
+
+// openHistory opens the persistent history and loads its latest successful
+// entries into memory. It returns nil when the history is disabled.
+func openHistory(cfg *config.Config, memory *orchestrator.ContextMemory) orchestrator.HistoryStore {
+	if cfg.HistoryPath == "" {
+		return nil
+	}
+	store := history.Open(cfg.HistoryPath, cfg.HistoryRetention)
+	recent, err := store.RecentSucceeded(memory.Size())
+	if err != nil {
+		log.Warn("Failed to load history: %v", err)
+	}
+	memory.Restore(recent)
+	log.Debug("Restored %d interactions from %s", len(recent), cfg.HistoryPath)
+	return store
+}
+
+// runHistoryCommand handles `bobik history [-n N] [запрос]`.
+func runHistoryCommand(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	limit := fs.Int("n", 20, "number of entries to show, 0 for all")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	entries, err := history.Load(cfg.HistoryPath)
+	if os.IsNotExist(err) {
+		fmt.Println("No history yet, run Bobik and give it a few commands first")
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read history: %v\n", err)
+		return 1
+	}
+
+	entries = history.Last(history.Search(entries, strings.Join(fs.Args(), " ")), *limit)
+	if len(entries) == 0 {
+		fmt.Println("Nothing found")
+		return 0
+	}
+	history.Write(os.Stdout, entries)
+	return 0
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"hey-bobik/internal/config"
+	"hey-bobik/internal/index"
+	"hey-bobik/internal/llm"
+	"hey-bobik/internal/orchestrator"
+)
+
+// newNotesIndex opens the embeddings index of the vault, or returns nil when
+// no embedding model is configured or the vault isn't reachable as files.
+// Embeddings always come from Ollama.
+func newNotesIndex(cfg *config.Config) (*index.Index, *llm.Client) {
+	if cfg.EmbedModel == "" || !localVault(cfg) {
+		return nil, nil
+	}
+	c := llm.New(cfg.OllamaURL, cfg.EmbedModel)
+	c.Timeout, c.MaxRetries, c.KeepAlive = cfg.OllamaTimeout, cfg.LLMMaxRetries, cfg.OllamaKeepAlive
+	return index.Open(cfg.IndexPath, cfg.VaultPath, cfg.EmbedModel, c), c
+}
+
+// watchIndex checks the embedding model, then indexes changed notes until ctx ends.
+func watchIndex(ctx context.Context, cfg *config.Config, n orchestrator.Notifier, x *index.Index, c *llm.Client) {
+	if err := ensureModel(ctx, cfg, n, c); err != nil {
+		msg := modelErrorMessage(c, err)
+		log.Warn("%s, questions about notes won't work: %v", msg, err)
+		n.Notify(ctx, "Bobik", msg)
+		return
+	}
+	x.Watch(ctx, cfg.IndexInterval)
+	log.Debug("Notes index has %d chunks", x.Len())
+}
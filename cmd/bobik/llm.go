@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"hey-bobik/internal/config"
+	"hey-bobik/internal/llm"
+	"hey-bobik/internal/orchestrator"
+)
+
+// llmBackend is what the router and vision roles need from a client.
+type llmBackend interface {
+	orchestrator.LLMClient
+	orchestrator.VisionLLMClient
+}
+
+// newRoleClient creates the client of one role. With fallbacks it returns a
+// chain that tries the primary model first and the fallbacks in order.
+func newRoleClient(cfg *config.Config, role, model string, fallbacks []config.ModelEndpoint, opts config.GenerationOptions) (llmBackend, error) {
+	primary, err := newLLMClient(cfg, config.ModelEndpoint{Model: model}, opts)
+	if err != nil || len(fallbacks) == 0 {
+		return primary, err
+	}
+
+	entries := []llm.Entry{{Name: model, Client: primary}}
+	for _, ep := range fallbacks {
+		c, err := newLLMClient(cfg, ep, opts)
+		if err != nil {
+			return nil, fmt.Errorf("%s fallback %s: %w", role, ep, err)
+		}
+		entries = append(entries, llm.Entry{Name: ep.String(), Client: c})
+	}
+	return llm.NewFallback(role, entries...), nil
+}
+
+// newLLMClient creates a client for the endpoint's provider with the
+// configured deadline, retries, circuit breaker and role generation options.
+// An endpoint without provider or URL uses the global ones.
+func newLLMClient(cfg *config.Config, ep config.ModelEndpoint, role config.GenerationOptions) (llmBackend, error) {
+	opts := llm.Options{
+		Temperature: role.Temperature,
+		TopP:        role.TopP,
+		NumCtx:      role.NumCtx,
+		NumPredict:  role.NumPredict,
+		Seed:        role.Seed,
+		Stop:        role.Stop,
+	}
+	keepAlive := cfg.OllamaKeepAlive
+	if role.KeepAlive != "" {
+		keepAlive = role.KeepAlive
+	}
+
+	var breaker *llm.Breaker
+	if cfg.LLMBreakerThreshold > 0 {
+		breaker = llm.NewBreaker(cfg.LLMBreakerThreshold, cfg.LLMBreakerCooldown)
+	}
+
+	provider := ep.Provider
+	if provider == "" {
+		provider = cfg.LLMProvider
+	}
+
+	switch provider {
+	case "", "ollama":
+		c := llm.New(orDefault(ep.URL, cfg.OllamaURL), ep.Model)
+		c.Timeout, c.MaxRetries, c.Breaker = cfg.OllamaTimeout, cfg.LLMMaxRetries, breaker
+		c.Options, c.KeepAlive, c.Think = opts, keepAlive, role.Think
+		return c, nil
+	case "openai":
+		c := llm.NewOpenAI(orDefault(ep.URL, cfg.LLMURL), ep.Model, cfg.LLMAPIKey)
+		c.Timeout, c.MaxRetries, c.Breaker = cfg.OllamaTimeout, cfg.LLMMaxRetries, breaker
+		c.Options = opts
+		return c, nil
+	default:
+		return nil, fmt.Errorf("unknown llm_provider %q (expected ollama or openai)", provider)
+	}
+}
+
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+// setHooks sends model reasoning and usage metrics of a client to the orchestrator.
+func setHooks(client interface{}, o *orchestrator.Orchestrator) {
+	onThinking := func(thinking string) { o.Emit(orchestrator.EventThinking, thinking) }
+	switch c := client.(type) {
+	case *llm.Client:
+		c.OnThinking, c.OnUsage = onThinking, o.TrackUsage
+	case *llm.OpenAIClient:
+		c.OnThinking, c.OnUsage = onThinking, o.TrackUsage
+	case *llm.Fallback:
+		for _, e := range c.Entries {
+			setHooks(e.Client, o)
+		}
+	}
+}
+
+// degradedStatus shows which roles run on a fallback model, implemented by the tray.
+type degradedStatus interface {
+	SetDegraded(role, model string)
+	ClearDegraded(role string)
+}
+
+// watchFallback reports when a chain switches to a fallback model and back.
+func watchFallback(client interface{}, status degradedStatus) {
+	f, ok := client.(*llm.Fallback)
+	if !ok {
+		return
+	}
+	f.OnAnswer = func(role, name string, degraded bool) {
+		if degraded {
+			status.SetDegraded(role, name)
+		} else {
+			status.ClearDegraded(role)
+		}
+	}
+}
@@ -3,20 +3,21 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"hey-bobik/internal/audio"
 	"hey-bobik/internal/config"
-	"hey-bobik/internal/llm"
+	"hey-bobik/internal/control"
 	"hey-bobik/internal/logger"
 	"hey-bobik/internal/orchestrator"
+	"hey-bobik/internal/stats"
 	"hey-bobik/internal/stt"
 	"hey-bobik/internal/tools/calc"
 	"hey-bobik/internal/tools/clipboard"
 	"hey-bobik/internal/tools/clock"
 	"hey-bobik/internal/tools/notifier"
-	"hey-bobik/internal/tools/obsidian"
 	"hey-bobik/internal/tools/screen"
+	"hey-bobik/internal/tools/tasks"
 	"hey-bobik/internal/tools/timer"
-	"hey-bobik/internal/tools/tts"
 	"hey-bobik/internal/ui/tray"
 	"os"
 	"os/signal"
@@ -35,6 +36,7 @@ func main() {
 	ollamaURL := flag.String("ollama", "", "Ollama API URL")
 	ollamaModel := flag.String("llm", "", "Ollama model name")
 
+	flag.Usage = usage
 	flag.Parse()
 
 	// Load configuration
@@ -64,12 +66,35 @@ func main() {
 		cfg.OllamaModel = *ollamaModel
 	}
 
-	log.Info("Starting Bobik with model: %s, LLM: %s", cfg.ModelPath, cfg.OllamaModel)
+	// Subcommands run instead of the voice agent
+	if args := flag.Args(); len(args) > 0 {
+		os.Exit(runSubcommand(cfg, args))
+	}
+
+	log.Info("Starting Bobik with model: %s, LLM: %s (%s)", cfg.ModelPath, cfg.OllamaModel, cfg.LLMProvider)
 
 	// 1. Initialize Tools
 	n := notifier.New()
-	oService := obsidian.New(cfg.VaultPath, cfg.NotePrefix)
-	lClient := llm.New(cfg.OllamaURL, cfg.OllamaModel)
+	oService, err := newNoteService(cfg)
+	if err != nil {
+		log.Error("Invalid note settings: %v", err)
+		os.Exit(1)
+	}
+	lClient, err := newRoleClient(cfg, "router", cfg.OllamaModel, cfg.RouterFallbacks, cfg.RouterOptions)
+	if err != nil {
+		log.Error("Failed to create LLM client: %v", err)
+		os.Exit(1)
+	}
+
+	// General questions get their own model only when configured
+	var chatClient orchestrator.LLMClient
+	if cfg.ChatModel != "" || len(cfg.ChatFallbacks) > 0 {
+		chatClient, err = newRoleClient(cfg, "chat", orDefault(cfg.ChatModel, cfg.OllamaModel), cfg.ChatFallbacks, cfg.ChatOptions)
+		if err != nil {
+			log.Error("Failed to create chat LLM client: %v", err)
+			os.Exit(1)
+		}
+	}
 
 	cService := clock.New()
 	tService := timer.New(func(name string) {
@@ -77,10 +102,8 @@ func main() {
 	})
 
 	// Initialize TTS
-	ttsService := tts.New(cfg.TTSEnabled, cfg.TTSCommand)
-	if cfg.TTSEnabled && !ttsService.IsAvailable() {
-		log.Warn("TTS enabled but command '%s' not found", cfg.TTSCommand)
-	}
+	ttsService, stopTTS := newSpeaker(cfg)
+	defer stopTTS()
 
 	// Initialize Clipboard
 	clipboardService := clipboard.New()
@@ -93,7 +116,7 @@ func main() {
 
 	// Initialize Screen capture and Vision LLM (if enabled)
 	var screenService *screen.Adapter
-	var visionClient *llm.Client
+	var visionClient orchestrator.VisionLLMClient
 
 	if cfg.VisionEnabled {
 		// Инициализируем инструмент скриншотов
@@ -103,13 +126,19 @@ func main() {
 			log.Info("Screen capture available using: %s", screenTool.GetAvailableBackend())
 
 			// Инициализируем отдельный LLM клиент для vision модели
-			visionClient = llm.New(cfg.OllamaURL, cfg.VisionModel)
+			if visionClient, err = newRoleClient(cfg, "vision", cfg.VisionModel, cfg.VisionFallbacks, cfg.VisionOptions); err != nil {
+				log.Error("Failed to create vision LLM client: %v", err)
+				os.Exit(1)
+			}
 			log.Info("Vision model configured: %s", cfg.VisionModel)
 		} else {
 			log.Warn("Vision enabled but no screenshot tool found (install gnome-screenshot, scrot, or grim)")
 		}
 	}
 
+	// Semantic search over the vault, indexed in the background
+	notesIndex, embedClient := newNotesIndex(cfg)
+
 	// 2. Initialize STT Engine
 	engine, err := stt.NewEngine(cfg.ModelPath)
 	if err != nil {
@@ -137,21 +166,34 @@ func main() {
 		cancel()
 	})
 
-	// 4. Initialize Orchestrator
+	// 4. Initialize Orchestrator, restoring recent interactions from the history
+	memory := orchestrator.NewContextMemory(10)
+	historyStore := openHistory(cfg, memory)
+	llmStats := stats.New(cfg.StatsPath)
 	o := &orchestrator.Orchestrator{
-		Recorder:  recorder,
-		STT:       engine,
-		Notifier:  n,
-		LLM:       lClient,
-		VisionLLM: visionClient,
-		Obsidian:  oService,
-		Timer:     tService,
-		Clock:     cService,
-		TTS:       ttsService,
-		Clipboard: clipboardService,
-		Calc:      calcService,
-		Screen:    screenService,
-		Memory:    orchestrator.NewContextMemory(10),
+		Recorder:       recorder,
+		STT:            engine,
+		Notifier:       n,
+		LLM:            lClient,
+		ChatLLM:        chatClient,
+		VisionLLM:      visionClient,
+		Obsidian:       oService,
+		Timer:          tService,
+		Clock:          cService,
+		TTS:            ttsService,
+		Clipboard:      clipboardService,
+		Calc:           calcService,
+		Screen:         screenService,
+		Memory:         memory,
+		History:        historyStore,
+		ConfirmRisky:   cfg.ConfirmRisky,
+		ConfirmTimeout: cfg.ConfirmTimeout,
+		NativeTools:    cfg.OllamaNativeTools,
+		SpeechLimit:    cfg.TTSMaxLength,
+		Usage:          llmStats,
+		OnEvent: func(e orchestrator.Event) {
+			log.Debug("Event %s: %s", e.Type, e.Text)
+		},
 		OnStateChange: func(s orchestrator.State) {
 			switch s {
 			case orchestrator.StateIdle:
@@ -164,6 +206,38 @@ func main() {
 		},
 	}
 
+	// Tasks and the index read the vault as files, the REST backend has neither
+	if localVault(cfg) {
+		o.Tasks = tasks.New(cfg.VaultPath, cfg.TasksFile)
+	}
+	if notesIndex != nil {
+		o.Index = notesIndex
+		go watchIndex(ctx, cfg, n, notesIndex, embedClient)
+	}
+
+	// Reasoning of thinking models goes to the event stream, never to the router;
+	// usage metrics are aggregated per command
+	setHooks(lClient, o)
+	setHooks(chatClient, o)
+	setHooks(visionClient, o)
+
+	// The tray shows which roles answer with a fallback model
+	for _, c := range []interface{}{lClient, chatClient, visionClient} {
+		watchFallback(c, trayManager)
+	}
+
+	// Check models and keep the router warm in the background, the tray shows problems
+	go prepareModels(ctx, cfg, n, trayManager, lClient, chatClient, visionClient)
+
+	// The control API exposes the agent to local scripts
+	if cfg.ControlAddr != "" {
+		go func() {
+			if err := control.New(cfg.ControlAddr, llmStats).Run(ctx); err != nil {
+				log.Warn("Control API stopped: %v", err)
+			}
+		}()
+	}
+
 	// Start Orchestrator in a goroutine
 	go func() {
 		if err := o.Start(ctx); err != nil && err != context.Canceled {
@@ -184,3 +258,28 @@ func main() {
 	// Run Tray on the main thread
 	trayManager.Run()
 }
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [command]\n\nCommands:\n", os.Args[0])
+	fmt.Fprintln(flag.CommandLine.Output(), `  tts test "текст"   speak text with the configured voice`)
+	fmt.Fprintln(flag.CommandLine.Output(), `  stats              show LLM latency and token usage per model and action`)
+	fmt.Fprintln(flag.CommandLine.Output(), `  history [запрос]   browse and search past interactions, -n N limits the count`)
+	fmt.Fprintln(flag.CommandLine.Output(), "\nFlags:")
+	flag.PrintDefaults()
+}
+
+// runSubcommand executes a one-shot CLI command and returns the exit code.
+func runSubcommand(cfg *config.Config, args []string) int {
+	switch args[0] {
+	case "tts":
+		return runTTSCommand(cfg, args[1:])
+	case "stats":
+		return runStatsCommand(cfg)
+	case "history":
+		return runHistoryCommand(cfg, args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command: %s\n", args[0])
+		usage()
+		return 2
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hey-bobik/internal/config"
+	"hey-bobik/internal/llm"
+	"hey-bobik/internal/orchestrator"
+	"time"
+)
+
+// modelStatus is where model problems are shown, implemented by the tray.
+type modelStatus interface {
+	SetError(msg string)
+	ClearError()
+}
+
+// prepareModels checks the Ollama models at startup, pulls missing ones when
+// auto-pull is enabled, then keeps the router model warm until ctx ends.
+// Only the primary model of each role is checked; when it's missing but the
+// role has fallbacks, the agent keeps working and the tray shows degraded mode
+// on the first call. A failed role doesn't stop the checks of the others or
+// warming the router. Other providers manage their models themselves and are skipped.
+func prepareModels(ctx context.Context, cfg *config.Config, n orchestrator.Notifier, status modelStatus, roles ...interface{}) {
+	var router *llm.Client
+	if len(roles) > 0 {
+		router, _ = primaryModel(roles[0])
+	}
+
+	checked := map[*llm.Client]bool{}
+	failed, routerFailed := false, false
+	for _, role := range roles {
+		c, hasFallbacks := primaryModel(role)
+		if c == nil || checked[c] {
+			continue
+		}
+		checked[c] = true
+
+		if err := ensureModel(ctx, cfg, n, c); err != nil {
+			msg := modelErrorMessage(c, err)
+			if c == router {
+				routerFailed = true
+			}
+			if hasFallbacks {
+				log.Warn("%s, using fallback models: %v", msg, err)
+				n.Notify(ctx, "Bobik", msg+". Использую резервную модель")
+				continue
+			}
+			log.Error("%s: %v", msg, err)
+			n.Notify(ctx, "Bobik Error", msg)
+			status.SetError(msg)
+			failed = true
+			continue
+		}
+		log.Info("Model %s is ready", c.Model)
+	}
+	if !failed {
+		status.ClearError()
+	}
+
+	if router != nil && !routerFailed {
+		keepWarm(ctx, router, cfg.OllamaWarmInterval)
+	}
+}
+
+// primaryModel returns the Ollama client a role tries first, or nil for other
+// providers, and whether the role has fallbacks.
+func primaryModel(client interface{}) (*llm.Client, bool) {
+	switch c := client.(type) {
+	case *llm.Client:
+		return c, false
+	case *llm.Fallback:
+		if len(c.Entries) == 0 {
+			return nil, false
+		}
+		primary, _ := c.Entries[0].Client.(*llm.Client)
+		return primary, len(c.Entries) > 1
+	}
+	return nil, false
+}
+
+// ensureModel verifies the model and pulls it when missing and allowed.
+func ensureModel(ctx context.Context, cfg *config.Config, n orchestrator.Notifier, c *llm.Client) error {
+	err := c.CheckModel(ctx)
+	if !errors.Is(err, llm.ErrModelNotFound) || !cfg.OllamaAutoPull {
+		return err
+	}
+
+	log.Info("Pulling missing model %s...", c.Model)
+	n.Notify(ctx, "Bobik", "Загружаю модель "+c.Model+"...")
+	if err := c.Pull(ctx, pullNotifier(ctx, n, c.Model)); err != nil {
+		return err
+	}
+	n.Notify(ctx, "Bobik", "Модель "+c.Model+" загружена")
+	return c.CheckModel(ctx)
+}
+
+// pullNotifier reports download progress in 25% steps so notifications don't flood.
+func pullNotifier(ctx context.Context, n orchestrator.Notifier, model string) func(llm.PullProgress) {
+	lastStep := -1
+	lastStatus := ""
+	return func(p llm.PullProgress) {
+		if p.Status != lastStatus {
+			// A new layer starts from zero
+			lastStatus, lastStep = p.Status, -1
+		}
+		pct := p.Percent()
+		if pct < 0 || pct/25 == lastStep {
+			return
+		}
+		lastStep = pct / 25
+		log.Info("Pulling %s: %s %d%%", model, p.Status, pct)
+		n.Notify(ctx, "Bobik", fmt.Sprintf("Загрузка %s: %d%%", model, pct))
+	}
+}
+
+// modelErrorMessage explains a failed model check in the user's language.
+func modelErrorMessage(c *llm.Client, err error) string {
+	switch {
+	case errors.Is(err, llm.ErrModelNotFound):
+		return fmt.Sprintf("Модель %s не найдена, выполните: ollama pull %s", c.Model, c.Model)
+	case errors.Is(err, llm.ErrServerDown), errors.Is(err, llm.ErrCircuitOpen):
+		return "Ollama недоступна по адресу " + c.BaseURL
+	case errors.Is(err, llm.ErrTimeout):
+		return "Ollama не отвечает"
+	default:
+		return "Ошибка проверки модели " + c.Model
+	}
+}
+
+// keepWarm loads the model now and again every interval so it stays resident.
+func keepWarm(ctx context.Context, c *llm.Client, interval time.Duration) {
+	warm := func() {
+		start := time.Now()
+		if err := c.Warm(ctx); err != nil {
+			log.Warn("Failed to warm up %s: %v", c.Model, err)
+			return
+		}
+		log.Debug("Model %s warm in %s", c.Model, time.Since(start))
+	}
+
+	warm()
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			warm()
+		}
+	}
+}
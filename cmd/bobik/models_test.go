@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"hey-bobik/internal/config"
+	"hey-bobik/internal/llm"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+type mockNotifier struct{}
+
+func (mockNotifier) Notify(ctx context.Context, title, message string) error { return nil }
+
+type mockStatus struct {
+	errors  []string
+	cleared bool
+}
+
+func (m *mockStatus) SetError(msg string) { m.errors = append(m.errors, msg) }
+func (m *mockStatus) ClearError()         { m.cleared = true }
+
+func TestPrepareModelsFailedRoleStillWarmsRouter(t *testing.T) {
+	var mu sync.Mutex
+	var warmed []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			json.NewEncoder(w).Encode(map[string]any{"models": []map[string]string{{"name": "qwen3:8b"}}})
+		case "/api/show":
+			json.NewEncoder(w).Encode(map[string]any{"capabilities": []string{"completion"}})
+		case "/api/generate":
+			var req llm.GenerateRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			mu.Lock()
+			warmed = append(warmed, req.Model)
+			mu.Unlock()
+			json.NewEncoder(w).Encode(map[string]any{"done": true})
+		}
+	}))
+	defer server.Close()
+
+	router := llm.New(server.URL, "qwen3:8b")
+	vision := llm.New(server.URL, "llava:13b")
+	status := &mockStatus{}
+	prepareModels(context.Background(), &config.Config{}, mockNotifier{}, status, router, vision)
+
+	if len(status.errors) != 1 || status.cleared {
+		t.Errorf("the missing vision model should stay reported, got %q (cleared %v)", status.errors, status.cleared)
+	}
+	if len(warmed) != 1 || warmed[0] != "qwen3:8b" {
+		t.Errorf("the router should still be warmed, got %q", warmed)
+	}
+}
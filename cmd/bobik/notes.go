@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"hey-bobik/internal/config"
+	"hey-bobik/internal/orchestrator"
+	"hey-bobik/internal/tools/obsidian"
+)
+
+// newNoteService picks the vault backend: files written directly, or the
+// Obsidian Local REST API plugin for a vault that can't be reached as files.
+func newNoteService(cfg *config.Config) (orchestrator.ObsidianService, error) {
+	opts := obsidian.Options{
+		PathTemplate:   cfg.NotePathTemplate,
+		HeaderTemplate: cfg.NoteHeaderTemplate,
+		HeaderFile:     cfg.NoteHeaderFile,
+		EntryTemplate:  cfg.NoteEntryTemplate,
+		EntryDelimiter: cfg.NoteEntryDelimiter,
+		Section:        cfg.NoteSection,
+
+		NamedFolder:         cfg.NamedNoteFolder,
+		NamedHeaderTemplate: cfg.NamedNoteHeaderTemplate,
+		NamedHeaderFile:     cfg.NamedNoteHeaderFile,
+		NamedEntryTemplate:  cfg.NamedNoteEntryTemplate,
+
+		AttachmentFolder: cfg.AttachmentFolder,
+	}
+
+	switch cfg.ObsidianBackend {
+	case "", "files":
+		return obsidian.NewWithOptions(cfg.VaultPath, cfg.NotePrefix, opts)
+	case "rest":
+		log.Info("Writing notes through the Obsidian Local REST API at %s", orDefault(cfg.ObsidianAPIURL, obsidian.DefaultAPIURL))
+		return obsidian.NewAPI(cfg.ObsidianAPIURL, cfg.ObsidianAPIKey, cfg.ObsidianAPICert, opts)
+	default:
+		return nil, fmt.Errorf("unknown obsidian_backend %q (expected files or rest)", cfg.ObsidianBackend)
+	}
+}
+
+// localVault reports whether the vault is reached as files on this machine.
+func localVault(cfg *config.Config) bool {
+	return cfg.ObsidianBackend == "" || cfg.ObsidianBackend == "files"
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+	"hey-bobik/internal/config"
+	"hey-bobik/internal/stats"
+	"os"
+)
+
+// runStatsCommand prints the LLM metrics collected by the agent.
+func runStatsCommand(cfg *config.Config) int {
+	snap, err := stats.Load(cfg.StatsPath)
+	if os.IsNotExist(err) {
+		fmt.Println("No stats yet, run Bobik and give it a few commands first")
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read stats: %v\n", err)
+		return 1
+	}
+	snap.Write(os.Stdout)
+	return 0
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"hey-bobik/internal/audio"
+	"hey-bobik/internal/config"
+	"hey-bobik/internal/tools/tts"
+	"os"
+	"strings"
+)
+
+// newSpeaker builds the TTS speaker from config and starts in-process playback.
+// The returned function releases the audio device.
+func newSpeaker(cfg *config.Config) (*tts.Speaker, func()) {
+	ttsService := tts.NewWithOptions(cfg.TTSEnabled, cfg.TTSCommand, tts.Options{
+		Voice:     cfg.TTSVoice,
+		Rate:      cfg.TTSRate,
+		Pitch:     cfg.TTSPitch,
+		Volume:    cfg.TTSVolume,
+		ExtraArgs: cfg.TTSExtraArgs,
+	})
+	ttsService.MaxLength = cfg.TTSMaxLength
+	if !cfg.TTSEnabled {
+		return ttsService, func() {}
+	}
+
+	if !ttsService.IsAvailable() {
+		log.Warn("TTS enabled but command '%s' not found", cfg.TTSCommand)
+	}
+	if err := ttsService.Validate(); err != nil {
+		log.Warn("Invalid TTS settings: %v", err)
+	}
+
+	player := audio.NewPlayer(cfg.TTSOutputDevice)
+	if err := player.Start(); err != nil {
+		log.Warn("In-process TTS playback unavailable, falling back to %s: %v", cfg.TTSCommand, err)
+		return ttsService, func() {}
+	}
+	ttsService.Player = player
+	ttsService.Cache = tts.NewCache(cfg.TTSCacheDir)
+	ttsService.CachePhrases = cfg.TTSCachePhrases
+	go ttsService.Prewarm(context.Background())
+
+	return ttsService, func() { player.Stop() }
+}
+
+// runTTSCommand handles `bobik tts test "текст"` for auditioning voice settings.
+func runTTSCommand(cfg *config.Config, args []string) int {
+	if len(args) < 2 || args[0] != "test" {
+		fmt.Fprintln(os.Stderr, `usage: bobik tts test "текст"`)
+		return 2
+	}
+	text := strings.Join(args[1:], " ")
+
+	// Auditioning should work even if TTS is switched off for the agent
+	cfg.TTSEnabled = true
+	ttsService, stop := newSpeaker(cfg)
+	defer stop()
+
+	if !ttsService.IsAvailable() {
+		fmt.Fprintf(os.Stderr, "TTS command %q not found\n", cfg.TTSCommand)
+		return 1
+	}
+	if err := ttsService.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid TTS settings: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Speaking with %s %s\n", ttsService.Command, strings.Join(ttsService.Args, " "))
+	if err := ttsService.Speak(context.Background(), text); err != nil {
+		fmt.Fprintf(os.Stderr, "speak failed: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,112 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"github.com/gordonklaus/portaudio"
+	"strings"
+)
+
+const playerFramesPerBuffer = 1024
+
+// Player plays mono 16-bit PCM through PortAudio.
+type Player struct {
+	DeviceName string // substring of the output device name, empty for the default device
+	device     *portaudio.DeviceInfo
+}
+
+// NewPlayer creates a new Player for the given output device.
+func NewPlayer(deviceName string) *Player {
+	return &Player{
+		DeviceName: deviceName,
+	}
+}
+
+// Start initializes PortAudio and resolves the output device.
+func (p *Player) Start() error {
+	if err := portaudio.Initialize(); err != nil {
+		return fmt.Errorf("failed to initialize PortAudio: %w", err)
+	}
+
+	device, err := findOutputDevice(p.DeviceName)
+	if err != nil {
+		portaudio.Terminate()
+		return err
+	}
+
+	p.device = device
+	return nil
+}
+
+// findOutputDevice returns the first output device whose name contains name,
+// or the default output device when name is empty.
+func findOutputDevice(name string) (*portaudio.DeviceInfo, error) {
+	if name == "" {
+		device, err := portaudio.DefaultOutputDevice()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get default output device: %w", err)
+		}
+		return device, nil
+	}
+
+	devices, err := portaudio.Devices()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audio devices: %w", err)
+	}
+	for _, d := range devices {
+		if d.MaxOutputChannels > 0 && strings.Contains(strings.ToLower(d.Name), strings.ToLower(name)) {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("output device %q not found", name)
+}
+
+// Play writes samples to the output device and blocks until playback ends
+// or ctx is cancelled.
+func (p *Player) Play(ctx context.Context, samples []int16, sampleRate int) error {
+	if p.device == nil {
+		return fmt.Errorf("player not started")
+	}
+
+	buf := make([]int16, playerFramesPerBuffer)
+	params := portaudio.LowLatencyParameters(nil, p.device)
+	params.Output.Channels = 1
+	params.SampleRate = float64(sampleRate)
+	params.FramesPerBuffer = len(buf)
+
+	stream, err := portaudio.OpenStream(params, buf)
+	if err != nil {
+		return fmt.Errorf("failed to open output stream: %w", err)
+	}
+	defer stream.Close()
+
+	if err := stream.Start(); err != nil {
+		return fmt.Errorf("failed to start output stream: %w", err)
+	}
+
+	for off := 0; off < len(samples); off += len(buf) {
+		if ctx.Err() != nil {
+			stream.Abort()
+			return ctx.Err()
+		}
+
+		n := copy(buf, samples[off:])
+		// Pad the final chunk with silence
+		for i := n; i < len(buf); i++ {
+			buf[i] = 0
+		}
+
+		if err := stream.Write(); err != nil {
+			stream.Abort()
+			return fmt.Errorf("failed to write to output stream: %w", err)
+		}
+	}
+
+	return stream.Stop()
+}
+
+// Stop releases PortAudio resources.
+func (p *Player) Stop() error {
+	p.device = nil
+	return portaudio.Terminate()
+}
@@ -22,26 +22,147 @@ type Config struct {
 	MaxListenTime time.Duration `json:"max_listen_time"`
 
 	// LLM settings
+	LLMProvider   string        `json:"llm_provider"` // "ollama" or "openai" (llama.cpp server, LM Studio, vLLM)
+	LLMURL        string        `json:"llm_url"`      // base URL of the OpenAI-compatible server
+	LLMAPIKey     string        `json:"llm_api_key"`  // optional Bearer token for local proxies
 	OllamaURL     string        `json:"ollama_url"`
 	OllamaModel   string        `json:"ollama_model"`
-	OllamaTimeout time.Duration `json:"ollama_timeout"`
+	OllamaTimeout time.Duration `json:"ollama_timeout"` // deadline for each LLM call
+	// Resilience: retries on connection errors and 5xx, then the circuit
+	// breaker skips the LLM for the cool-down after repeated failures
+	LLMMaxRetries       int           `json:"llm_max_retries"`
+	LLMBreakerThreshold int           `json:"llm_breaker_threshold"`
+	LLMBreakerCooldown  time.Duration `json:"llm_breaker_cooldown"`
+	// Use native tool calls when the model reports the "tools" capability
+	OllamaNativeTools bool `json:"ollama_native_tools"`
+	// Model management: pull missing models at startup and keep the router model loaded
+	OllamaAutoPull     bool          `json:"ollama_auto_pull"`
+	OllamaKeepAlive    string        `json:"ollama_keep_alive"`    // e.g. "30m", "-1" keeps it forever
+	OllamaWarmInterval time.Duration `json:"ollama_warm_interval"` // 0 disables periodic warm-up
+
+	// Generation options per role; the router defaults to deterministic sampling
+	RouterOptions GenerationOptions `json:"router_options"`
+	VisionOptions GenerationOptions `json:"vision_options"`
+
+	// Fallback chains per role, tried in order when the primary model fails or
+	// times out. Entries are model names or {"model", "url", "provider"} objects
+	RouterFallbacks []ModelEndpoint `json:"router_fallbacks"`
+	VisionFallbacks []ModelEndpoint `json:"vision_fallbacks"`
+	// General questions (ANSWER action); an empty chat_model uses the router model
+	ChatModel     string            `json:"chat_model"`
+	ChatOptions   GenerationOptions `json:"chat_options"`
+	ChatFallbacks []ModelEndpoint   `json:"chat_fallbacks"`
 
 	// Vision model settings (для анализа скриншотов)
 	VisionModel   string `json:"vision_model"`   // e.g., "llava", "llava:13b", "bakllava"
 	VisionEnabled bool   `json:"vision_enabled"` // включить возможность анализа экрана
 
+	// Ask "да/нет" by voice before deleting, rewriting or overwriting;
+	// silence until the timeout counts as "нет"
+	ConfirmRisky   bool          `json:"confirm_risky"`
+	ConfirmTimeout time.Duration `json:"confirm_timeout"`
+
 	// Obsidian settings
 	VaultPath  string `json:"vault_path"`
 	NotePrefix string `json:"note_prefix"`
+	// Daily note layout as Go text/template; empty keeps {prefix}{YYYY-MM-DD}.md
+	// with "## HH:MM:SS" entries. See obsidian.TemplateData for the fields
+	NotePathTemplate   string `json:"note_path_template"`   // e.g. "Daily/{{.Date.Format \"2006/01\"}}/{{date}}.md"
+	NoteHeaderTemplate string `json:"note_header_template"` // contents of a new daily note
+	NoteHeaderFile     string `json:"note_header_file"`     // vault template file, overrides note_header_template
+	NoteEntryTemplate  string `json:"note_entry_template"`  // e.g. "- {{time}} {{.Content}}\n"
+	NoteEntryDelimiter string `json:"note_entry_delimiter"` // line prefix that starts an entry, e.g. "- "
+	NoteSection        string `json:"note_section"`         // heading entries go under, e.g. "## Inbox"; empty appends at the end
+	TasksFile          string `json:"tasks_file"`           // vault file new tasks are appended to; open tasks are read from the whole vault
+	// Notes written by name ("запиши в проект Альфа"), found by title or alias
+	NamedNoteFolder         string `json:"named_note_folder"`          // where missing named notes are created, e.g. "Projects"
+	NamedNoteHeaderTemplate string `json:"named_note_header_template"` // contents of a new named note
+	NamedNoteHeaderFile     string `json:"named_note_header_file"`     // vault template file, overrides named_note_header_template
+	NamedNoteEntryTemplate  string `json:"named_note_entry_template"`  // e.g. "- {{date}} {{.Content}}\n"
+	// Folder for screenshots saved to notes, relative to the vault or, with "./", to the
+	// daily note; empty follows Obsidian's "Default location for new attachments"
+	AttachmentFolder string `json:"attachment_folder"`
+	// Daily notes backend: "files" writes the vault directly, "rest" goes
+	// through the Local REST API plugin. Tasks, named notes and the index
+	// work with files only
+	ObsidianBackend string `json:"obsidian_backend"`
+	ObsidianAPIURL  string `json:"obsidian_api_url"`  // plugin address, e.g. "https://127.0.0.1:27124"
+	ObsidianAPIKey  string `json:"obsidian_api_key"`  // API key from the plugin settings
+	ObsidianAPICert string `json:"obsidian_api_cert"` // plugin certificate to trust, empty uses the system roots
 
 	// TTS settings
 	TTSEnabled bool   `json:"tts_enabled"`
 	TTSCommand string `json:"tts_command"` // e.g., "espeak-ng" or "piper"
+	// Voice settings
+	TTSVoice     string   `json:"tts_voice"`      // espeak voice name or piper model path
+	TTSRate      float64  `json:"tts_rate"`       // speaking rate multiplier, 1.0 is normal
+	TTSPitch     int      `json:"tts_pitch"`      // espeak pitch 0-99, 0 for default
+	TTSVolume    float64  `json:"tts_volume"`     // output gain 0-2, 1.0 is normal
+	TTSExtraArgs []string `json:"tts_extra_args"` // passed verbatim to the engine
+	TTSMaxLength int      `json:"tts_max_length"` // spoken text is cut on a sentence boundary, in characters
+	// In-process playback through PortAudio
+	TTSOutputDevice string   `json:"tts_output_device"` // substring of the device name, empty for default
+	TTSCacheDir     string   `json:"tts_cache_dir"`
+	TTSCachePhrases []string `json:"tts_cache_phrases"` // fixed phrases kept synthesized on disk
+
+	// LLM latency and token metrics, read by `bobik stats` and the control API
+	StatsPath string `json:"stats_path"`
+	// Local HTTP control API of the running agent, e.g. GET /stats; empty disables it
+	ControlAddr string `json:"control_addr"`
+
+	// Interactions kept across restarts, read by `bobik history`
+	HistoryPath      string        `json:"history_path"`      // empty disables the history
+	HistoryRetention time.Duration `json:"history_retention"` // older entries are pruned at startup, 0 keeps everything
+
+	// Questions about the vault (ASK_NOTES) use Ollama embeddings of the notes
+	EmbedModel    string        `json:"embed_model"`    // e.g. "nomic-embed-text", empty disables ASK_NOTES
+	IndexPath     string        `json:"index_path"`     // where the vectors are kept between runs
+	IndexInterval time.Duration `json:"index_interval"` // how often changed notes are re-indexed, 0 indexes once at startup
 
 	// Logging
 	LogLevel string `json:"log_level"` // debug, info, warn, error
 }
 
+// GenerationOptions tune sampling for one model role. Unset fields keep the
+// model defaults; set a field to null in JSON to drop a built-in default.
+type GenerationOptions struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	NumCtx      int      `json:"num_ctx,omitempty"`
+	NumPredict  int      `json:"num_predict,omitempty"`
+	Seed        *int     `json:"seed,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+	KeepAlive   string   `json:"keep_alive,omitempty"` // overrides ollama_keep_alive for this role
+	Think       *bool    `json:"think,omitempty"`      // Ollama think flag for reasoning models, unset keeps the model default
+}
+
+// ModelEndpoint is one entry of a fallback chain. Empty URL and Provider
+// fall back to llm_provider and its URL.
+type ModelEndpoint struct {
+	Model    string `json:"model"`
+	URL      string `json:"url,omitempty"`
+	Provider string `json:"provider,omitempty"`
+}
+
+// UnmarshalJSON accepts a plain model name as well as an object.
+func (e *ModelEndpoint) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*e = ModelEndpoint{Model: name}
+		return nil
+	}
+	type plain ModelEndpoint
+	return json.Unmarshal(data, (*plain)(e))
+}
+
+// String names the entry in logs and the tray.
+func (e ModelEndpoint) String() string {
+	if e.URL == "" {
+		return e.Model
+	}
+	return e.Model + "@" + e.URL
+}
+
 // Default returns the default configuration.
 func Default() *Config {
 	home, _ := os.UserHomeDir()
@@ -59,27 +180,67 @@ func Default() *Config {
 		MaxListenTime: 7 * time.Second,
 
 		// LLM
-		OllamaURL:     "http://localhost:11434",
-		OllamaModel:   "qwen3:8b",
-		OllamaTimeout: 60 * time.Second,
+		LLMProvider:         "ollama",
+		LLMURL:              "http://localhost:8080",
+		OllamaURL:           "http://localhost:11434",
+		OllamaModel:         "qwen3:8b",
+		OllamaTimeout:       60 * time.Second,
+		OllamaNativeTools:   true,
+		LLMMaxRetries:       2,
+		LLMBreakerThreshold: 3,
+		LLMBreakerCooldown:  30 * time.Second,
+		OllamaKeepAlive:     "30m",
+		OllamaWarmInterval:  10 * time.Minute,
+		// Same input, same route: makes routing reproducible for evaluation
+		RouterOptions: GenerationOptions{
+			Temperature: floatPtr(0),
+			Seed:        intPtr(42),
+		},
 
 		// Vision
 		VisionModel:   "llava",
 		VisionEnabled: false,
 
+		ConfirmRisky:   true,
+		ConfirmTimeout: 5 * time.Second,
+
 		// Obsidian
-		VaultPath:  filepath.Join(home, "SECOND_BRAIN", "SECOND_BRAIN"),
-		NotePrefix: "",
+		VaultPath:       filepath.Join(home, "SECOND_BRAIN", "SECOND_BRAIN"),
+		ObsidianBackend: "files",
+		ObsidianAPIURL:  "https://127.0.0.1:27124",
+		NotePrefix:      "",
+		TasksFile:       "Tasks.md",
 
 		// TTS
-		TTSEnabled: false,
-		TTSCommand: "espeak-ng",
+		TTSEnabled:   false,
+		TTSCommand:   "espeak-ng",
+		TTSRate:      1.0,
+		TTSVolume:    1.0,
+		TTSMaxLength: 300,
+		TTSCacheDir:  filepath.Join(home, ".cache", "bobik", "tts"),
+		TTSCachePhrases: []string{
+			"Записал", "Таймер запущен", "Отменено", "Нечего отменять",
+			"Скопировано", "Сохранено", "Секунду", "Задача добавлена", "Отметил",
+			"Сохранил экран в заметку",
+		},
+
+		StatsPath:        filepath.Join(home, ".local", "share", "bobik", "stats.json"),
+		ControlAddr:      "127.0.0.1:7461",
+		HistoryPath:      filepath.Join(home, ".local", "share", "bobik", "history.jsonl"),
+		HistoryRetention: 30 * 24 * time.Hour,
+
+		IndexPath:     filepath.Join(home, ".local", "share", "bobik", "index.json"),
+		IndexInterval: 5 * time.Minute,
 
 		// Logging
 		LogLevel: "info",
 	}
 }
 
+func floatPtr(v float64) *float64 { return &v }
+
+func intPtr(v int) *int { return &v }
+
 // Load loads configuration from file, applying env overrides.
 func Load(path string) (*Config, error) {
 	cfg := Default()
@@ -119,6 +280,15 @@ func (c *Config) applyEnvOverrides() {
 	if v := os.Getenv("BOBIK_MODEL_PATH"); v != "" {
 		c.ModelPath = v
 	}
+	if v := os.Getenv("BOBIK_LLM_PROVIDER"); v != "" {
+		c.LLMProvider = v
+	}
+	if v := os.Getenv("BOBIK_LLM_URL"); v != "" {
+		c.LLMURL = v
+	}
+	if v := os.Getenv("BOBIK_LLM_API_KEY"); v != "" {
+		c.LLMAPIKey = v
+	}
 	if v := os.Getenv("BOBIK_OLLAMA_URL"); v != "" {
 		c.OllamaURL = v
 	}
@@ -128,6 +298,9 @@ func (c *Config) applyEnvOverrides() {
 	if v := os.Getenv("BOBIK_VAULT_PATH"); v != "" {
 		c.VaultPath = v
 	}
+	if v := os.Getenv("BOBIK_OBSIDIAN_API_KEY"); v != "" {
+		c.ObsidianAPIKey = v
+	}
 	if v := os.Getenv("BOBIK_NOTE_PREFIX"); v != "" {
 		c.NotePrefix = v
 	}
@@ -137,6 +310,12 @@ func (c *Config) applyEnvOverrides() {
 	if v := os.Getenv("BOBIK_TTS_COMMAND"); v != "" {
 		c.TTSCommand = v
 	}
+	if v := os.Getenv("BOBIK_TTS_VOICE"); v != "" {
+		c.TTSVoice = v
+	}
+	if v := os.Getenv("BOBIK_TTS_OUTPUT_DEVICE"); v != "" {
+		c.TTSOutputDevice = v
+	}
 	if v := os.Getenv("BOBIK_LOG_LEVEL"); v != "" {
 		c.LogLevel = v
 	}
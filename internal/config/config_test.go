@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -96,3 +97,61 @@ func TestSave(t *testing.T) {
 		t.Errorf("expected OllamaModel saved-model, got %s", loaded.OllamaModel)
 	}
 }
+
+func TestRouterOptions(t *testing.T) {
+	cfg := Default()
+	if cfg.RouterOptions.Temperature == nil || *cfg.RouterOptions.Temperature != 0 {
+		t.Fatalf("expected deterministic router temperature by default, got %v", cfg.RouterOptions.Temperature)
+	}
+
+	cfgPath := filepath.Join(t.TempDir(), "config.json")
+	content := `{
+		"router_options": {"num_ctx": 4096, "seed": null},
+		"vision_options": {"temperature": 0.7, "stop": ["###"]}
+	}`
+	if err := os.WriteFile(cfgPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(cfgPath)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	r := cfg.RouterOptions
+	if r.NumCtx != 4096 || r.Temperature == nil || *r.Temperature != 0 {
+		t.Errorf("router options should merge with defaults, got %+v", r)
+	}
+	if r.Seed != nil {
+		t.Errorf("null should drop the default seed, got %d", *r.Seed)
+	}
+	v := cfg.VisionOptions
+	if v.Temperature == nil || *v.Temperature != 0.7 || len(v.Stop) != 1 {
+		t.Errorf("unexpected vision options: %+v", v)
+	}
+}
+
+func TestFallbackChains(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "config.json")
+	content := `{
+		"router_fallbacks": ["qwen3:4b", {"model": "qwen3:8b", "url": "http://gpu:11434"}],
+		"vision_fallbacks": [{"model": "llava", "provider": "openai", "url": "http://gpu:8080"}]
+	}`
+	if err := os.WriteFile(cfgPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(cfgPath)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	want := []ModelEndpoint{{Model: "qwen3:4b"}, {Model: "qwen3:8b", URL: "http://gpu:11434"}}
+	if !reflect.DeepEqual(cfg.RouterFallbacks, want) {
+		t.Errorf("expected %+v, got %+v", want, cfg.RouterFallbacks)
+	}
+	if len(cfg.VisionFallbacks) != 1 || cfg.VisionFallbacks[0].Provider != "openai" {
+		t.Errorf("unexpected vision fallbacks: %+v", cfg.VisionFallbacks)
+	}
+	if got := cfg.RouterFallbacks[1].String(); got != "qwen3:8b@http://gpu:11434" {
+		t.Errorf("unexpected entry name %q", got)
+	}
+}
@@ -0,0 +1,98 @@
+// Package control serves the local HTTP API of a running agent.
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"hey-bobik/internal/logger"
+	"hey-bobik/internal/stats"
+	"net"
+	"net/http"
+	"time"
+)
+
+var log = logger.New("control")
+
+// shutdownTimeout bounds how long open requests may take once the agent stops.
+const shutdownTimeout = 2 * time.Second
+
+// Server is the control API. It listens on a local address only, e.g.
+// 127.0.0.1:7461, and has no authentication.
+//
+//	GET /stats  LLM latency and token usage per model and per action
+type Server struct {
+	Addr  string
+	Stats *stats.Stats
+}
+
+// New creates a control API on addr serving the given metrics.
+func New(addr string, s *stats.Stats) *Server {
+	return &Server{Addr: addr, Stats: s}
+}
+
+// Run serves the API until ctx ends.
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Handler: s.Handler(), ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Info("Control API listening on http://%s", ln.Addr())
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Handler returns the routes of the API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /stats", s.handleStats)
+	return mux
+}
+
+// summary adds the derived figures `bobik stats` shows to the raw totals.
+type summary struct {
+	stats.Summary
+	AvgLatency time.Duration `json:"avg_latency"`
+	AvgLoad    time.Duration `json:"avg_load"`
+	PromptRate float64       `json:"prompt_tokens_per_second"`
+	EvalRate   float64       `json:"eval_tokens_per_second"`
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	snap := s.Stats.Snapshot()
+	resp := struct {
+		Since   time.Time          `json:"since"`
+		Models  map[string]summary `json:"models"`
+		Actions map[string]summary `json:"actions"`
+	}{
+		Since:   snap.Since,
+		Models:  summaries(snap.Models),
+		Actions: summaries(snap.Actions),
+	}
+	writeJSON(w, resp)
+}
+
+func summaries(rows map[string]stats.Summary) map[string]summary {
+	out := make(map[string]summary, len(rows))
+	for k, s := range rows {
+		out[k] = summary{Summary: s, AvgLatency: s.AvgLatency(), AvgLoad: s.AvgLoad(), PromptRate: s.PromptRate(), EvalRate: s.EvalRate()}
+	}
+	return out
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Warn("Failed to write response: %v", err)
+	}
+}
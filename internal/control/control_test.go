@@ -0,0 +1,53 @@
+package control
+
+import (
+	"encoding/json"
+	"hey-bobik/internal/llm"
+	"hey-bobik/internal/stats"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	s := stats.New("")
+	s.Record("TIMER", map[string][]llm.Usage{"qwen3:8b": {
+		{TotalDuration: time.Second, PromptEvalCount: 100, EvalCount: 10, EvalDuration: 500 * time.Millisecond},
+	}})
+	server := httptest.NewServer(New("", s).Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected response %s %q", resp.Status, resp.Header.Get("Content-Type"))
+	}
+
+	var got struct {
+		Models  map[string]map[string]float64 `json:"models"`
+		Actions map[string]map[string]float64 `json:"actions"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	model := got.Models["qwen3:8b"]
+	if model["calls"] != 1 || model["prompt_tokens"] != 100 || model["eval_tokens_per_second"] != 20 || model["avg_latency"] != float64(time.Second) {
+		t.Errorf("unexpected model summary %v", model)
+	}
+	if got.Actions["TIMER"]["calls"] != 1 {
+		t.Errorf("unexpected actions %v", got.Actions)
+	}
+
+	post, err := http.Post(server.URL+"/stats", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	post.Body.Close()
+	if post.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("only GET should be served, got %s", post.Status)
+	}
+}
@@ -0,0 +1,224 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hey-bobik/internal/logger"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+var log = logger.New("history")
+
+// Entry is one interaction with the agent.
+type Entry struct {
+	Time       time.Time `json:"time"`
+	Transcript string    `json:"transcript"`       // what the user said
+	Intent     string    `json:"intent,omitempty"` // parsed router answer, e.g. "ACTION: NOTE | ARG: ..."
+	Result     string    `json:"result,omitempty"` // description of what was done
+	Error      string    `json:"error,omitempty"`
+}
+
+// Succeeded reports whether the interaction did something, as opposed to a
+// failed or unrecognised command.
+func (e Entry) Succeeded() bool {
+	return e.Error == "" && e.Result != ""
+}
+
+// Store appends interactions to a JSONL file, one entry per line, and drops
+// entries older than the retention period.
+type Store struct {
+	path      string
+	retention time.Duration
+
+	mu sync.Mutex
+}
+
+// Open creates a store at path and prunes entries past retention.
+// A zero retention keeps everything.
+func Open(path string, retention time.Duration) *Store {
+	s := &Store{path: path, retention: retention}
+	if err := s.Prune(time.Now()); err != nil {
+		log.Warn("Failed to prune history %s: %v", path, err)
+	}
+	return s
+}
+
+// Append writes an entry to the end of the file.
+func (s *Store) Append(e Entry) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// Prune rewrites the file without entries older than the retention period.
+func (s *Store) Prune(now time.Time) error {
+	if s.retention <= 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := Load(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	cutoff := now.Add(-s.retention)
+	kept := entries[:0]
+	for _, e := range entries {
+		if !e.Time.Before(cutoff) {
+			kept = append(kept, e)
+		}
+	}
+	if len(kept) == len(entries) {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range kept {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	log.Debug("Pruned %d history entries", len(entries)-len(kept))
+	return os.Rename(tmp, s.path)
+}
+
+// Recent returns up to n of the latest entries, oldest first.
+func (s *Store) Recent(n int) ([]Entry, error) {
+	return s.recent(n, func(Entry) bool { return true })
+}
+
+// RecentSucceeded returns up to n of the latest successful entries, oldest
+// first, so failed commands don't take the place of older good ones.
+func (s *Store) RecentSucceeded(n int) ([]Entry, error) {
+	return s.recent(n, Entry.Succeeded)
+}
+
+func (s *Store) recent(n int, keep func(Entry) bool) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := Load(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	kept := entries[:0]
+	for _, e := range entries {
+		if keep(e) {
+			kept = append(kept, e)
+		}
+	}
+	return Last(kept, n), err
+}
+
+// Load reads all entries from a history file, skipping damaged lines,
+// e.g. one cut short by a crash.
+func Load(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			log.Warn("Skipping damaged history line %d: %v", line, err)
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return entries, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// Last returns up to n entries from the end; n <= 0 returns all of them.
+func Last(entries []Entry, n int) []Entry {
+	if n > 0 && len(entries) > n {
+		return entries[len(entries)-n:]
+	}
+	return entries
+}
+
+// Search returns entries whose transcript, intent, result or error contain
+// every word of the query, case-insensitively.
+func Search(entries []Entry, query string) []Entry {
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return entries
+	}
+
+	var found []Entry
+	for _, e := range entries {
+		text := strings.ToLower(strings.Join([]string{e.Transcript, e.Intent, e.Result, e.Error}, " "))
+		match := true
+		for _, w := range words {
+			if !strings.Contains(text, w) {
+				match = false
+				break
+			}
+		}
+		if match {
+			found = append(found, e)
+		}
+	}
+	return found
+}
+
+// Write prints entries as a table.
+func Write(w io.Writer, entries []Entry) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tCOMMAND\tINTENT\tRESULT\t")
+	for _, e := range entries {
+		result := e.Result
+		if e.Error != "" {
+			result = "ошибка: " + e.Error
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t\n", e.Time.Format("2006-01-02 15:04"), e.Transcript, e.Intent, result)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,120 @@
+package history
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppendAndRecent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bobik", "history.jsonl")
+	s := Open(path, 0)
+
+	for _, cmd := range []string{"запиши раз", "запиши два", "запиши три"} {
+		if err := s.Append(Entry{Transcript: cmd, Result: "ok"}); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	recent, err := s.Recent(2)
+	if err != nil {
+		t.Fatalf("Recent failed: %v", err)
+	}
+	if len(recent) != 2 || recent[0].Transcript != "запиши два" || recent[1].Transcript != "запиши три" {
+		t.Errorf("unexpected recent entries: %+v", recent)
+	}
+	if recent[0].Time.IsZero() {
+		t.Error("time should be set on append")
+	}
+}
+
+func TestRecentSucceeded(t *testing.T) {
+	s := Open(filepath.Join(t.TempDir(), "history.jsonl"), 0)
+	s.Append(Entry{Transcript: "запиши раз", Result: "ok"})
+	s.Append(Entry{Transcript: "запиши два", Result: "ok"})
+	s.Append(Entry{Transcript: "сломалась", Error: "timeout"})
+	s.Append(Entry{Transcript: "непонятно"})
+
+	recent, err := s.RecentSucceeded(2)
+	if err != nil {
+		t.Fatalf("RecentSucceeded failed: %v", err)
+	}
+	if len(recent) != 2 || recent[0].Transcript != "запиши раз" || recent[1].Transcript != "запиши два" {
+		t.Errorf("expected the last two successful entries, got %+v", recent)
+	}
+}
+
+func TestRecentWithoutFile(t *testing.T) {
+	s := Open(filepath.Join(t.TempDir(), "none.jsonl"), time.Hour)
+	recent, err := s.Recent(10)
+	if err != nil || len(recent) != 0 {
+		t.Errorf("expected empty history, got %+v, %v", recent, err)
+	}
+}
+
+func TestRetention(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	now := time.Now()
+	s := Open(path, 0)
+	s.Append(Entry{Time: now.Add(-48 * time.Hour), Transcript: "старое"})
+	s.Append(Entry{Time: now.Add(-time.Hour), Transcript: "свежее"})
+
+	Open(path, 24*time.Hour)
+
+	entries, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Transcript != "свежее" {
+		t.Errorf("expected only the fresh entry, got %+v", entries)
+	}
+}
+
+func TestLoadSkipsDamagedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	content := `{"transcript":"раз"}` + "\n" + `{"transcript":"дв` + "\n\n" + `{"transcript":"три"}` + "\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(entries) != 2 || entries[1].Transcript != "три" {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	entries := []Entry{
+		{Transcript: "запиши купить молоко", Result: "Записано: купить молоко"},
+		{Transcript: "поставь таймер на 5 минут", Intent: "ACTION: TIMER | ARG: 5m"},
+		{Transcript: "что на экране", Error: "Vision timeout"},
+	}
+
+	if got := Search(entries, "Молоко купить"); len(got) != 1 || got[0].Transcript != entries[0].Transcript {
+		t.Errorf("expected the note, got %+v", got)
+	}
+	if got := Search(entries, "timer"); len(got) != 1 {
+		t.Errorf("intent should be searchable, got %+v", got)
+	}
+	if got := Search(entries, "timeout"); len(got) != 1 {
+		t.Errorf("errors should be searchable, got %+v", got)
+	}
+	if got := Search(entries, " "); len(got) != 3 {
+		t.Errorf("empty query should return everything, got %d", len(got))
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	Write(&buf, []Entry{{Time: time.Date(2026, 1, 2, 3, 4, 0, 0, time.Local), Transcript: "что на экране", Error: "timeout"}})
+	out := buf.String()
+	if !strings.Contains(out, "2026-01-02 03:04") || !strings.Contains(out, "ошибка: timeout") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
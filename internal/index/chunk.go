@@ -0,0 +1,117 @@
+package index
+
+import (
+	"strings"
+)
+
+// maxChunkRunes bounds the text embedded as one vector, small enough for
+// embedding models with a 512 token context.
+const maxChunkRunes = 800
+
+// Split cuts a note into chunks of whole paragraphs. Each chunk starts with
+// the note title and the heading it is under, so a chunk makes sense alone.
+func Split(title, text string) []string {
+	var chunks []string
+	var heading string
+	var current []string
+	size := 0
+
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		prefix := title
+		if heading != "" {
+			prefix += " / " + heading
+		}
+		chunks = append(chunks, prefix+"\n"+strings.Join(current, "\n\n"))
+		current, size = nil, 0
+	}
+
+	for _, p := range paragraphs(stripFrontmatter(text)) {
+		if h, ok := headingText(p); ok {
+			flush()
+			heading = h
+			continue
+		}
+		for _, piece := range cut(p, maxChunkRunes) {
+			n := len([]rune(piece))
+			if size > 0 && size+n > maxChunkRunes {
+				flush()
+			}
+			current = append(current, piece)
+			size += n
+		}
+	}
+	flush()
+	return chunks
+}
+
+// paragraphs splits text at blank lines and before headings.
+func paragraphs(text string) []string {
+	var out []string
+	var current []string
+	flush := func() {
+		if p := strings.TrimSpace(strings.Join(current, "\n")); p != "" {
+			out = append(out, p)
+		}
+		current = nil
+	}
+	for _, line := range strings.Split(text, "\n") {
+		_, isHeading := headingText(line)
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+		case isHeading:
+			flush()
+			out = append(out, strings.TrimSpace(line))
+		default:
+			current = append(current, line)
+		}
+	}
+	flush()
+	return out
+}
+
+// headingText returns the text of a Markdown heading line.
+func headingText(line string) (string, bool) {
+	trimmed := strings.TrimLeft(line, "#")
+	level := len(line) - len(trimmed)
+	if level == 0 || level > 6 || !strings.HasPrefix(trimmed, " ") || strings.Contains(line, "\n") {
+		return "", false
+	}
+	return strings.TrimSpace(trimmed), true
+}
+
+// stripFrontmatter drops the YAML block at the top of a note.
+func stripFrontmatter(text string) string {
+	if !strings.HasPrefix(text, "---\n") {
+		return text
+	}
+	if end := strings.Index(text[4:], "\n---"); end >= 0 {
+		rest := text[4+end+4:]
+		return strings.TrimPrefix(rest, "\n")
+	}
+	return text
+}
+
+// cut splits a long paragraph into pieces of at most max runes at spaces.
+func cut(p string, max int) []string {
+	runes := []rune(p)
+	var out []string
+	for len(runes) > max {
+		at := max
+		for i := max; i > max/2; i-- {
+			if runes[i] == ' ' || runes[i] == '\n' {
+				at = i
+				break
+			}
+		}
+		out = append(out, strings.TrimSpace(string(runes[:at])))
+		runes = runes[at:]
+	}
+	if rest := strings.TrimSpace(string(runes)); rest != "" {
+		out = append(out, rest)
+	}
+	return out
+}
@@ -0,0 +1,304 @@
+package index
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hey-bobik/internal/logger"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var log = logger.New("index")
+
+// embedBatch is how many chunks are sent in one embed request.
+const embedBatch = 16
+
+// Embedder turns texts into vectors, e.g. llm.Client with an embedding model.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// Result is a chunk of a note matching a query.
+type Result struct {
+	File  string // path relative to the vault
+	Text  string
+	Score float64 // cosine similarity to the query
+}
+
+type chunk struct {
+	Text   string    `json:"text"`
+	Vector []float32 `json:"vector"`
+}
+
+type fileEntry struct {
+	ModTime time.Time `json:"mod_time"`
+	Size    int64     `json:"size"`
+	Chunks  []chunk   `json:"chunks"`
+}
+
+// stored is the on-disk form of the index.
+type stored struct {
+	Model string                `json:"model"`
+	Files map[string]*fileEntry `json:"files"`
+}
+
+// Index keeps embeddings of the vault's Markdown notes and finds the chunks
+// closest to a question. Only notes changed since the last update are
+// embedded again.
+type Index struct {
+	VaultPath string
+
+	path     string
+	model    string
+	embedder Embedder
+
+	updateMu sync.Mutex // one update at a time
+	mu       sync.Mutex
+	files    map[string]*fileEntry
+}
+
+// Open loads the index stored at path. An index built with another model is
+// discarded, as its vectors can't be compared. An empty path keeps the index
+// in memory only.
+func Open(path, vaultPath, model string, embedder Embedder) *Index {
+	x := &Index{
+		VaultPath: vaultPath,
+		path:      path,
+		model:     model,
+		embedder:  embedder,
+		files:     map[string]*fileEntry{},
+	}
+	if path == "" {
+		return x
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warn("Failed to read index %s: %v", path, err)
+		}
+		return x
+	}
+	var s stored
+	if err := json.Unmarshal(data, &s); err != nil {
+		log.Warn("Index %s is damaged, rebuilding: %v", path, err)
+		return x
+	}
+	if s.Model != model {
+		log.Info("Index was built with %s, rebuilding with %s", s.Model, model)
+		return x
+	}
+	if s.Files != nil {
+		x.files = s.Files
+	}
+	return x
+}
+
+// Len returns the number of indexed chunks.
+func (x *Index) Len() int {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	n := 0
+	for _, f := range x.files {
+		n += len(f.Chunks)
+	}
+	return n
+}
+
+// Update embeds new and changed notes and forgets deleted ones. It returns
+// how many notes changed. Progress is saved even when embedding fails.
+func (x *Index) Update(ctx context.Context) (int, error) {
+	x.updateMu.Lock()
+	defer x.updateMu.Unlock()
+
+	seen := map[string]bool{}
+	var changed []string
+	stats := map[string]fs.FileInfo{}
+	err := filepath.WalkDir(x.VaultPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != x.VaultPath && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(d.Name(), ".md") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(x.VaultPath, path)
+		seen[rel] = true
+		stats[rel] = info
+
+		x.mu.Lock()
+		old, ok := x.files[rel]
+		x.mu.Unlock()
+		if !ok || !old.ModTime.Equal(info.ModTime()) || old.Size != info.Size() {
+			changed = append(changed, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read vault: %w", err)
+	}
+
+	x.mu.Lock()
+	removed := 0
+	for rel := range x.files {
+		if !seen[rel] {
+			delete(x.files, rel)
+			removed++
+		}
+	}
+	x.mu.Unlock()
+
+	updated := 0
+	for _, rel := range changed {
+		if err = x.embedFile(ctx, rel, stats[rel]); err != nil {
+			err = fmt.Errorf("failed to index %s: %w", rel, err)
+			break
+		}
+		updated++
+	}
+
+	if updated+removed > 0 {
+		log.Info("Indexed %d changed note(s), removed %d", updated, removed)
+		if saveErr := x.save(); saveErr != nil && err == nil {
+			err = saveErr
+		}
+	}
+	return updated + removed, err
+}
+
+func (x *Index) embedFile(ctx context.Context, rel string, info fs.FileInfo) error {
+	data, err := os.ReadFile(filepath.Join(x.VaultPath, rel))
+	if err != nil {
+		return err
+	}
+	title := strings.TrimSuffix(filepath.Base(rel), ".md")
+	texts := Split(title, string(data))
+
+	entry := &fileEntry{ModTime: info.ModTime(), Size: info.Size()}
+	for start := 0; start < len(texts); start += embedBatch {
+		end := min(start+embedBatch, len(texts))
+		vectors, err := x.embedder.Embed(ctx, texts[start:end])
+		if err != nil {
+			return err
+		}
+		for i, v := range vectors {
+			entry.Chunks = append(entry.Chunks, chunk{Text: texts[start+i], Vector: v})
+		}
+	}
+
+	x.mu.Lock()
+	x.files[rel] = entry
+	x.mu.Unlock()
+	return nil
+}
+
+// Watch updates the index now and then every interval until ctx is done,
+// so edits made in Obsidian are picked up.
+func (x *Index) Watch(ctx context.Context, interval time.Duration) {
+	update := func() {
+		if _, err := x.Update(ctx); err != nil && ctx.Err() == nil {
+			log.Warn("Index update failed: %v", err)
+		}
+	}
+
+	update()
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			update()
+		}
+	}
+}
+
+// Search returns up to k chunks most similar to the query, best first.
+func (x *Index) Search(ctx context.Context, query string, k int) ([]Result, error) {
+	vectors, err := x.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	q := vectors[0]
+
+	x.mu.Lock()
+	var results []Result
+	for rel, f := range x.files {
+		for _, c := range f.Chunks {
+			if score := cosine(q, c.Vector); score > 0 {
+				results = append(results, Result{File: rel, Text: c.Text, Score: score})
+			}
+		}
+	}
+	x.mu.Unlock()
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].File < results[j].File
+	})
+	if k > 0 && len(results) > k {
+		results = results[:k]
+	}
+	return results, nil
+}
+
+// save writes the index atomically so a crash can't leave half a file.
+func (x *Index) save() error {
+	if x.path == "" {
+		return nil
+	}
+	x.mu.Lock()
+	data, err := json.Marshal(stored{Model: x.model, Files: x.files})
+	x.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(x.path), 0755); err != nil {
+		return err
+	}
+	tmp := x.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, x.path)
+}
+
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,142 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// wordEmbedder counts words of a small vocabulary by their first four letters.
+type wordEmbedder struct {
+	calls  int
+	inputs int
+	err    error
+}
+
+var vocabulary = []string{"созв", "подр", "смет", "хлеб", "моло", "рели"}
+
+func (e *wordEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	e.calls++
+	e.inputs += len(texts)
+	if e.err != nil {
+		return nil, e.err
+	}
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		v := make([]float32, len(vocabulary))
+		for _, w := range strings.Fields(strings.ToLower(text)) {
+			for j, stem := range vocabulary {
+				if strings.HasPrefix(w, stem) {
+					v[j]++
+				}
+			}
+		}
+		vectors[i] = v
+	}
+	return vectors, nil
+}
+
+func TestSplit(t *testing.T) {
+	text := "---\ntags: [work]\n---\nВступление.\n\n## Созвон\nОбсудили смету.\n\nДоговорились на пятницу.\n# Итоги\n" +
+		strings.Repeat("слово ", 200)
+	chunks := Split("2026-10-16", text)
+
+	if len(chunks) != 4 {
+		t.Fatalf("expected 4 chunks, got %d: %q", len(chunks), chunks)
+	}
+	if chunks[0] != "2026-10-16\nВступление." {
+		t.Errorf("frontmatter should be dropped, got %q", chunks[0])
+	}
+	if chunks[1] != "2026-10-16 / Созвон\nОбсудили смету.\n\nДоговорились на пятницу." {
+		t.Errorf("paragraphs under a heading should be merged, got %q", chunks[1])
+	}
+	for _, c := range chunks[2:] {
+		if !strings.HasPrefix(c, "2026-10-16 / Итоги\n") || len([]rune(c)) > maxChunkRunes+30 {
+			t.Errorf("long paragraph should be cut, got %d runes", len([]rune(c)))
+		}
+	}
+}
+
+func TestUpdateIncremental(t *testing.T) {
+	vault := t.TempDir()
+	path := filepath.Join(t.TempDir(), "index.json")
+	write := func(name, text string, mod time.Time) {
+		p := filepath.Join(vault, name)
+		os.MkdirAll(filepath.Dir(p), 0755)
+		os.WriteFile(p, []byte(text), 0644)
+		os.Chtimes(p, mod, mod)
+	}
+	mod := time.Date(2026, 10, 16, 10, 0, 0, 0, time.Local)
+	write("2026-10-16.md", "## 10:00\nСозвон с подрядчиком, обсудили смету\n", mod)
+	write("Покупки.md", "Купить хлеб и молоко\n", mod)
+	write(".obsidian/workspace.md", "служебное\n", mod)
+
+	e := &wordEmbedder{}
+	x := Open(path, vault, "nomic-embed-text", e)
+	if n, err := x.Update(context.Background()); n != 2 || err != nil {
+		t.Fatalf("expected 2 indexed notes, got %d, %v", n, err)
+	}
+	if x.Len() != 2 {
+		t.Errorf("expected 2 chunks, got %d", x.Len())
+	}
+
+	// Nothing changed, nothing is embedded again
+	e.calls = 0
+	if n, _ := x.Update(context.Background()); n != 0 || e.calls != 0 {
+		t.Errorf("unchanged notes were embedded again: %d notes, %d calls", n, e.calls)
+	}
+
+	write("Покупки.md", "Купить хлеб\n", mod.Add(time.Hour))
+	os.Remove(filepath.Join(vault, "2026-10-16.md"))
+	e.inputs = 0
+	if n, _ := x.Update(context.Background()); n != 2 || e.inputs != 1 {
+		t.Errorf("expected one change and one removal with 1 embedded chunk, got %d notes, %d chunks", n, e.inputs)
+	}
+
+	// The saved index is reused by the same model only
+	if Open(path, vault, "nomic-embed-text", e).Len() != 1 {
+		t.Error("the index should be loaded from disk")
+	}
+	if Open(path, vault, "bge-m3", e).Len() != 0 {
+		t.Error("an index of another model should be discarded")
+	}
+}
+
+func TestUpdateKeepsProgress(t *testing.T) {
+	vault := t.TempDir()
+	os.WriteFile(filepath.Join(vault, "a.md"), []byte("хлеб\n"), 0644)
+
+	e := &wordEmbedder{err: errors.New("connection refused")}
+	x := Open("", vault, "m", e)
+	if _, err := x.Update(context.Background()); err == nil {
+		t.Fatal("expected the embed error")
+	}
+
+	// The failed note is retried on the next update
+	e.err = nil
+	if n, err := x.Update(context.Background()); n != 1 || err != nil {
+		t.Errorf("expected the note to be indexed, got %d, %v", n, err)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	vault := t.TempDir()
+	os.WriteFile(filepath.Join(vault, "2026-10-16.md"), []byte("Созвон с подрядчиком, обсудили смету\n"), 0644)
+	os.WriteFile(filepath.Join(vault, "Покупки.md"), []byte("Купить хлеб и молоко\n"), 0644)
+	os.WriteFile(filepath.Join(vault, "Бобик.md"), []byte("Релиз в пятницу\n"), 0644)
+
+	x := Open("", vault, "m", &wordEmbedder{})
+	x.Update(context.Background())
+
+	results, err := x.Search(context.Background(), "когда был созвон с подрядчиком", 2)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 1 || results[0].File != "2026-10-16.md" || results[0].Score < 0.5 {
+		t.Errorf("unexpected results %+v", results)
+	}
+}
@@ -0,0 +1,39 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+)
+
+// EmbedRequest represents the request body for Ollama's embed API.
+type EmbedRequest struct {
+	Model     string   `json:"model"`
+	Input     []string `json:"input"`
+	KeepAlive string   `json:"keep_alive,omitempty"`
+}
+
+// EmbedResponse represents the response body from Ollama's embed API.
+type EmbedResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+// Embed returns one embedding vector per input text, in the same order.
+func (c *Client) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	reqBody := EmbedRequest{
+		Model:     c.Model,
+		Input:     texts,
+		KeepAlive: c.KeepAlive,
+	}
+
+	var resp EmbedResponse
+	if err := c.post(ctx, "/api/embed", reqBody, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("embed returned %d vectors for %d inputs", len(resp.Embeddings), len(texts))
+	}
+	return resp.Embeddings, nil
+}
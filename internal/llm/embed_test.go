@@ -0,0 +1,44 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEmbed(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embed" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		var req EmbedRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "nomic-embed-text" || len(req.Input) != 2 {
+			t.Errorf("unexpected request %+v", req)
+		}
+		fmt.Fprintln(w, `{"embeddings":[[0.1,0.2],[0.3,0.4]]}`)
+	}))
+	defer ts.Close()
+
+	vectors, err := New(ts.URL, "nomic-embed-text").Embed(context.Background(), []string{"раз", "два"})
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+	if len(vectors) != 2 || vectors[1][0] != 0.3 {
+		t.Errorf("unexpected vectors %v", vectors)
+	}
+}
+
+func TestEmbedCountMismatch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"embeddings":[[0.1,0.2]]}`)
+	}))
+	defer ts.Close()
+
+	if _, err := New(ts.URL, "m").Embed(context.Background(), []string{"раз", "два"}); err == nil {
+		t.Error("expected an error when the server returns fewer vectors")
+	}
+}
@@ -0,0 +1,252 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// errNoToolModel is returned by Fallback.ChatWithTools when no entry can call tools.
+var errNoToolModel = errors.New("no model in the chain supports tools")
+
+// Backend is what every entry of a fallback chain must implement.
+// Client and OpenAIClient both do.
+type Backend interface {
+	Chat(ctx context.Context, messages []Message) (string, error)
+	GenerateWithImages(ctx context.Context, system, prompt string, images []string) (string, error)
+}
+
+type streamingBackend interface {
+	ChatStream(ctx context.Context, messages []Message, onChunk func(string) error) (string, error)
+	GenerateWithImagesStream(ctx context.Context, system, prompt string, images []string, onChunk func(string) error) (string, error)
+}
+
+type toolBackend interface {
+	ChatWithTools(ctx context.Context, messages []Message, tools []ToolDefinition) (Message, error)
+	SupportsTools(ctx context.Context) bool
+}
+
+// Entry is one model of a fallback chain.
+type Entry struct {
+	Name   string // shown in logs and the tray, e.g. "qwen3:8b" or "qwen3:4b@http://host:11434"
+	Client Backend
+}
+
+// Fallback tries its entries in order until one answers, so routing keeps
+// working when the primary model isn't loaded or runs out of VRAM.
+type Fallback struct {
+	Role    string // router, vision or chat
+	Entries []Entry
+	// OnAnswer is called when the answering entry changes; degraded is true
+	// while a model other than the first one answers.
+	OnAnswer func(role, name string, degraded bool)
+
+	mu           sync.Mutex
+	last         int  // index of the entry that answered last, -1 before the first call
+	lastDegraded bool // whether that answer was degraded
+}
+
+// NewFallback creates a chain; the first entry is the primary model.
+func NewFallback(role string, entries ...Entry) *Fallback {
+	return &Fallback{Role: role, Entries: entries, last: -1}
+}
+
+// LastModel returns the name of the entry that answered the last call.
+func (f *Fallback) LastModel() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.last < 0 {
+		return ""
+	}
+	return f.Entries[f.last].Name
+}
+
+// try calls each entry until one succeeds. The errors of all entries are
+// joined, so errors.Is still finds ErrTimeout and friends.
+func (f *Fallback) try(ctx context.Context, call func(Entry) error) error {
+	all := make([]int, len(f.Entries))
+	for i := range all {
+		all[i] = i
+	}
+	return f.tryEntries(ctx, all, call)
+}
+
+// tryEntries is try over some of the entries, given by index. The chain is
+// degraded when any but the first of them answers.
+func (f *Fallback) tryEntries(ctx context.Context, entries []int, call func(Entry) error) error {
+	var errs []error
+	for n, i := range entries {
+		e := f.Entries[i]
+		err := call(e)
+		if err == nil {
+			f.answered(i, n > 0)
+			return nil
+		}
+		if ctx.Err() != nil {
+			// The caller gave up, the next model won't be faster
+			return err
+		}
+		var partial partialStreamError
+		if errors.As(err, &partial) {
+			// Half an answer was already delivered, switching models would repeat it
+			return partial.err
+		}
+		if n < len(entries)-1 {
+			log.Warn("%s model %s failed, trying %s: %v", f.Role, e.Name, f.Entries[entries[n+1]].Name, err)
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", e.Name, err))
+	}
+	return errors.Join(errs...)
+}
+
+func (f *Fallback) answered(i int, degraded bool) {
+	f.mu.Lock()
+	changed := f.last != i || f.lastDegraded != degraded
+	f.last, f.lastDegraded = i, degraded
+	f.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	name := f.Entries[i].Name
+	if degraded {
+		log.Warn("%s is degraded, answered by fallback model %s", f.Role, name)
+	} else {
+		log.Info("%s answered by %s", f.Role, name)
+	}
+	if f.OnAnswer != nil {
+		f.OnAnswer(f.Role, name, degraded)
+	}
+}
+
+// Generate sends a prompt and returns the first successful answer.
+func (f *Fallback) Generate(ctx context.Context, system, prompt string) (string, error) {
+	return f.GenerateWithImages(ctx, system, prompt, nil)
+}
+
+// GenerateWithImages sends a prompt with images through the chain.
+func (f *Fallback) GenerateWithImages(ctx context.Context, system, prompt string, images []string) (string, error) {
+	var out string
+	err := f.try(ctx, func(e Entry) error {
+		var err error
+		out, err = e.Client.GenerateWithImages(ctx, system, prompt, images)
+		return err
+	})
+	return out, err
+}
+
+// Chat sends a conversation through the chain.
+func (f *Fallback) Chat(ctx context.Context, messages []Message) (string, error) {
+	var out string
+	err := f.try(ctx, func(e Entry) error {
+		var err error
+		out, err = e.Client.Chat(ctx, messages)
+		return err
+	})
+	return out, err
+}
+
+// ChatStream streams from the first entry that answers. Entries without
+// streaming deliver their whole answer as one chunk.
+func (f *Fallback) ChatStream(ctx context.Context, messages []Message, onChunk func(string) error) (string, error) {
+	var out string
+	err := f.try(ctx, func(e Entry) error {
+		var err error
+		if s, ok := e.Client.(streamingBackend); ok {
+			out, err = streamOnce(onChunk, func(chunk func(string) error) (string, error) {
+				return s.ChatStream(ctx, messages, chunk)
+			})
+			return err
+		}
+		if out, err = e.Client.Chat(ctx, messages); err != nil {
+			return err
+		}
+		return deliver(onChunk, out)
+	})
+	return out, err
+}
+
+// GenerateWithImagesStream is the streaming variant of GenerateWithImages.
+func (f *Fallback) GenerateWithImagesStream(ctx context.Context, system, prompt string, images []string, onChunk func(string) error) (string, error) {
+	var out string
+	err := f.try(ctx, func(e Entry) error {
+		var err error
+		if s, ok := e.Client.(streamingBackend); ok {
+			out, err = streamOnce(onChunk, func(chunk func(string) error) (string, error) {
+				return s.GenerateWithImagesStream(ctx, system, prompt, images, chunk)
+			})
+			return err
+		}
+		if out, err = e.Client.GenerateWithImages(ctx, system, prompt, images); err != nil {
+			return err
+		}
+		return deliver(onChunk, out)
+	})
+	return out, err
+}
+
+// ChatWithTools goes through the entries that support native tool calls.
+// Models without tools are skipped rather than failed, so a primary model
+// that can't call tools doesn't put the chain in degraded mode.
+func (f *Fallback) ChatWithTools(ctx context.Context, messages []Message, tools []ToolDefinition) (Message, error) {
+	var capable []int
+	for i, e := range f.Entries {
+		if t, ok := e.Client.(toolBackend); ok && t.SupportsTools(ctx) {
+			capable = append(capable, i)
+		}
+	}
+	if len(capable) == 0 {
+		return Message{}, errNoToolModel
+	}
+
+	var out Message
+	err := f.tryEntries(ctx, capable, func(e Entry) error {
+		var err error
+		out, err = e.Client.(toolBackend).ChatWithTools(ctx, messages, tools)
+		return err
+	})
+	return out, err
+}
+
+// SupportsTools reports whether any model in the chain can call tools.
+func (f *Fallback) SupportsTools(ctx context.Context) bool {
+	for _, e := range f.Entries {
+		if t, ok := e.Client.(toolBackend); ok && t.SupportsTools(ctx) {
+			return true
+		}
+	}
+	return false
+}
+
+// partialStreamError marks a stream that failed after delivering chunks.
+type partialStreamError struct{ err error }
+
+func (e partialStreamError) Error() string { return e.err.Error() }
+
+// streamOnce runs a stream and marks failures after the first chunk as partial.
+func streamOnce(onChunk func(string) error, run func(func(string) error) (string, error)) (string, error) {
+	started := false
+	out, err := run(func(chunk string) error {
+		started = true
+		if onChunk == nil {
+			return nil
+		}
+		return onChunk(chunk)
+	})
+	if err != nil && started {
+		return out, partialStreamError{err}
+	}
+	return out, err
+}
+
+// deliver passes a complete answer as a single chunk.
+func deliver(onChunk func(string) error, text string) error {
+	if onChunk == nil || text == "" {
+		return nil
+	}
+	if err := onChunk(text); err != nil && !errors.Is(err, ErrStopStream) {
+		return partialStreamError{err}
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// stubBackend answers with reply or fails with err, optionally after some chunks.
+type stubBackend struct {
+	reply  string
+	err    error
+	chunks []string
+	calls  int
+}
+
+func (s *stubBackend) Chat(ctx context.Context, messages []Message) (string, error) {
+	s.calls++
+	return s.reply, s.err
+}
+
+func (s *stubBackend) GenerateWithImages(ctx context.Context, system, prompt string, images []string) (string, error) {
+	s.calls++
+	return s.reply, s.err
+}
+
+func (s *stubBackend) ChatStream(ctx context.Context, messages []Message, onChunk func(string) error) (string, error) {
+	s.calls++
+	for _, c := range s.chunks {
+		if err := onChunk(c); err != nil {
+			return "", err
+		}
+	}
+	return s.reply, s.err
+}
+
+func (s *stubBackend) GenerateWithImagesStream(ctx context.Context, system, prompt string, images []string, onChunk func(string) error) (string, error) {
+	return s.ChatStream(ctx, nil, onChunk)
+}
+
+func TestFallbackUsesNextModel(t *testing.T) {
+	primary := &stubBackend{err: fmt.Errorf("%w: out of memory", ErrServerDown)}
+	second := &stubBackend{reply: "ACTION: NOTE | ARG: тест"}
+
+	var answered []string
+	f := NewFallback("router", Entry{"qwen3:8b", primary}, Entry{"qwen3:4b", second})
+	f.OnAnswer = func(role, name string, degraded bool) {
+		answered = append(answered, fmt.Sprintf("%s %s %v", role, name, degraded))
+	}
+
+	out, err := f.Chat(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != second.reply {
+		t.Errorf("expected fallback answer, got %q", out)
+	}
+	if f.LastModel() != "qwen3:4b" {
+		t.Errorf("expected qwen3:4b to be recorded, got %q", f.LastModel())
+	}
+
+	// The same model answering again doesn't repeat the notification
+	f.Chat(context.Background(), nil)
+	primary.err = nil
+	f.Chat(context.Background(), nil)
+
+	want := []string{"router qwen3:4b true", "router qwen3:8b false"}
+	if strings.Join(answered, ";") != strings.Join(want, ";") {
+		t.Errorf("expected %q, got %q", want, answered)
+	}
+}
+
+// toolStub is a stubBackend that can call tools.
+type toolStub struct {
+	stubBackend
+}
+
+func (s *toolStub) SupportsTools(ctx context.Context) bool { return true }
+
+func (s *toolStub) ChatWithTools(ctx context.Context, messages []Message, tools []ToolDefinition) (Message, error) {
+	s.calls++
+	return Message{Role: "assistant", Content: s.reply}, s.err
+}
+
+func TestFallbackToolsSkipModelsWithoutTools(t *testing.T) {
+	primary := &stubBackend{reply: "ACTION: TIME | ARG: none"}
+	tools := &toolStub{stubBackend{reply: "ok"}}
+
+	var degraded []bool
+	f := NewFallback("router", Entry{"gemma3", primary}, Entry{"qwen3:8b", tools})
+	f.OnAnswer = func(role, name string, d bool) { degraded = append(degraded, d) }
+
+	msg, err := f.ChatWithTools(context.Background(), nil, nil)
+	if err != nil || msg.Content != "ok" {
+		t.Fatalf("ChatWithTools = %+v, %v", msg, err)
+	}
+	if primary.calls != 0 {
+		t.Error("a model without tools should not be called")
+	}
+	if len(degraded) != 1 || degraded[0] {
+		t.Errorf("the first model with tools answering is not degraded, got %v", degraded)
+	}
+
+	_, err = NewFallback("router", Entry{"gemma3", primary}).ChatWithTools(context.Background(), nil, nil)
+	if !errors.Is(err, errNoToolModel) {
+		t.Errorf("expected errNoToolModel, got %v", err)
+	}
+}
+
+func TestFallbackDegradedByCall(t *testing.T) {
+	primary := &stubBackend{err: ErrServerDown}
+	tools := &toolStub{stubBackend{reply: "ok"}}
+
+	var answered []string
+	f := NewFallback("router", Entry{"gemma3", primary}, Entry{"qwen3:8b", tools})
+	f.OnAnswer = func(role, name string, d bool) { answered = append(answered, fmt.Sprintf("%s %v", name, d)) }
+
+	// The same model answers a tool call as the primary and a chat as a fallback
+	f.ChatWithTools(context.Background(), nil, nil)
+	f.Chat(context.Background(), nil)
+	f.ChatWithTools(context.Background(), nil, nil)
+
+	want := []string{"qwen3:8b false", "qwen3:8b true", "qwen3:8b false"}
+	if strings.Join(answered, ";") != strings.Join(want, ";") {
+		t.Errorf("expected %q, got %q", want, answered)
+	}
+}
+
+func TestFallbackJoinsErrors(t *testing.T) {
+	f := NewFallback("vision",
+		Entry{"llava", &stubBackend{err: ErrTimeout}},
+		Entry{"moondream", &stubBackend{err: ErrModelNotFound}},
+	)
+
+	_, err := f.GenerateWithImages(context.Background(), "", "что на экране", nil)
+	if !errors.Is(err, ErrTimeout) || !errors.Is(err, ErrModelNotFound) {
+		t.Errorf("expected both errors to be kept, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "moondream") {
+		t.Errorf("error should name the models, got %v", err)
+	}
+	if f.LastModel() != "" {
+		t.Errorf("no model answered, got %q", f.LastModel())
+	}
+}
+
+func TestFallbackStopsWhenCallerGivesUp(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	second := &stubBackend{reply: "ok"}
+	f := NewFallback("router", Entry{"a", &stubBackend{err: ErrTimeout}}, Entry{"b", second})
+	if _, err := f.Chat(ctx, nil); err == nil {
+		t.Fatal("expected an error")
+	}
+	if second.calls != 0 {
+		t.Error("fallback should not be tried after the context is cancelled")
+	}
+}
+
+func TestFallbackStream(t *testing.T) {
+	t.Run("before first chunk", func(t *testing.T) {
+		f := NewFallback("chat",
+			Entry{"a", &stubBackend{err: ErrServerDown}},
+			Entry{"b", &stubBackend{reply: "Привет.", chunks: []string{"При", "вет."}}},
+		)
+		var got strings.Builder
+		out, err := f.ChatStream(context.Background(), nil, func(s string) error {
+			got.WriteString(s)
+			return nil
+		})
+		if err != nil || out != "Привет." || got.String() != "Привет." {
+			t.Errorf("unexpected result %q %q %v", out, got.String(), err)
+		}
+	})
+
+	t.Run("after first chunk", func(t *testing.T) {
+		second := &stubBackend{reply: "ok"}
+		f := NewFallback("chat",
+			Entry{"a", &stubBackend{err: ErrServerDown, chunks: []string{"При"}}},
+			Entry{"b", second},
+		)
+		_, err := f.ChatStream(context.Background(), nil, func(string) error { return nil })
+		if !errors.Is(err, ErrServerDown) {
+			t.Errorf("expected the stream error, got %v", err)
+		}
+		if second.calls != 0 {
+			t.Error("a half-spoken answer should not be repeated by the fallback")
+		}
+	})
+}
+
+func TestFallbackWithClients(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), `"model":"missing"`) {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintln(w, `{"error":"model \"missing\" not found, try pulling it first"}`)
+			return
+		}
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"ACTION: TIME | ARG: none"},"done":true}`)
+	}))
+	defer ts.Close()
+
+	f := NewFallback("router",
+		Entry{"missing", New(ts.URL, "missing")},
+		Entry{"qwen3:4b", New(ts.URL, "qwen3:4b")},
+	)
+	out, err := f.Chat(context.Background(), []Message{{Role: "user", Content: "время"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != "ACTION: TIME | ARG: none" || f.LastModel() != "qwen3:4b" {
+		t.Errorf("unexpected answer %q from %q", out, f.LastModel())
+	}
+}
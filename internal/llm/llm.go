@@ -5,11 +5,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
+	"hey-bobik/internal/logger"
 	"net/http"
+	"sync"
 	"time"
 )
 
+var log = logger.New("llm")
+
 const (
 	defaultTimeout = 60 * time.Second
 )
@@ -18,34 +21,80 @@ const (
 type Client struct {
 	BaseURL    string
 	Model      string
-	Timeout    time.Duration
+	Timeout    time.Duration // deadline for each call, including reading a stream
+	MaxRetries int           // retries on connection errors and 5xx
+	Breaker    *Breaker      // optional, skips calls while the server keeps failing
+	KeepAlive  string        // how long Ollama keeps the model loaded after a call, e.g. "30m"
+	Options    Options       // generation parameters sent with every call
+	Think      *bool         // Ollama think flag, nil keeps the model default
+	// OnThinking receives the reasoning of thinking models, which is never
+	// part of the returned answer.
+	OnThinking func(thinking string)
+	// OnUsage receives timing and token counts of every generation call.
+	OnUsage    func(model string, u Usage)
 	httpClient *http.Client
+
+	mu           sync.Mutex
+	capabilities []string // cached model capabilities, nil until queried
 }
 
 // GenerateRequest represents the request body for Ollama's generate API.
 type GenerateRequest struct {
-	Model  string   `json:"model"`
-	Prompt string   `json:"prompt"`
-	System string   `json:"system"`
-	Stream bool     `json:"stream"`
-	Images []string `json:"images,omitempty"` // Для vision моделей: base64-encoded изображения
+	Model     string   `json:"model"`
+	Prompt    string   `json:"prompt"`
+	System    string   `json:"system"`
+	Stream    bool     `json:"stream"`
+	Images    []string `json:"images,omitempty"` // Для vision моделей: base64-encoded изображения
+	Think     *bool    `json:"think,omitempty"`
+	Options   *Options `json:"options,omitempty"`
+	KeepAlive string   `json:"keep_alive,omitempty"` // how long the model stays loaded, e.g. "30m"
 }
 
 // GenerateResponse represents the response body from Ollama's generate API.
 type GenerateResponse struct {
 	Response string `json:"response"`
+	Thinking string `json:"thinking,omitempty"` // with think enabled
 	Done     bool   `json:"done"`
+	Usage
+}
+
+// Message is a single role-tagged turn of a chat conversation.
+type Message struct {
+	Role      string     `json:"role"` // system, user, assistant or tool
+	Content   string     `json:"content"`
+	Thinking  string     `json:"thinking,omitempty"` // reasoning returned with think enabled
+	Images    []string   `json:"images,omitempty"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+}
+
+// ChatRequest represents the request body for Ollama's chat API.
+type ChatRequest struct {
+	Model     string           `json:"model"`
+	Messages  []Message        `json:"messages"`
+	Tools     []ToolDefinition `json:"tools,omitempty"`
+	Stream    bool             `json:"stream"`
+	Think     *bool            `json:"think,omitempty"`
+	Options   *Options         `json:"options,omitempty"`
+	KeepAlive string           `json:"keep_alive,omitempty"` // how long the model stays loaded, e.g. "30m"
+}
+
+// ChatResponse represents the response body from Ollama's chat API.
+type ChatResponse struct {
+	Message Message `json:"message"`
+	Done    bool    `json:"done"`
+	Usage
 }
 
 // New creates a new Ollama client.
 func New(baseURL, model string) *Client {
 	return &Client{
-		BaseURL: baseURL,
-		Model:   model,
-		Timeout: defaultTimeout,
-		httpClient: &http.Client{
-			Timeout: defaultTimeout,
-		},
+		BaseURL:    baseURL,
+		Model:      model,
+		Timeout:    defaultTimeout,
+		MaxRetries: defaultMaxRetries,
+		Breaker:    NewBreaker(defaultBreakerThreshold, defaultBreakerCooldown),
+		// Deadlines are applied per call from Timeout, see send
+		httpClient: &http.Client{},
 	}
 }
 
@@ -63,34 +112,91 @@ func (c *Client) GenerateWithImages(ctx context.Context, system, prompt string,
 		System: system,
 		Stream: false,
 		Images: images,
+		Think:  c.Think,
+
+		Options:   c.requestOptions(),
+		KeepAlive: c.KeepAlive,
 	}
 
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+	var genResp GenerateResponse
+	if err := c.post(ctx, "/api/generate", reqBody, &genResp); err != nil {
+		return "", err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"/api/generate", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+	c.reportUsage(genResp.Usage)
+	return c.answer(genResp.Response, genResp.Thinking), nil
+}
+
+// Chat sends a role-tagged conversation to Ollama's chat API and returns the assistant reply.
+// Keeping the system message and earlier turns stable lets Ollama reuse its KV cache.
+func (c *Client) Chat(ctx context.Context, messages []Message) (string, error) {
+	reqBody := ChatRequest{
+		Model:    c.Model,
+		Messages: messages,
+		Stream:   false,
+		Think:    c.Think,
+
+		Options:   c.requestOptions(),
+		KeepAlive: c.KeepAlive,
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+	var chatResp ChatResponse
+	if err := c.post(ctx, "/api/chat", reqBody, &chatResp); err != nil {
+		return "", err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+	c.reportUsage(chatResp.Usage)
+	return c.answer(chatResp.Message.Content, chatResp.Message.Thinking), nil
+}
+
+// post sends a JSON request to the given API path and decodes the JSON response into out.
+func (c *Client) post(ctx context.Context, path string, reqBody, out interface{}) error {
+	return c.do(ctx, "POST", path, reqBody, decodeInto(out))
+}
+
+// get fetches the given API path and decodes the JSON response into out.
+func (c *Client) get(ctx context.Context, path string, out interface{}) error {
+	return c.do(ctx, "GET", path, nil, decodeInto(out))
+}
+
+func decodeInto(out interface{}) func(*http.Response) error {
+	return func(resp *http.Response) error {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+		return nil
 	}
+}
 
-	var genResp GenerateResponse
-	if err := json.NewDecoder(resp.Body).Decode(&genResp); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+// do sends a request with an optional JSON body to the given API path and
+// passes an OK response to handle.
+func (c *Client) do(ctx context.Context, method, path string, reqBody interface{}, handle func(*http.Response) error) error {
+	return c.doWithPolicy(ctx, c.policy(), method, path, reqBody, handle)
+}
+
+func (c *Client) doWithPolicy(ctx context.Context, p callPolicy, method, path string, reqBody interface{}, handle func(*http.Response) error) error {
+	var jsonData []byte
+	if reqBody != nil {
+		var err error
+		if jsonData, err = json.Marshal(reqBody); err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+	}
+
+	newReq := func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		if reqBody != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		return req, nil
 	}
 
-	return genResp.Response, nil
+	return send(ctx, c.httpClient, p, newReq, handle)
+}
+
+func (c *Client) policy() callPolicy {
+	return callPolicy{timeout: c.Timeout, maxRetries: c.MaxRetries, breaker: c.Breaker}
 }
@@ -2,10 +2,12 @@ package llm
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestGenerate(t *testing.T) {
@@ -54,3 +56,109 @@ func TestGenerateDecodeError(t *testing.T) {
 		t.Error("expected error for invalid json, got nil")
 	}
 }
+
+func TestChat(t *testing.T) {
+	var got ChatRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("expected /api/chat, got %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"ACTION: TIME | ARG: none"},"done":true}`)
+	}))
+	defer ts.Close()
+
+	client := New(ts.URL, "test-model")
+	resp, err := client.Chat(context.Background(), []Message{
+		{Role: "system", Content: "Ты — Бобик"},
+		{Role: "user", Content: "запиши хлеб"},
+		{Role: "assistant", Content: "ACTION: NOTE | ARG: хлеб"},
+		{Role: "user", Content: "сколько времени"},
+	})
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if resp != "ACTION: TIME | ARG: none" {
+		t.Errorf("unexpected reply: %s", resp)
+	}
+	if got.Model != "test-model" || got.Stream {
+		t.Errorf("unexpected request: %+v", got)
+	}
+	if len(got.Messages) != 4 || got.Messages[2].Role != "assistant" {
+		t.Errorf("expected 4 role-tagged messages, got %+v", got.Messages)
+	}
+}
+
+func TestChatError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintln(w, `{"error":"model not found"}`)
+	}))
+	defer ts.Close()
+
+	client := New(ts.URL, "missing-model")
+	if _, err := client.Chat(context.Background(), []Message{{Role: "user", Content: "привет"}}); err == nil {
+		t.Error("expected error for 404 status code, got nil")
+	}
+}
+
+func TestGenerationOptions(t *testing.T) {
+	var got map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"message":{"role":"assistant","content":"ok"},"done":true}`))
+	}))
+	defer ts.Close()
+
+	client := New(ts.URL, "test-model")
+	if _, err := client.Chat(context.Background(), nil); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if _, ok := got["options"]; ok {
+		t.Errorf("unset options should be omitted, got %v", got["options"])
+	}
+
+	temp, seed := 0.0, 42
+	client.Options = Options{Temperature: &temp, Seed: &seed, NumCtx: 4096, Stop: []string{"\n\n"}}
+	client.KeepAlive = "30m"
+	if _, err := client.Chat(context.Background(), nil); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	opts, _ := got["options"].(map[string]interface{})
+	if opts["temperature"] != 0.0 || opts["seed"] != 42.0 || opts["num_ctx"] != 4096.0 {
+		t.Errorf("unexpected options: %v", opts)
+	}
+	if _, ok := opts["top_p"]; ok {
+		t.Error("unset top_p should be omitted")
+	}
+	if got["keep_alive"] != "30m" {
+		t.Errorf("expected keep_alive 30m, got %v", got["keep_alive"])
+	}
+}
+
+func TestUsage(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"ok"},"done":true,
+			"total_duration":1500000000,"load_duration":500000000,
+			"prompt_eval_count":200,"prompt_eval_duration":400000000,
+			"eval_count":20,"eval_duration":250000000}`)
+	}))
+	defer ts.Close()
+
+	var model string
+	var got Usage
+	client := New(ts.URL, "test-model")
+	client.OnUsage = func(m string, u Usage) { model, got = m, u }
+	if _, err := client.Chat(context.Background(), nil); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	if model != "test-model" || got.TotalDuration != 1500*time.Millisecond || got.LoadDuration != 500*time.Millisecond {
+		t.Errorf("unexpected usage for %s: %+v", model, got)
+	}
+	if got.PromptRate() != 500 || got.EvalRate() != 80 {
+		t.Errorf("unexpected rates: prompt %.1f, eval %.1f", got.PromptRate(), got.EvalRate())
+	}
+}
@@ -0,0 +1,139 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// TagsResponse represents the list of local models from Ollama's tags API.
+type TagsResponse struct {
+	Models []struct {
+		Name string `json:"name"`
+	} `json:"models"`
+}
+
+// PullProgress is one status update of a model download.
+type PullProgress struct {
+	Status    string `json:"status"`
+	Total     int64  `json:"total"`
+	Completed int64  `json:"completed"`
+	Error     string `json:"error"`
+}
+
+// Percent returns the download progress of the current layer, or -1 if unknown.
+func (p PullProgress) Percent() int {
+	if p.Total <= 0 {
+		return -1
+	}
+	return int(p.Completed * 100 / p.Total)
+}
+
+// ListModels returns the names of the models available on the server.
+func (c *Client) ListModels(ctx context.Context) ([]string, error) {
+	var tags TagsResponse
+	if err := c.get(ctx, "/api/tags", &tags); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(tags.Models))
+	for _, m := range tags.Models {
+		names = append(names, m.Name)
+	}
+	return names, nil
+}
+
+// CheckModel verifies that the server is reachable and has the model.
+// It returns ErrServerDown or ErrModelNotFound so callers can explain the problem.
+// The model capabilities are cached on success.
+func (c *Client) CheckModel(ctx context.Context) error {
+	names, err := c.ListModels(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list models at %s: %w", c.BaseURL, err)
+	}
+
+	found := false
+	for _, name := range names {
+		if sameModel(name, c.Model) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("%w: %s is not pulled on %s", ErrModelNotFound, c.Model, c.BaseURL)
+	}
+
+	caps, err := c.Capabilities(ctx)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.capabilities = caps
+	c.mu.Unlock()
+	log.Debug("Model %s capabilities: %v", c.Model, caps)
+	return nil
+}
+
+// sameModel compares model names, treating a missing tag as ":latest".
+func sameModel(a, b string) bool {
+	withTag := func(s string) string {
+		if !strings.Contains(s, ":") {
+			return s + ":latest"
+		}
+		return s
+	}
+	return withTag(a) == withTag(b)
+}
+
+// Pull downloads the model, reporting progress. Downloads can take minutes,
+// so the client Timeout doesn't apply; bound it with ctx instead.
+func (c *Client) Pull(ctx context.Context, progress func(PullProgress)) error {
+	reqBody := map[string]interface{}{"model": c.Model, "stream": true}
+	policy := c.policy()
+	policy.timeout = 0
+
+	return c.doWithPolicy(ctx, policy, "POST", "/api/pull", reqBody, func(resp *http.Response) error {
+		return readPullProgress(resp, progress)
+	})
+}
+
+func readPullProgress(resp *http.Response, progress func(PullProgress)) error {
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var p PullProgress
+		if err := json.Unmarshal(scanner.Bytes(), &p); err != nil {
+			return fmt.Errorf("failed to decode pull progress: %w", err)
+		}
+		if p.Error != "" {
+			return fmt.Errorf("pull failed: %s", p.Error)
+		}
+		if progress != nil {
+			progress(p)
+		}
+		if p.Status == "success" {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read pull progress: %w", err)
+	}
+	return fmt.Errorf("pull ended without success")
+}
+
+// Warm loads the model into memory without generating anything, so the next
+// command doesn't pay the load time. KeepAlive decides how long it stays.
+// Options are sent too, as a different num_ctx would make Ollama reload the model.
+func (c *Client) Warm(ctx context.Context) error {
+	reqBody := GenerateRequest{
+		Model:     c.Model,
+		Options:   c.requestOptions(),
+		KeepAlive: c.KeepAlive,
+	}
+	var resp GenerateResponse
+	return c.post(ctx, "/api/generate", reqBody, &resp)
+}
@@ -0,0 +1,107 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// modelServer is an Ollama stand-in with a set of pulled models.
+func modelServer(t *testing.T, models ...string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			if r.Method != "GET" {
+				t.Errorf("expected GET for tags, got %s", r.Method)
+			}
+			var tags TagsResponse
+			for _, m := range models {
+				tags.Models = append(tags.Models, struct {
+					Name string `json:"name"`
+				}{m})
+			}
+			json.NewEncoder(w).Encode(tags)
+		case "/api/show":
+			fmt.Fprintln(w, `{"capabilities":["completion","tools"]}`)
+		case "/api/pull":
+			for _, done := range []int{0, 50, 100} {
+				fmt.Fprintf(w, `{"status":"pulling","total":100,"completed":%d}`+"\n", done)
+			}
+			fmt.Fprintln(w, `{"status":"success"}`)
+		case "/api/generate":
+			var req GenerateRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.KeepAlive != "30m" || req.Prompt != "" {
+				t.Errorf("unexpected warm-up request: %+v", req)
+			}
+			fmt.Fprintln(w, `{"response":"","done":true}`)
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+}
+
+func TestCheckModel(t *testing.T) {
+	ts := modelServer(t, "qwen3:8b", "llava:latest")
+	defer ts.Close()
+
+	for _, model := range []string{"qwen3:8b", "llava"} {
+		client := New(ts.URL, model)
+		if err := client.CheckModel(context.Background()); err != nil {
+			t.Errorf("%s: unexpected error %v", model, err)
+		}
+		if !client.SupportsTools(context.Background()) {
+			t.Errorf("%s: capabilities should be cached by the check", model)
+		}
+	}
+
+	client := New(ts.URL, "qwen3:14b")
+	if err := client.CheckModel(context.Background()); !errors.Is(err, ErrModelNotFound) {
+		t.Errorf("expected ErrModelNotFound, got %v", err)
+	}
+}
+
+func TestCheckModelServerDown(t *testing.T) {
+	ts := modelServer(t)
+	url := ts.URL
+	ts.Close()
+
+	client := New(url, "qwen3:8b")
+	client.MaxRetries = 0
+	if err := client.CheckModel(context.Background()); !errors.Is(err, ErrServerDown) {
+		t.Errorf("expected ErrServerDown, got %v", err)
+	}
+}
+
+func TestPull(t *testing.T) {
+	ts := modelServer(t)
+	defer ts.Close()
+
+	var percents []int
+	client := New(ts.URL, "qwen3:8b")
+	err := client.Pull(context.Background(), func(p PullProgress) {
+		percents = append(percents, p.Percent())
+	})
+	if err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	want := []int{0, 50, 100, -1}
+	if fmt.Sprint(percents) != fmt.Sprint(want) {
+		t.Errorf("expected progress %v, got %v", want, percents)
+	}
+}
+
+func TestWarm(t *testing.T) {
+	ts := modelServer(t)
+	defer ts.Close()
+
+	client := New(ts.URL, "qwen3:8b")
+	client.KeepAlive = "30m"
+	if err := client.Warm(context.Background()); err != nil {
+		t.Fatalf("Warm failed: %v", err)
+	}
+}
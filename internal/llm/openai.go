@@ -0,0 +1,174 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAIClient talks to OpenAI-compatible servers such as llama.cpp's
+// llama-server, LM Studio and vLLM via /v1/chat/completions.
+type OpenAIClient struct {
+	BaseURL    string
+	Model      string
+	APIKey     string        // optional, sent as a Bearer token
+	Timeout    time.Duration // deadline for each call
+	MaxRetries int           // retries on connection errors and 5xx
+	Breaker    *Breaker      // optional, skips calls while the server keeps failing
+	Options    Options       // mapped to the OpenAI sampling fields, num_ctx is server-side
+	OnThinking func(thinking string)
+	OnUsage    func(model string, u Usage) // token counts and wall time, the API has no server timings
+	httpClient *http.Client
+}
+
+// openAIMessage is a chat message whose content is either a string or a list of parts.
+type openAIMessage struct {
+	Role    string      `json:"role"`
+	Content interface{} `json:"content"`
+}
+
+// openAIContentPart is a text or image part of a multimodal message.
+type openAIContentPart struct {
+	Type     string          `json:"type"` // "text" or "image_url"
+	Text     string          `json:"text,omitempty"`
+	ImageURL *openAIImageURL `json:"image_url,omitempty"`
+}
+
+type openAIImageURL struct {
+	URL string `json:"url"`
+}
+
+type openAIChatRequest struct {
+	Model       string          `json:"model"`
+	Messages    []openAIMessage `json:"messages"`
+	Stream      bool            `json:"stream"`
+	Temperature *float64        `json:"temperature,omitempty"`
+	TopP        *float64        `json:"top_p,omitempty"`
+	MaxTokens   int             `json:"max_tokens,omitempty"`
+	Seed        *int            `json:"seed,omitempty"`
+	Stop        []string        `json:"stop,omitempty"`
+}
+
+type openAIChatResponse struct {
+	Choices []struct {
+		Message struct {
+			Content          string `json:"content"`
+			ReasoningContent string `json:"reasoning_content"` // llama-server with --reasoning-format
+		} `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// NewOpenAI creates a client for an OpenAI-compatible server.
+// baseURL may be given with or without the trailing /v1.
+func NewOpenAI(baseURL, model, apiKey string) *OpenAIClient {
+	baseURL = strings.TrimSuffix(strings.TrimRight(baseURL, "/"), "/v1")
+	return &OpenAIClient{
+		BaseURL:    baseURL,
+		Model:      model,
+		APIKey:     apiKey,
+		Timeout:    defaultTimeout,
+		MaxRetries: defaultMaxRetries,
+		Breaker:    NewBreaker(defaultBreakerThreshold, defaultBreakerCooldown),
+		httpClient: &http.Client{},
+	}
+}
+
+// Generate sends a prompt and returns the generated response.
+func (c *OpenAIClient) Generate(ctx context.Context, system, prompt string) (string, error) {
+	return c.GenerateWithImages(ctx, system, prompt, nil)
+}
+
+// GenerateWithImages sends a prompt with base64-encoded PNG images as image content parts.
+func (c *OpenAIClient) GenerateWithImages(ctx context.Context, system, prompt string, images []string) (string, error) {
+	var messages []Message
+	if system != "" {
+		messages = append(messages, Message{Role: "system", Content: system})
+	}
+	messages = append(messages, Message{Role: "user", Content: prompt, Images: images})
+	return c.Chat(ctx, messages)
+}
+
+// Chat sends a role-tagged conversation and returns the assistant reply.
+func (c *OpenAIClient) Chat(ctx context.Context, messages []Message) (string, error) {
+	reqBody := openAIChatRequest{
+		Model:    c.Model,
+		Messages: make([]openAIMessage, 0, len(messages)),
+		Stream:   false,
+
+		Temperature: c.Options.Temperature,
+		TopP:        c.Options.TopP,
+		MaxTokens:   c.Options.NumPredict,
+		Seed:        c.Options.Seed,
+		Stop:        c.Options.Stop,
+	}
+	for _, m := range messages {
+		reqBody.Messages = append(reqBody.Messages, toOpenAIMessage(m))
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	newReq := func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"/v1/chat/completions", bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if c.APIKey != "" {
+			req.Header.Set("Authorization", "Bearer "+c.APIKey)
+		}
+		return req, nil
+	}
+
+	var chatResp openAIChatResponse
+	start := time.Now()
+	policy := callPolicy{timeout: c.Timeout, maxRetries: c.MaxRetries, breaker: c.Breaker}
+	err = send(ctx, c.httpClient, policy, newReq, func(resp *http.Response) error {
+		if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(chatResp.Choices) == 0 {
+		return "", fmt.Errorf("response has no choices")
+	}
+	reportUsage(c.Model, Usage{
+		TotalDuration:   time.Since(start),
+		PromptEvalCount: chatResp.Usage.PromptTokens,
+		EvalCount:       chatResp.Usage.CompletionTokens,
+	}, c.OnUsage)
+
+	msg := chatResp.Choices[0].Message
+	answer, inline := SplitThinking(msg.Content)
+	reportThinking(c.Model, msg.ReasoningContent+"\n"+inline, c.OnThinking)
+	return answer, nil
+}
+
+// toOpenAIMessage converts a message, turning images into data-URL content parts.
+func toOpenAIMessage(m Message) openAIMessage {
+	if len(m.Images) == 0 {
+		return openAIMessage{Role: m.Role, Content: m.Content}
+	}
+
+	parts := []openAIContentPart{{Type: "text", Text: m.Content}}
+	for _, img := range m.Images {
+		parts = append(parts, openAIContentPart{
+			Type:     "image_url",
+			ImageURL: &openAIImageURL{URL: "data:image/png;base64," + img},
+		})
+	}
+	return openAIMessage{Role: m.Role, Content: parts}
+}
@@ -0,0 +1,132 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAIChat(t *testing.T) {
+	var got map[string]interface{}
+	var auth string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("expected /v1/chat/completions, got %s", r.URL.Path)
+		}
+		auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		fmt.Fprintln(w, `{"choices":[{"message":{"role":"assistant","content":"ACTION: TIME | ARG: none"}}]}`)
+	}))
+	defer ts.Close()
+
+	// Trailing /v1 is accepted as llama-server documents it that way
+	client := NewOpenAI(ts.URL+"/v1/", "local-model", "secret")
+	resp, err := client.Chat(context.Background(), []Message{
+		{Role: "system", Content: "Ты — Бобик"},
+		{Role: "user", Content: "сколько времени"},
+	})
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if resp != "ACTION: TIME | ARG: none" {
+		t.Errorf("unexpected reply: %s", resp)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("expected bearer token, got %q", auth)
+	}
+	if got["model"] != "local-model" {
+		t.Errorf("expected model local-model, got %v", got["model"])
+	}
+	msgs, _ := got["messages"].([]interface{})
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 messages, got %v", got["messages"])
+	}
+	if content := msgs[1].(map[string]interface{})["content"]; content != "сколько времени" {
+		t.Errorf("expected plain string content, got %v", content)
+	}
+}
+
+func TestOpenAIGenerateWithImages(t *testing.T) {
+	var got struct {
+		Messages []struct {
+			Role    string              `json:"role"`
+			Content []openAIContentPart `json:"content"`
+		} `json:"messages"`
+	}
+	var auth string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		fmt.Fprintln(w, `{"choices":[{"message":{"content":"Окно терминала"}}]}`)
+	}))
+	defer ts.Close()
+
+	client := NewOpenAI(ts.URL, "vision-model", "")
+	resp, err := client.GenerateWithImages(context.Background(), "", "Что на экране?", []string{"aGVsbG8="})
+	if err != nil {
+		t.Fatalf("GenerateWithImages failed: %v", err)
+	}
+	if resp != "Окно терминала" {
+		t.Errorf("unexpected reply: %s", resp)
+	}
+	if auth != "" {
+		t.Errorf("expected no auth header without API key, got %q", auth)
+	}
+	if len(got.Messages) != 1 || len(got.Messages[0].Content) != 2 {
+		t.Fatalf("expected one message with text and image parts, got %+v", got.Messages)
+	}
+	parts := got.Messages[0].Content
+	if parts[0].Type != "text" || parts[0].Text != "Что на экране?" {
+		t.Errorf("unexpected text part: %+v", parts[0])
+	}
+	if parts[1].Type != "image_url" || parts[1].ImageURL.URL != "data:image/png;base64,aGVsbG8=" {
+		t.Errorf("unexpected image part: %+v", parts[1])
+	}
+}
+
+func TestOpenAIErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintln(w, `{"choices":[]}`)
+	}))
+	defer ts.Close()
+
+	if _, err := NewOpenAI(ts.URL, "m", "").Chat(context.Background(), []Message{{Role: "user", Content: "x"}}); err == nil {
+		t.Error("expected error for 401 status code")
+	}
+	if _, err := NewOpenAI(ts.URL, "m", "key").Chat(context.Background(), []Message{{Role: "user", Content: "x"}}); err == nil {
+		t.Error("expected error for empty choices")
+	}
+}
+
+func TestOpenAIOptions(t *testing.T) {
+	var got map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprintln(w, `{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`)
+	}))
+	defer ts.Close()
+
+	temp := 0.0
+	client := NewOpenAI(ts.URL, "local-model", "")
+	client.Options = Options{Temperature: &temp, NumPredict: 64, NumCtx: 8192}
+	if _, err := client.Chat(context.Background(), nil); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if got["temperature"] != 0.0 || got["max_tokens"] != 64.0 {
+		t.Errorf("options not mapped: %v", got)
+	}
+	if _, ok := got["num_ctx"]; ok {
+		t.Error("num_ctx has no OpenAI equivalent and must not be sent")
+	}
+}
@@ -0,0 +1,27 @@
+package llm
+
+// Options are Ollama generation parameters. Unset fields keep the model
+// defaults; pointers distinguish an explicit zero such as temperature 0.
+type Options struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	NumCtx      int      `json:"num_ctx,omitempty"`
+	NumPredict  int      `json:"num_predict,omitempty"`
+	Seed        *int     `json:"seed,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+}
+
+// IsZero reports whether no option is set.
+func (o Options) IsZero() bool {
+	return o.Temperature == nil && o.TopP == nil && o.NumCtx == 0 &&
+		o.NumPredict == 0 && o.Seed == nil && len(o.Stop) == 0
+}
+
+// requestOptions returns the options to send, or nil to omit them.
+func (c *Client) requestOptions() *Options {
+	if c.Options.IsZero() {
+		return nil
+	}
+	opts := c.Options
+	return &opts
+}
@@ -0,0 +1,239 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Error kinds returned by the clients, test them with errors.Is.
+var (
+	// ErrTimeout means the call did not finish within the configured deadline.
+	ErrTimeout = errors.New("llm request timed out")
+	// ErrModelNotFound means the server is up but doesn't have the model.
+	ErrModelNotFound = errors.New("llm model not found")
+	// ErrServerDown means the server is unreachable or keeps failing with 5xx.
+	ErrServerDown = errors.New("llm server unavailable")
+	// ErrCircuitOpen means the call was skipped because recent calls kept failing.
+	ErrCircuitOpen = errors.New("llm circuit open")
+)
+
+const (
+	defaultMaxRetries       = 2
+	defaultBreakerThreshold = 3
+	defaultBreakerCooldown  = 30 * time.Second
+	retryBaseDelay          = 250 * time.Millisecond
+)
+
+// StatusError is a non-OK HTTP response from the server.
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d: %s", e.StatusCode, e.Message)
+}
+
+// Breaker skips calls for a cool-down period after repeated failures, so a
+// dead server costs nothing instead of a full timeout per command.
+type Breaker struct {
+	Threshold int           // consecutive failures that open the circuit
+	Cooldown  time.Duration // how long calls are skipped once open
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+// NewBreaker creates a circuit breaker.
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{Threshold: threshold, Cooldown: cooldown}
+}
+
+// Allow returns ErrCircuitOpen while the circuit is open. After the cool-down
+// calls go through again and a single failure reopens it.
+func (b *Breaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures >= b.Threshold && time.Now().Before(b.openUntil) {
+		return ErrCircuitOpen
+	}
+	return nil
+}
+
+// Success closes the circuit.
+func (b *Breaker) Success() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+}
+
+// Failure records a failed call and opens the circuit at the threshold.
+func (b *Breaker) Failure() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.failures >= b.Threshold {
+		if b.failures == b.Threshold {
+			log.Warn("LLM failed %d times in a row, skipping calls for %s", b.failures, b.Cooldown)
+		}
+		b.openUntil = time.Now().Add(b.Cooldown)
+	}
+}
+
+// callPolicy bounds one logical request: deadline, retries and circuit breaking.
+type callPolicy struct {
+	timeout    time.Duration
+	maxRetries int
+	breaker    *Breaker
+}
+
+// send performs a request built by newReq under the policy and passes an OK
+// response to handle. Connection errors and 5xx are retried with jittered
+// backoff; failures inside handle are returned as is.
+func send(ctx context.Context, hc *http.Client, p callPolicy, newReq func(context.Context) (*http.Request, error), handle func(*http.Response) error) error {
+	if err := p.breaker.Allow(); err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := sendOnce(ctx, hc, p.timeout, newReq, handle)
+		if err == nil {
+			p.breaker.Success()
+			return nil
+		}
+
+		var herr handlerError
+		if errors.As(err, &herr) {
+			// The server answered, so it is healthy
+			p.breaker.Success()
+			return herr.err
+		}
+		if errors.Is(err, ErrTimeout) || errors.Is(err, ErrServerDown) {
+			p.breaker.Failure()
+		}
+		if !errors.Is(err, ErrServerDown) || attempt >= p.maxRetries || ctx.Err() != nil {
+			return err
+		}
+
+		delay := backoff(attempt)
+		log.Debug("LLM request failed (%v), retry %d in %s", err, attempt+1, delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+// handlerError marks an error returned by the response handler.
+type handlerError struct{ err error }
+
+func (e handlerError) Error() string { return e.err.Error() }
+
+func sendOnce(ctx context.Context, hc *http.Client, timeout time.Duration, newReq func(context.Context) (*http.Request, error), handle func(*http.Response) error) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	req, err := newReq(ctx)
+	if err != nil {
+		return err
+	}
+
+	resp, err := hc.Do(req)
+	if err != nil {
+		return classify(ctx, fmt.Errorf("failed to send request: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return statusError(resp)
+	}
+
+	if err := handle(resp); err != nil {
+		if ctx.Err() != nil {
+			// The deadline hit while the body was being read
+			return classify(ctx, err)
+		}
+		return handlerError{err}
+	}
+	return nil
+}
+
+// classify wraps a transport error with its kind.
+func classify(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+	if ctx.Err() != nil {
+		// Cancelled by the caller, nothing to blame the server for
+		return err
+	}
+	var ue *url.Error
+	if errors.As(err, &ue) {
+		if ue.Timeout() {
+			return fmt.Errorf("%w: %w", ErrTimeout, err)
+		}
+		return fmt.Errorf("%w: %w", ErrServerDown, err)
+	}
+	return err
+}
+
+// statusError reads the body of a failed response and classifies it.
+func statusError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+	msg := strings.TrimSpace(string(body))
+
+	// Ollama and OpenAI-compatible servers put the reason in an "error" field
+	var apiErr struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(body, &apiErr) == nil && len(apiErr.Error) > 0 {
+		var s string
+		var obj struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(apiErr.Error, &s) == nil {
+			msg = s
+		} else if json.Unmarshal(apiErr.Error, &obj) == nil && obj.Message != "" {
+			msg = obj.Message
+		}
+	}
+
+	se := &StatusError{StatusCode: resp.StatusCode, Message: msg}
+	switch {
+	case resp.StatusCode == http.StatusNotFound && strings.Contains(strings.ToLower(msg), "not found"):
+		return fmt.Errorf("%w: %w", ErrModelNotFound, se)
+	case resp.StatusCode >= 500:
+		return fmt.Errorf("%w: %w", ErrServerDown, se)
+	default:
+		return se
+	}
+}
+
+// backoff returns the delay before retry attempt+1: exponential with jitter.
+func backoff(attempt int) time.Duration {
+	d := retryBaseDelay << attempt
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
@@ -0,0 +1,147 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryOnServerError(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			http.Error(w, `{"error":"busy"}`, http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"message":{"role":"assistant","content":"ok"},"done":true}`))
+	}))
+	defer ts.Close()
+
+	client := New(ts.URL, "test-model")
+	resp, err := client.Chat(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("expected success after retries, got %v", err)
+	}
+	if resp != "ok" || calls != 3 {
+		t.Errorf("expected ok after 3 calls, got %q after %d", resp, calls)
+	}
+}
+
+func TestModelNotFound(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"model 'missing' not found, try pulling it first"}`))
+	}))
+	defer ts.Close()
+
+	client := New(ts.URL, "missing")
+	_, err := client.Chat(context.Background(), nil)
+	if !errors.Is(err, ErrModelNotFound) {
+		t.Fatalf("expected ErrModelNotFound, got %v", err)
+	}
+	var se *StatusError
+	if !errors.As(err, &se) || se.StatusCode != http.StatusNotFound {
+		t.Errorf("expected StatusError 404, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("missing model must not be retried, got %d calls", calls)
+	}
+}
+
+func TestTimeoutFromConfig(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(300 * time.Millisecond):
+		case <-r.Context().Done():
+		}
+	}))
+	defer ts.Close()
+
+	client := New(ts.URL, "test-model")
+	client.Timeout = 50 * time.Millisecond
+
+	start := time.Now()
+	_, err := client.Chat(context.Background(), nil)
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected ErrTimeout, got %v", err)
+	}
+	if time.Since(start) > 250*time.Millisecond {
+		t.Errorf("timeout was not applied, call took %s", time.Since(start))
+	}
+}
+
+func TestServerDown(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := ts.URL
+	ts.Close()
+
+	client := New(url, "test-model")
+	client.MaxRetries = 1
+	_, err := client.Chat(context.Background(), nil)
+	if !errors.Is(err, ErrServerDown) {
+		t.Fatalf("expected ErrServerDown, got %v", err)
+	}
+}
+
+func TestBreakerSkipsCalls(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	client := New(ts.URL, "test-model")
+	client.MaxRetries = 0
+	client.Breaker = NewBreaker(2, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Chat(context.Background(), nil); !errors.Is(err, ErrServerDown) {
+			t.Fatalf("call %d: expected ErrServerDown, got %v", i, err)
+		}
+	}
+	if _, err := client.Chat(context.Background(), nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("open circuit must not reach the server, got %d calls", calls)
+	}
+}
+
+func TestBreakerCooldown(t *testing.T) {
+	b := NewBreaker(1, 20*time.Millisecond)
+	b.Failure()
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected open circuit, got %v", err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("expected a trial call after cool-down, got %v", err)
+	}
+	b.Failure()
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("a failed trial should reopen the circuit, got %v", err)
+	}
+	b.Success()
+	if err := b.Allow(); err != nil {
+		t.Errorf("success should close the circuit, got %v", err)
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	for attempt := 0; attempt < 3; attempt++ {
+		max := retryBaseDelay << attempt
+		for i := 0; i < 20; i++ {
+			if d := backoff(attempt); d < max/2 || d > max {
+				t.Fatalf("backoff(%d) = %s, want within [%s, %s]", attempt, d, max/2, max)
+			}
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrStopStream can be returned from a chunk callback to end streaming early.
+// The streaming call then returns the text received so far without error.
+var ErrStopStream = errors.New("stop stream")
+
+// maxStreamLine bounds a single NDJSON line; vision answers can be long.
+const maxStreamLine = 1024 * 1024
+
+// streamChunk is one NDJSON line of a streamed generate or chat response.
+type streamChunk struct {
+	Response string  `json:"response"` // generate API
+	Thinking string  `json:"thinking"` // generate API with think enabled
+	Message  Message `json:"message"`  // chat API
+	Done     bool    `json:"done"`
+	Error    string  `json:"error"`
+	Usage            // set on the final chunk
+}
+
+// ChatStream sends a conversation to Ollama's chat API with streaming enabled
+// and calls onChunk for every piece of the reply as it arrives.
+func (c *Client) ChatStream(ctx context.Context, messages []Message, onChunk func(string) error) (string, error) {
+	reqBody := ChatRequest{
+		Model:    c.Model,
+		Messages: messages,
+		Stream:   true,
+		Think:    c.Think,
+
+		Options:   c.requestOptions(),
+		KeepAlive: c.KeepAlive,
+	}
+	return c.stream(ctx, "/api/chat", reqBody, onChunk)
+}
+
+// GenerateWithImagesStream is the streaming variant of GenerateWithImages.
+func (c *Client) GenerateWithImagesStream(ctx context.Context, system, prompt string, images []string, onChunk func(string) error) (string, error) {
+	reqBody := GenerateRequest{
+		Model:  c.Model,
+		Prompt: prompt,
+		System: system,
+		Stream: true,
+		Images: images,
+		Think:  c.Think,
+
+		Options:   c.requestOptions(),
+		KeepAlive: c.KeepAlive,
+	}
+	return c.stream(ctx, "/api/generate", reqBody, onChunk)
+}
+
+// stream reads NDJSON chunks until the model is done or onChunk asks to stop.
+// The client Timeout bounds the whole stream. Reasoning, whether in the
+// thinking field or inline <think> blocks, never reaches onChunk.
+func (c *Client) stream(ctx context.Context, path string, reqBody interface{}, onChunk func(string) error) (string, error) {
+	var full strings.Builder
+	var filter thinkFilter
+	var thinking strings.Builder
+
+	emit := func(text string) error {
+		if text == "" {
+			return nil
+		}
+		full.WriteString(text)
+		if onChunk == nil {
+			return nil
+		}
+		return onChunk(text)
+	}
+
+	err := c.do(ctx, "POST", path, reqBody, func(resp *http.Response) error {
+		err := readStream(resp.Body, func(chunk streamChunk) error {
+			if chunk.Done {
+				c.reportUsage(chunk.Usage)
+			}
+			thinking.WriteString(chunk.Thinking + chunk.Message.Thinking)
+			return emit(filter.feed(chunk.Response + chunk.Message.Content))
+		})
+		if err == nil {
+			err = emit(filter.flush())
+		}
+		if errors.Is(err, ErrStopStream) {
+			return nil
+		}
+		return err
+	})
+
+	c.reportThinking(thinking.String() + "\n" + filter.thinking.String())
+	return strings.TrimSpace(full.String()), err
+}
+
+// readStream decodes NDJSON lines and passes them to handle until done.
+func readStream(body io.Reader, handle func(streamChunk) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLine)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var chunk streamChunk
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+		if chunk.Error != "" {
+			return fmt.Errorf("stream error: %s", chunk.Error)
+		}
+		if err := handle(chunk); err != nil {
+			return err
+		}
+		if chunk.Done {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	return fmt.Errorf("stream ended before done")
+}
@@ -0,0 +1,85 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestChatStream(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if !req.Stream {
+			t.Error("expected stream to be enabled")
+		}
+		for _, part := range []string{"Пер", "вое. ", "Второе."} {
+			fmt.Fprintf(w, `{"message":{"role":"assistant","content":%q},"done":false}`+"\n", part)
+		}
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":""},"done":true}`)
+	}))
+	defer ts.Close()
+
+	var chunks []string
+	client := New(ts.URL, "test-model")
+	full, err := client.ChatStream(context.Background(), []Message{{Role: "user", Content: "привет"}}, func(c string) error {
+		chunks = append(chunks, c)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+	if full != "Первое. Второе." {
+		t.Errorf("unexpected full reply: %q", full)
+	}
+	if len(chunks) != 3 {
+		t.Errorf("expected 3 chunks, got %q", chunks)
+	}
+}
+
+func TestGenerateWithImagesStreamStop(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req GenerateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if len(req.Images) != 1 {
+			t.Errorf("expected 1 image, got %d", len(req.Images))
+		}
+		for _, part := range []string{"ACTION: TIME", " | ARG: none\n", "лишнее"} {
+			fmt.Fprintf(w, `{"response":%q,"done":false}`+"\n", part)
+		}
+		fmt.Fprintln(w, `{"response":"","done":true}`)
+	}))
+	defer ts.Close()
+
+	client := New(ts.URL, "test-model")
+	full, err := client.GenerateWithImagesStream(context.Background(), "", "что на экране", []string{"aW1n"}, func(c string) error {
+		if strings.Contains(c, "\n") {
+			return ErrStopStream
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+	if full != "ACTION: TIME | ARG: none" {
+		t.Errorf("expected text up to the stop, got %q", full)
+	}
+}
+
+func TestStreamError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"message":{"content":"нач"},"done":false}`)
+		fmt.Fprintln(w, `{"error":"model crashed"}`)
+	}))
+	defer ts.Close()
+
+	client := New(ts.URL, "test-model")
+	_, err := client.ChatStream(context.Background(), nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "model crashed") {
+		t.Errorf("expected stream error, got %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package llm
+
+import "strings"
+
+const (
+	thinkOpen  = "<think>"
+	thinkClose = "</think>"
+)
+
+// SplitThinking separates <think>...</think> reasoning from the answer.
+// Models whose chat template opens the block in the prompt only emit the
+// closing tag, so text before a lone </think> is treated as reasoning too.
+func SplitThinking(text string) (answer, thinking string) {
+	if !strings.Contains(text, thinkOpen) {
+		if i := strings.Index(text, thinkClose); i >= 0 {
+			thinking, text = text[:i], text[i+len(thinkClose):]
+		}
+	}
+
+	var f thinkFilter
+	answer = f.feed(text) + f.flush()
+	thinking = strings.TrimSpace(thinking + f.thinking.String())
+	return strings.TrimSpace(answer), thinking
+}
+
+// thinkFilter removes think blocks from streamed text. Tags may be split
+// across chunks, so a tail that could start a tag is held back until the
+// next chunk decides.
+type thinkFilter struct {
+	inThink  bool
+	pending  string
+	thinking strings.Builder
+}
+
+// feed returns the visible part of chunk.
+func (f *thinkFilter) feed(chunk string) string {
+	s := f.pending + chunk
+	f.pending = ""
+
+	var out strings.Builder
+	for {
+		tag := thinkOpen
+		if f.inThink {
+			tag = thinkClose
+		}
+
+		i := strings.Index(s, tag)
+		if i < 0 {
+			keep := partialTagSuffix(s, tag)
+			f.write(&out, s[:len(s)-keep])
+			f.pending = s[len(s)-keep:]
+			return out.String()
+		}
+
+		f.write(&out, s[:i])
+		s = s[i+len(tag):]
+		f.inThink = !f.inThink
+	}
+}
+
+// flush returns visible text held back at the end of the stream.
+func (f *thinkFilter) flush() string {
+	rest := f.pending
+	f.pending = ""
+	if f.inThink {
+		// Unclosed block, e.g. the answer was cut by num_predict
+		f.thinking.WriteString(rest)
+		return ""
+	}
+	return rest
+}
+
+func (f *thinkFilter) write(out *strings.Builder, s string) {
+	if f.inThink {
+		f.thinking.WriteString(s)
+	} else {
+		out.WriteString(s)
+	}
+}
+
+// partialTagSuffix returns the length of the longest suffix of s that is a
+// proper prefix of tag.
+func partialTagSuffix(s, tag string) int {
+	for k := len(tag) - 1; k > 0; k-- {
+		if strings.HasSuffix(s, tag[:k]) {
+			return k
+		}
+	}
+	return 0
+}
+
+// answer strips reasoning from a reply, reports it and returns the rest.
+// thinking is the separate reasoning field returned when the think flag is set.
+func (c *Client) answer(content, thinking string) string {
+	answer, inline := SplitThinking(content)
+	c.reportThinking(strings.TrimSpace(thinking + "\n" + inline))
+	return answer
+}
+
+// reportThinking logs reasoning at debug level and passes it to OnThinking.
+func (c *Client) reportThinking(thinking string) {
+	reportThinking(c.Model, thinking, c.OnThinking)
+}
+
+func reportThinking(model, thinking string, hook func(string)) {
+	thinking = strings.TrimSpace(thinking)
+	if thinking == "" {
+		return
+	}
+	log.Debug("Thinking (%s): %s", model, thinking)
+	if hook != nil {
+		hook(thinking)
+	}
+}
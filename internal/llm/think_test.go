@@ -0,0 +1,114 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSplitThinking(t *testing.T) {
+	tests := []struct {
+		name, in, answer, thinking string
+	}{
+		{"no reasoning", "ACTION: TIME | ARG: none", "ACTION: TIME | ARG: none", ""},
+		{
+			"think block",
+			"<think>\nПользователь хочет заметку, значит ACTION: NOTE\n</think>\n\nACTION: TIMER | ARG: 60",
+			"ACTION: TIMER | ARG: 60",
+			"Пользователь хочет заметку, значит ACTION: NOTE",
+		},
+		{"empty block", "<think>\n\n</think>\n\nACTION: TIME | ARG: none", "ACTION: TIME | ARG: none", ""},
+		{"opened in template", "думаю...\n</think>\nACTION: CALC | ARG: 2+2", "ACTION: CALC | ARG: 2+2", "думаю..."},
+		{"unclosed", "<think>не успел додумать", "", "не успел додумать"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			answer, thinking := SplitThinking(tt.in)
+			if answer != tt.answer {
+				t.Errorf("answer: expected %q, got %q", tt.answer, answer)
+			}
+			if thinking != tt.thinking {
+				t.Errorf("thinking: expected %q, got %q", tt.thinking, thinking)
+			}
+		})
+	}
+}
+
+func TestThinkFilterSplitTags(t *testing.T) {
+	var f thinkFilter
+	var out strings.Builder
+	for _, chunk := range []string{"<thi", "nk>ACTION: NOTE\n", "</th", "ink>", "ACTION: TIME", " <", "| ARG: none"} {
+		out.WriteString(f.feed(chunk))
+	}
+	out.WriteString(f.flush())
+
+	if out.String() != "ACTION: TIME <| ARG: none" {
+		t.Errorf("unexpected visible text %q", out.String())
+	}
+	if f.thinking.String() != "ACTION: NOTE\n" {
+		t.Errorf("unexpected thinking %q", f.thinking.String())
+	}
+}
+
+func TestChatThinking(t *testing.T) {
+	var req ChatRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&req)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","thinking":"нужен таймер","content":"<think>ещё ACTION: NOTE</think>ACTION: TIMER | ARG: 60"},"done":true}`)
+	}))
+	defer ts.Close()
+
+	think := false
+	var captured string
+	client := New(ts.URL, "qwen3:8b")
+	client.Think = &think
+	client.OnThinking = func(s string) { captured = s }
+
+	resp, err := client.Chat(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if resp != "ACTION: TIMER | ARG: 60" {
+		t.Errorf("reasoning leaked into the answer: %q", resp)
+	}
+	if captured != "нужен таймер\nещё ACTION: NOTE" {
+		t.Errorf("unexpected captured thinking %q", captured)
+	}
+	if req.Think == nil || *req.Think {
+		t.Errorf("think flag not sent: %v", req.Think)
+	}
+}
+
+func TestChatStreamHidesThinking(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, part := range []string{"<think>", "ACTION: NOTE\n", "</think>\n\n", "ACTION: TIME | ARG: none"} {
+			fmt.Fprintf(w, `{"message":{"role":"assistant","content":%q},"done":false}`+"\n", part)
+		}
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":""},"done":true}`)
+	}))
+	defer ts.Close()
+
+	var seen strings.Builder
+	var captured string
+	client := New(ts.URL, "qwen3:8b")
+	client.OnThinking = func(s string) { captured = s }
+
+	full, err := client.ChatStream(context.Background(), nil, func(c string) error {
+		seen.WriteString(c)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+	if strings.Contains(seen.String(), "NOTE") || full != "ACTION: TIME | ARG: none" {
+		t.Errorf("reasoning reached the caller: chunks %q, full %q", seen.String(), full)
+	}
+	if captured != "ACTION: NOTE" {
+		t.Errorf("unexpected captured thinking %q", captured)
+	}
+}
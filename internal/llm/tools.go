@@ -0,0 +1,105 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// ToolDefinition describes a function the model may call.
+type ToolDefinition struct {
+	Type     string             `json:"type"` // always "function"
+	Function FunctionDefinition `json:"function"`
+}
+
+// FunctionDefinition is the JSON-schema description of a callable function.
+type FunctionDefinition struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Parameters  ToolParameters `json:"parameters"`
+}
+
+// ToolParameters is the JSON schema of a function's arguments.
+type ToolParameters struct {
+	Type       string                  `json:"type"` // always "object"
+	Properties map[string]ToolProperty `json:"properties"`
+	Required   []string                `json:"required,omitempty"`
+}
+
+// ToolProperty describes a single function argument.
+type ToolProperty struct {
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+// ToolCall is a structured function call returned by the model.
+type ToolCall struct {
+	Function ToolCallFunction `json:"function"`
+}
+
+// ToolCallFunction holds the called function name and its arguments.
+type ToolCallFunction struct {
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+// ShowResponse represents the subset of Ollama's show API we use.
+type ShowResponse struct {
+	Capabilities []string `json:"capabilities"`
+}
+
+// ChatWithTools sends a conversation with tool definitions and returns the
+// assistant message, which may contain tool calls instead of text.
+func (c *Client) ChatWithTools(ctx context.Context, messages []Message, tools []ToolDefinition) (Message, error) {
+	reqBody := ChatRequest{
+		Model:    c.Model,
+		Messages: messages,
+		Tools:    tools,
+		Stream:   false,
+		Think:    c.Think,
+
+		Options:   c.requestOptions(),
+		KeepAlive: c.KeepAlive,
+	}
+
+	var chatResp ChatResponse
+	if err := c.post(ctx, "/api/chat", reqBody, &chatResp); err != nil {
+		return Message{}, err
+	}
+
+	c.reportUsage(chatResp.Usage)
+	msg := chatResp.Message
+	msg.Content = c.answer(msg.Content, msg.Thinking)
+	msg.Thinking = ""
+	return msg, nil
+}
+
+// SupportsTools reports whether the model advertises the "tools" capability.
+// The answer is cached after the first successful lookup.
+func (c *Client) SupportsTools(ctx context.Context) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.capabilities == nil {
+		caps, err := c.Capabilities(ctx)
+		if err != nil {
+			log.Debug("Failed to query capabilities of %s: %v", c.Model, err)
+			return false
+		}
+		c.capabilities = caps
+	}
+	return slices.Contains(c.capabilities, "tools")
+}
+
+// Capabilities returns the model capabilities reported by Ollama's show API.
+func (c *Client) Capabilities(ctx context.Context) ([]string, error) {
+	var show ShowResponse
+	if err := c.post(ctx, "/api/show", map[string]string{"model": c.Model}, &show); err != nil {
+		return nil, fmt.Errorf("failed to show model %s: %w", c.Model, err)
+	}
+	if show.Capabilities == nil {
+		// Older servers don't report capabilities
+		return []string{}, nil
+	}
+	return show.Capabilities, nil
+}
@@ -0,0 +1,88 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// ollamaStandIn serves /api/show and /api/chat like a local Ollama with tool support.
+func ollamaStandIn(t *testing.T, capabilities string, showCalls *int, gotReq *ChatRequest) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/show":
+			*showCalls++
+			fmt.Fprintf(w, `{"capabilities":%s}`, capabilities)
+		case "/api/chat":
+			if err := json.NewDecoder(r.Body).Decode(gotReq); err != nil {
+				t.Errorf("failed to decode request: %v", err)
+			}
+			fmt.Fprintln(w, `{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"TIMER","arguments":{"arg":300}}}]},"done":true}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestChatWithTools(t *testing.T) {
+	var showCalls int
+	var got ChatRequest
+	ts := ollamaStandIn(t, `["completion","tools"]`, &showCalls, &got)
+	defer ts.Close()
+
+	client := New(ts.URL, "qwen3:8b")
+	tools := []ToolDefinition{{
+		Type: "function",
+		Function: FunctionDefinition{
+			Name:        "TIMER",
+			Description: "Поставить таймер",
+			Parameters: ToolParameters{
+				Type:       "object",
+				Properties: map[string]ToolProperty{"arg": {Type: "string", Description: "секунды"}},
+				Required:   []string{"arg"},
+			},
+		},
+	}}
+
+	msg, err := client.ChatWithTools(context.Background(), []Message{{Role: "user", Content: "таймер на 5 минут"}}, tools)
+	if err != nil {
+		t.Fatalf("ChatWithTools failed: %v", err)
+	}
+	if len(got.Tools) != 1 || got.Tools[0].Function.Name != "TIMER" {
+		t.Errorf("expected tool definitions in request, got %+v", got.Tools)
+	}
+	if len(msg.ToolCalls) != 1 || msg.ToolCalls[0].Function.Name != "TIMER" {
+		t.Fatalf("expected TIMER tool call, got %+v", msg)
+	}
+	if v, ok := msg.ToolCalls[0].Function.Arguments["arg"].(float64); !ok || v != 300 {
+		t.Errorf("expected arg 300, got %v", msg.ToolCalls[0].Function.Arguments["arg"])
+	}
+}
+
+func TestSupportsTools(t *testing.T) {
+	var showCalls int
+	ts := ollamaStandIn(t, `["completion","tools"]`, &showCalls, &ChatRequest{})
+	defer ts.Close()
+
+	client := New(ts.URL, "qwen3:8b")
+	if !client.SupportsTools(context.Background()) {
+		t.Error("expected tools capability")
+	}
+	client.SupportsTools(context.Background())
+	if showCalls != 1 {
+		t.Errorf("expected capabilities to be cached, got %d show calls", showCalls)
+	}
+}
+
+func TestSupportsToolsMissing(t *testing.T) {
+	var showCalls int
+	ts := ollamaStandIn(t, `["completion","vision"]`, &showCalls, &ChatRequest{})
+	defer ts.Close()
+
+	if New(ts.URL, "llava").SupportsTools(context.Background()) {
+		t.Error("expected no tools capability")
+	}
+}
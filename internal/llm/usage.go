@@ -0,0 +1,48 @@
+package llm
+
+import "time"
+
+// Usage is the timing and token accounting Ollama reports with a finished
+// response. Durations arrive as nanoseconds, which decode into time.Duration.
+type Usage struct {
+	TotalDuration      time.Duration `json:"total_duration"`
+	LoadDuration       time.Duration `json:"load_duration"`
+	PromptEvalCount    int           `json:"prompt_eval_count"`
+	PromptEvalDuration time.Duration `json:"prompt_eval_duration"`
+	EvalCount          int           `json:"eval_count"`
+	EvalDuration       time.Duration `json:"eval_duration"`
+}
+
+// PromptRate returns prompt processing speed in tokens per second.
+func (u Usage) PromptRate() float64 {
+	return tokensPerSecond(u.PromptEvalCount, u.PromptEvalDuration)
+}
+
+// EvalRate returns generation speed in tokens per second.
+func (u Usage) EvalRate() float64 {
+	return tokensPerSecond(u.EvalCount, u.EvalDuration)
+}
+
+func tokensPerSecond(tokens int, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(tokens) / d.Seconds()
+}
+
+// reportUsage logs the usage of a call and passes it to OnUsage.
+func (c *Client) reportUsage(u Usage) {
+	reportUsage(c.Model, u, c.OnUsage)
+}
+
+func reportUsage(model string, u Usage, hook func(string, Usage)) {
+	if u == (Usage{}) {
+		return
+	}
+	log.Debug("LLM %s: total %s, load %s, prompt %d tok (%.0f tok/s), eval %d tok (%.0f tok/s)",
+		model, u.TotalDuration.Round(time.Millisecond), u.LoadDuration.Round(time.Millisecond),
+		u.PromptEvalCount, u.PromptRate(), u.EvalCount, u.EvalRate())
+	if hook != nil {
+		hook(model, u)
+	}
+}
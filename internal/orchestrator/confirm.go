@@ -0,0 +1,186 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hey-bobik/internal/tools/obsidian"
+	"strings"
+	"time"
+)
+
+// Risk rates how much harm an action does if the command was misheard.
+type Risk int
+
+const (
+	RiskSafe Risk = iota
+	RiskHigh      // destroys or overwrites data, asked for confirmation first
+)
+
+// PhraseListener is implemented by STT engines that can listen for a short
+// answer restricted to a grammar, e.g. yes or no.
+type PhraseListener interface {
+	ListenForPhrase(audioChan <-chan []int16, grammar string, timeout time.Duration) (string, error)
+}
+
+// BlockingSpeaker is implemented by TTS engines that can tell when a phrase
+// has finished playing.
+type BlockingSpeaker interface {
+	SpeakAndWait(ctx context.Context, text string) error
+}
+
+const (
+	defaultConfirmTimeout = 5 * time.Second
+	confirmPrompt         = "Уверены?"
+	confirmGrammar        = `["да", "нет", "ага", "давай", "подтверждаю", "не надо", "отмена", "[unk]"]`
+)
+
+// yesWords are answers that confirm an action; anything else, silence included, declines it.
+var yesWords = map[string]bool{"да": true, "ага": true, "давай": true, "подтверждаю": true}
+
+// confirm asks the user before a risky action and listens for yes or no.
+// It returns true for safe actions and when confirmation is disabled.
+func (o *Orchestrator) confirm(ctx context.Context, tool Tool, arg string, audioChan <-chan []int16) bool {
+	if !o.ConfirmRisky || tool.Risk == nil {
+		return true
+	}
+	risk, question := tool.Risk(o, arg)
+	if risk < RiskHigh {
+		return true
+	}
+
+	log.Info("Asking to confirm %s: %s", tool.Name, question)
+	o.Notifier.Notify(ctx, "Bobik", question+" (да/нет)")
+	o.ask(ctx, question)
+	drainAudio(audioChan)
+	if o.OnStateChange != nil {
+		o.OnStateChange(StateListening)
+	}
+
+	answer, err := o.listenForAnswer(audioChan)
+	if o.OnStateChange != nil {
+		o.OnStateChange(StateThinking)
+	}
+	if err != nil {
+		log.Warn("Confirmation failed: %v", err)
+		return false
+	}
+	log.Debug("Confirmation answer: %q", answer)
+	return isYes(answer)
+}
+
+// ask speaks the question before listening for the answer. The spoken
+// question leaves out "да/нет", and the quoted user text is only spoken when
+// playback can be waited for, otherwise the microphone could hear a "да" in
+// it and confirm the action by itself.
+func (o *Orchestrator) ask(ctx context.Context, question string) {
+	if bs, ok := o.TTS.(BlockingSpeaker); ok {
+		if err := bs.SpeakAndWait(ctx, question); err != nil {
+			log.Debug("Speaking the question failed: %v", err)
+		}
+		return
+	}
+	o.speak(ctx, confirmPrompt)
+}
+
+// drainAudio drops audio captured before the question was asked.
+func drainAudio(audioChan <-chan []int16) {
+	for {
+		select {
+		case _, ok := <-audioChan:
+			if !ok {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// listenForAnswer uses the yes/no grammar when the engine supports it.
+func (o *Orchestrator) listenForAnswer(audioChan <-chan []int16) (string, error) {
+	timeout := o.ConfirmTimeout
+	if timeout <= 0 {
+		timeout = defaultConfirmTimeout
+	}
+	if pl, ok := o.STT.(PhraseListener); ok {
+		return pl.ListenForPhrase(audioChan, confirmGrammar, timeout)
+	}
+	return o.STT.Transcribe(audioChan)
+}
+
+func isYes(answer string) bool {
+	words := strings.Fields(strings.ToLower(answer))
+	return len(words) > 0 && yesWords[words[0]]
+}
+
+// quote shortens text for a spoken question.
+func quote(text string) string {
+	return "«" + truncateText(strings.TrimSpace(text), 40) + "»"
+}
+
+// noteRisk asks before a rewrite or creating a named note, appending is safe.
+func (o *Orchestrator) noteRisk(arg string) (Risk, string) {
+	if target, ok := strings.CutPrefix(arg, "TO:"); ok {
+		writer, ok := o.Obsidian.(NoteWriter)
+		name, _, _ := strings.Cut(target, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return RiskSafe, ""
+		}
+		if _, err := writer.FindNote(name); errors.Is(err, obsidian.ErrNoteNotFound) {
+			return RiskHigh, "Заметки " + quote(name) + " нет. Создать?"
+		}
+		return RiskSafe, ""
+	}
+	if !strings.HasPrefix(arg, "UPDATE:") {
+		return RiskSafe, ""
+	}
+	content := strings.TrimSpace(strings.TrimPrefix(arg, "UPDATE:"))
+	return RiskHigh, "Заменить последнюю заметку на " + quote(content) + "?"
+}
+
+// cancelRisk describes what would be undone or stopped.
+func (o *Orchestrator) cancelRisk(arg string) (Risk, string) {
+	arg = strings.ToLower(strings.TrimSpace(arg))
+	switch arg {
+	case "timer":
+		return RiskHigh, "Остановить все таймеры?"
+	case "note":
+		if step, ok := o.peekUndoKind(undoNote); ok {
+			return RiskHigh, "Отменить " + describeStep(step) + "?"
+		}
+		return RiskHigh, "Удалить последнюю заметку?"
+	case "all":
+		return RiskHigh, "Удалить последнюю заметку и остановить все таймеры?"
+	}
+
+	steps := o.peekUndo(parseUndoCount(arg))
+	if len(steps) == 0 {
+		// Nothing to undo, the handler just says so
+		return RiskSafe, ""
+	}
+	descs := make([]string, len(steps))
+	for i, step := range steps {
+		descs[i] = describeStep(step)
+	}
+	return RiskHigh, "Отменить " + strings.Join(descs, ", ") + "?"
+}
+
+func describeStep(step undoStep) string {
+	if step.detail == "" || step.kind == undoTimer {
+		return step.desc
+	}
+	return step.desc + " " + quote(step.detail)
+}
+
+// clipboardRisk asks before overwriting something already in the clipboard.
+func (o *Orchestrator) clipboardRisk(arg string) (Risk, string) {
+	if !strings.HasPrefix(strings.TrimSpace(arg), "write:") || o.Clipboard == nil {
+		return RiskSafe, ""
+	}
+	current, err := o.Clipboard.Read()
+	if err != nil || strings.TrimSpace(current) == "" {
+		return RiskSafe, ""
+	}
+	return RiskHigh, fmt.Sprintf("Заменить в буфере %s?", quote(current))
+}
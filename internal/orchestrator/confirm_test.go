@@ -0,0 +1,176 @@
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// phraseSTT transcribes a command, then answers the confirmation question.
+type phraseSTT struct {
+	command string
+	answer  string
+	grammar string
+	asked   int
+	pending int // audio frames still queued when the answer was listened for
+}
+
+func (m *phraseSTT) ListenForWakeWord(audioChan <-chan []int16, grammar string, wakeWord string) (bool, error) {
+	return true, nil
+}
+
+func (m *phraseSTT) Transcribe(audioChan <-chan []int16) (string, error) {
+	return m.command, nil
+}
+
+func (m *phraseSTT) ListenForPhrase(audioChan <-chan []int16, grammar string, timeout time.Duration) (string, error) {
+	m.asked++
+	m.grammar = grammar
+	m.pending = len(audioChan)
+	return m.answer, nil
+}
+
+func newConfirmOrchestrator(command, reply, answer string) (*Orchestrator, *phraseSTT, *mockObsidian, *mockNotifier, *mockHistory) {
+	stt := &phraseSTT{command: command, answer: answer}
+	obs := &mockObsidian{content: "купить хлеб"}
+	notif := &mockNotifier{}
+	store := &mockHistory{}
+	o := &Orchestrator{
+		Recorder:     &mockRecorder{},
+		STT:          stt,
+		Notifier:     notif,
+		LLM:          &mockLLM{response: reply},
+		Obsidian:     obs,
+		Timer:        &mockTimer{},
+		Clipboard:    &mockClipboard{},
+		Memory:       NewContextMemory(5),
+		History:      store,
+		ConfirmRisky: true,
+	}
+	return o, stt, obs, notif, store
+}
+
+func TestConfirmedAction(t *testing.T) {
+	o, stt, obs, _, _ := newConfirmOrchestrator("удали заметку", "ACTION: CANCEL | ARG: note", "да")
+	o.handleCommand(context.Background(), make(chan []int16, 1))
+
+	if stt.asked != 1 || !strings.Contains(stt.grammar, `"да"`) {
+		t.Errorf("expected one yes/no question, asked %d with %s", stt.asked, stt.grammar)
+	}
+	if obs.content != "" {
+		t.Errorf("confirmed delete should run, note is %q", obs.content)
+	}
+}
+
+func TestDeclinedAction(t *testing.T) {
+	for _, answer := range []string{"нет", "", "не надо"} {
+		o, _, obs, notif, store := newConfirmOrchestrator("отмена", "ACTION: CANCEL | ARG: note", answer)
+		o.handleCommand(context.Background(), make(chan []int16, 1))
+
+		if obs.content != "купить хлеб" {
+			t.Errorf("%q: note should be kept, got %q", answer, obs.content)
+		}
+		if notif.message != "Не выполняю" {
+			t.Errorf("%q: expected a decline notification, got %q", answer, notif.message)
+		}
+		if len(store.entries) != 1 || store.entries[0].Error != "not confirmed" {
+			t.Errorf("%q: expected the decline in the history, got %+v", answer, store.entries)
+		}
+	}
+}
+
+// blockingTTS records phrases spoken with SpeakAndWait.
+type blockingTTS struct {
+	mockTTS
+	waited []string
+}
+
+func (m *blockingTTS) SpeakAndWait(ctx context.Context, text string) error {
+	m.waited = append(m.waited, text)
+	return nil
+}
+
+func TestConfirmQuestionSpoken(t *testing.T) {
+	o, stt, _, _, _ := newConfirmOrchestrator("перепиши заметку", "ACTION: NOTE | ARG: UPDATE: да, купить молоко", "нет")
+	tts := &blockingTTS{}
+	o.TTS = tts
+	audio := make(chan []int16, 3)
+	audio <- []int16{1}
+	audio <- []int16{2}
+	o.handleCommand(context.Background(), audio)
+
+	if len(tts.waited) != 1 || !strings.Contains(tts.waited[0], "да, купить молоко") {
+		t.Errorf("expected the full question spoken before listening, got %q", tts.waited)
+	}
+	if stt.pending != 0 {
+		t.Errorf("audio captured before the question should be dropped, %d frames left", stt.pending)
+	}
+
+	// Without waiting for playback the quoted text could answer the question
+	o, _, _, _, _ = newConfirmOrchestrator("перепиши заметку", "ACTION: NOTE | ARG: UPDATE: да, купить молоко", "нет")
+	async := &mockTTS{}
+	o.TTS = async
+	o.handleCommand(context.Background(), make(chan []int16, 1))
+	for _, text := range async.spoken {
+		if strings.Contains(text, "купить молоко") {
+			t.Errorf("quoted text should not be spoken asynchronously, got %q", async.spoken)
+		}
+	}
+}
+
+func TestSafeActionNotConfirmed(t *testing.T) {
+	o, stt, obs, _, _ := newConfirmOrchestrator("запиши тест", "ACTION: NOTE | ARG: тест", "нет")
+	o.handleCommand(context.Background(), make(chan []int16, 1))
+
+	if stt.asked != 0 {
+		t.Error("appending a note should not ask for confirmation")
+	}
+	if obs.content != "тест" {
+		t.Errorf("expected the note to be saved, got %q", obs.content)
+	}
+}
+
+func TestConfirmDisabled(t *testing.T) {
+	o, stt, obs, _, _ := newConfirmOrchestrator("удали заметку", "ACTION: CANCEL | ARG: note", "нет")
+	o.ConfirmRisky = false
+	o.handleCommand(context.Background(), make(chan []int16, 1))
+
+	if stt.asked != 0 || obs.content != "" {
+		t.Errorf("without confirmation the action runs directly, asked %d, note %q", stt.asked, obs.content)
+	}
+}
+
+func TestRiskQuestions(t *testing.T) {
+	o, _, _, _, _ := newConfirmOrchestrator("", "", "")
+	ctx := context.Background()
+
+	if risk, _ := o.clipboardRisk("write:привет"); risk != RiskSafe {
+		t.Error("writing to an empty clipboard is safe")
+	}
+	o.Clipboard.Write("пароль от wifi")
+	if risk, q := o.clipboardRisk("write:привет"); risk != RiskHigh || q != "Заменить в буфере «пароль от wifi»?" {
+		t.Errorf("unexpected clipboard question %v %q", risk, q)
+	}
+
+	if risk, q := o.noteRisk("UPDATE: купить молоко"); risk != RiskHigh || q != "Заменить последнюю заметку на «купить молоко»?" {
+		t.Errorf("unexpected rewrite question %v %q", risk, q)
+	}
+
+	if risk, _ := o.cancelRisk("last"); risk != RiskSafe {
+		t.Error("nothing to undo should not ask")
+	}
+	o.handleNoteAction(ctx, "купить хлеб")
+	o.handleTimerAction(ctx, "60")
+	if _, q := o.cancelRisk("2"); q != "Отменить таймер, заметка «купить хлеб»?" {
+		t.Errorf("unexpected undo question %q", q)
+	}
+}
+
+func TestIsYes(t *testing.T) {
+	for answer, want := range map[string]bool{"да": true, "Ага": true, "да да": true, "нет": false, "": false, "не надо": false} {
+		if got := isYes(answer); got != want {
+			t.Errorf("isYes(%q) = %v, want %v", answer, got, want)
+		}
+	}
+}
@@ -6,8 +6,9 @@ import (
 
 // ContextEntry represents a single interaction in the history.
 type ContextEntry struct {
-	Command string
-	Action  string
+	Command string // what the user said
+	Reply   string // router answer, e.g. "ACTION: NOTE | ARG: ..."
+	Action  string // description of what was done
 }
 
 // ContextMemory stores a rolling history of interactions.
@@ -27,13 +28,18 @@ func NewContextMemory(maxSize int) *ContextMemory {
 
 // Add appends a new entry to the history, removing the oldest if necessary.
 func (m *ContextMemory) Add(command, action string) {
+	m.AddEntry(ContextEntry{Command: command, Action: action})
+}
+
+// AddEntry appends a full entry to the history, removing the oldest if necessary.
+func (m *ContextMemory) AddEntry(entry ContextEntry) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	if len(m.entries) >= m.maxSize {
-		m.entries = append(m.entries[1:], ContextEntry{Command: command, Action: action})
+		m.entries = append(m.entries[1:], entry)
 	} else {
-		m.entries = append(m.entries, ContextEntry{Command: command, Action: action})
+		m.entries = append(m.entries, entry)
 	}
 }
 
@@ -46,3 +52,8 @@ func (m *ContextMemory) GetHistory() []ContextEntry {
 	copy(history, m.entries)
 	return history
 }
+
+// Size returns how many entries the memory keeps.
+func (m *ContextMemory) Size() int {
+	return m.maxSize
+}
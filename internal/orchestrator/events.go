@@ -0,0 +1,28 @@
+package orchestrator
+
+import "time"
+
+// EventType identifies what an Event reports.
+type EventType string
+
+const (
+	EventCommand  EventType = "command"  // transcribed user command
+	EventIntent   EventType = "intent"   // action chosen by the router
+	EventThinking EventType = "thinking" // model reasoning, never spoken or parsed
+	EventResult   EventType = "result"   // description of the executed action
+)
+
+// Event is published to OnEvent as a command is processed.
+type Event struct {
+	Type EventType
+	Time time.Time
+	Text string
+}
+
+// Emit publishes an event to OnEvent if a listener is set.
+// LLM clients call it from their own goroutines, so listeners must be safe for that.
+func (o *Orchestrator) Emit(eventType EventType, text string) {
+	if o.OnEvent != nil {
+		o.OnEvent(Event{Type: eventType, Time: o.now(), Text: text})
+	}
+}
@@ -0,0 +1,28 @@
+package orchestrator
+
+import (
+	"hey-bobik/internal/history"
+)
+
+// recordHistory persists an interaction. A failing store never breaks a command.
+func (o *Orchestrator) recordHistory(e history.Entry) {
+	if o.History == nil {
+		return
+	}
+	e.Time = o.now()
+	if err := o.History.Append(e); err != nil {
+		log.Warn("Failed to save history: %v", err)
+	}
+}
+
+// Restore fills the memory with persisted interactions, oldest first, so
+// follow-ups like "исправь последнюю заметку" work after a restart.
+// Failed commands are skipped, as they never reach the memory at runtime.
+func (m *ContextMemory) Restore(entries []history.Entry) {
+	for _, e := range entries {
+		if !e.Succeeded() {
+			continue
+		}
+		m.AddEntry(ContextEntry{Command: e.Transcript, Reply: e.Intent, Action: e.Result})
+	}
+}
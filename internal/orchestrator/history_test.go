@@ -0,0 +1,79 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"hey-bobik/internal/history"
+	"testing"
+)
+
+type mockHistory struct {
+	entries []history.Entry
+}
+
+func (m *mockHistory) Append(e history.Entry) error {
+	m.entries = append(m.entries, e)
+	return nil
+}
+
+// failingObsidian can't save anything.
+type failingObsidian struct {
+	mockObsidian
+}
+
+func (m *failingObsidian) AppendToDailyNote(content string) error {
+	return errors.New("vault is read-only")
+}
+
+func TestHistoryRecorded(t *testing.T) {
+	store := &mockHistory{}
+	o := &Orchestrator{
+		Recorder: &mockRecorder{},
+		STT:      &mockSTT{transcription: "запиши тест"},
+		Notifier: &mockNotifier{},
+		LLM:      &mockLLM{response: "ACTION: NOTE | ARG: тест"},
+		Obsidian: &mockObsidian{},
+		Memory:   NewContextMemory(5),
+		History:  store,
+	}
+	o.handleCommand(context.Background(), make(chan []int16, 1))
+
+	o.LLM = &mockLLM{err: errors.New("boom")}
+	o.handleCommand(context.Background(), make(chan []int16, 1))
+
+	o.LLM = &mockLLM{response: "ACTION: NOTE | ARG: тест"}
+	o.Obsidian = &failingObsidian{}
+	o.handleCommand(context.Background(), make(chan []int16, 1))
+
+	if len(store.entries) != 3 {
+		t.Fatalf("expected 2 entries, got %+v", store.entries)
+	}
+	ok := store.entries[0]
+	if ok.Transcript != "запиши тест" || ok.Intent != "ACTION: NOTE | ARG: тест" || ok.Result == "" || ok.Time.IsZero() {
+		t.Errorf("unexpected entry %+v", ok)
+	}
+	if failed := store.entries[1]; failed.Error != "boom" || failed.Result != "" {
+		t.Errorf("expected the routing error to be kept, got %+v", failed)
+	}
+	if failed := store.entries[2]; failed.Error == "" || failed.Result != "" || failed.Succeeded() {
+		t.Errorf("expected the failed save recorded as an error, got %+v", failed)
+	}
+}
+
+func TestContextMemoryRestore(t *testing.T) {
+	m := NewContextMemory(2)
+	m.Restore([]history.Entry{
+		{Transcript: "первая", Intent: "ACTION: NOTE | ARG: 1", Result: "Записано: 1"},
+		{Transcript: "вторая", Intent: "ACTION: NOTE | ARG: 2", Result: "Записано: 2"},
+		{Transcript: "сломалась", Error: "timeout"},
+		{Transcript: "третья", Intent: "ACTION: NOTE | ARG: 3", Result: "Записано: 3"},
+	})
+
+	got := m.GetHistory()
+	if len(got) != 2 || got[0].Command != "вторая" || got[1].Command != "третья" {
+		t.Errorf("expected the last two successful entries, got %+v", got)
+	}
+	if got[1].Reply != "ACTION: NOTE | ARG: 3" {
+		t.Errorf("router reply not restored: %+v", got[1])
+	}
+}
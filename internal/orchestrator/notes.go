@@ -0,0 +1,276 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hey-bobik/internal/index"
+	"hey-bobik/internal/llm"
+	"hey-bobik/internal/tools/obsidian"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// NoteReader is implemented by note services that can read the vault back.
+type NoteReader interface {
+	Entries(from, to time.Time) ([]obsidian.Entry, error)
+	Search(query string, from, to time.Time) ([]obsidian.Entry, error) // zero dates search the whole vault
+}
+
+// NoteWriter is implemented by note services that can write to notes other
+// than the daily one, found by their spoken name.
+type NoteWriter interface {
+	FindNote(name string) (string, error)                              // vault-relative path, obsidian.ErrNoteNotFound if there's none
+	AppendToNote(name, content string) (file, entry string, err error) // creates a missing note from its template
+	RemoveFromNote(file, entry string) error
+}
+
+// AttachmentSaver is implemented by note services that can store files such
+// as screenshots in the vault.
+type AttachmentSaver interface {
+	SaveAttachment(name string, data []byte) (string, error) // vault path for an ![[embed]]
+	DeleteAttachment(path string) error
+}
+
+// NotesIndex finds the note chunks closest in meaning to a question.
+type NotesIndex interface {
+	Search(ctx context.Context, query string, k int) ([]index.Result, error)
+}
+
+// askNotesChunks is how many note chunks are given to the LLM as context.
+const askNotesChunks = 5
+
+const askNotesPrompt = "Ты — Бобик, голосовой помощник. Ответь на вопрос только по заметкам пользователя ниже, по-русски, кратко, в 1-3 предложениях, без markdown. " +
+	"В конце укажи в скобках названия заметок, на которые опираешься, например (2026-10-16). Если ответа в заметках нет, так и скажи."
+
+// defaultReadLimit is how much note text is read aloud before it is
+// summarised instead, when SpeechLimit is unlimited.
+const defaultReadLimit = 300
+
+const notesSummaryPrompt = "Ты — Бобик, голосовой помощник. Перескажи заметки пользователя по-русски кратко, в 2-3 предложениях, без markdown и списков. Не добавляй ничего, чего нет в заметках."
+
+func (o *Orchestrator) handleReadAction(ctx context.Context, arg string) string {
+	reader, ok := o.Obsidian.(NoteReader)
+	if !ok {
+		o.Notifier.Notify(ctx, "Bobik Error", "Чтение заметок недоступно")
+		return ""
+	}
+
+	from, to, ok := obsidian.ParsePeriod(strings.TrimSpace(arg), o.now())
+	if !ok {
+		o.Notifier.Notify(ctx, "Bobik", "Не понял, за какой день прочитать")
+		return ""
+	}
+	entries, err := reader.Entries(from, to)
+	if err != nil {
+		log.Error("Note read error: %v", err)
+		o.Notifier.Notify(ctx, "Bobik Error", "Не удалось прочитать заметки")
+		return ""
+	}
+	if len(entries) == 0 {
+		o.Notifier.Notify(ctx, "Bobik", "Заметок нет")
+		o.speak(ctx, "Заметок нет")
+		return "Read notes: none"
+	}
+
+	o.readBack(ctx, "Заметки", "", entries, !from.Equal(to))
+	return fmt.Sprintf("Read %d notes", len(entries))
+}
+
+func (o *Orchestrator) handleSearchAction(ctx context.Context, arg string) string {
+	reader, ok := o.Obsidian.(NoteReader)
+	if !ok {
+		o.Notifier.Notify(ctx, "Bobik Error", "Поиск по заметкам недоступен")
+		return ""
+	}
+
+	// "[период]: запрос" limits the search to daily notes of the period
+	query := strings.TrimSpace(arg)
+	var from, to time.Time
+	if period, rest, found := strings.Cut(query, ":"); found {
+		if f, t, ok := obsidian.ParsePeriod(period, o.now()); ok {
+			from, to, query = f, t, strings.TrimSpace(rest)
+		}
+	}
+	if query == "" || query == "none" {
+		o.Notifier.Notify(ctx, "Bobik", "Не понял, что искать")
+		return ""
+	}
+
+	entries, err := reader.Search(query, from, to)
+	if err != nil {
+		log.Error("Note search error: %v", err)
+		o.Notifier.Notify(ctx, "Bobik Error", "Не удалось найти заметки")
+		return ""
+	}
+	if len(entries) == 0 {
+		o.Notifier.Notify(ctx, "Bobik", "Ничего не нашёл: "+query)
+		o.speak(ctx, "Ничего не нашёл")
+		return "Searched notes: nothing found for " + query
+	}
+
+	o.readBack(ctx, "Найдено: "+query, query, entries, true)
+	return fmt.Sprintf("Found %d notes for %s", len(entries), query)
+}
+
+// readBack shows the entries and reads them aloud, summarised by the LLM
+// when they are too long to listen to.
+func (o *Orchestrator) readBack(ctx context.Context, title, query string, entries []obsidian.Entry, withDate bool) {
+	lines := make([]string, len(entries))
+	for i, e := range entries {
+		lines[i] = formatEntry(e, withDate)
+	}
+	text := strings.Join(lines, "\n")
+	o.Notifier.Notify(ctx, title, truncateText(text, 300))
+
+	limit := o.SpeechLimit
+	if limit <= 0 {
+		limit = defaultReadLimit
+	}
+	if len([]rune(text)) <= limit {
+		o.speak(ctx, text)
+		return
+	}
+
+	summary, err := o.summarizeNotes(ctx, query, text)
+	if err != nil {
+		log.Warn("Note summary failed, reading the beginning: %v", err)
+		summary = truncateText(text, limit)
+	}
+	o.speak(ctx, summary)
+}
+
+func (o *Orchestrator) summarizeNotes(ctx context.Context, query, text string) (string, error) {
+	prompt := "Заметки:\n" + text
+	if query != "" {
+		prompt = "Что искали: " + query + "\n\n" + prompt
+	}
+	summary, err := o.chatClient().Chat(ctx, []llm.Message{
+		{Role: "system", Content: notesSummaryPrompt},
+		{Role: "user", Content: prompt},
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(summary), nil
+}
+
+// formatEntry renders an entry on one line, e.g. "17.10 09:30 — текст".
+// Entries without a date, e.g. REST API matches in undated notes, have none.
+func formatEntry(e obsidian.Entry, withDate bool) string {
+	var when []string
+	if withDate && !e.Date.IsZero() {
+		when = append(when, e.Date.Format("02.01"))
+	}
+	if e.Heading != "" {
+		when = append(when, e.Heading)
+	}
+	content := strings.Join(strings.Fields(e.Content), " ")
+	if len(when) == 0 {
+		return content
+	}
+	return strings.Join(when, " ") + " — " + content
+}
+
+func (o *Orchestrator) handleAskNotesAction(ctx context.Context, arg string) string {
+	if o.Index == nil {
+		// Without embeddings the words of the question are searched instead
+		return o.handleSearchAction(ctx, arg)
+	}
+	question := strings.TrimSpace(arg)
+	if question == "" || question == "none" {
+		o.Notifier.Notify(ctx, "Bobik", "Не понял вопрос")
+		return ""
+	}
+
+	results, err := o.Index.Search(ctx, question, askNotesChunks)
+	if err != nil {
+		log.Error("Notes index search error: %v", err)
+		o.Notifier.Notify(ctx, "Bobik Error", llmErrorMessage(err))
+		return ""
+	}
+	if len(results) == 0 {
+		o.Notifier.Notify(ctx, "Bobik", "В заметках ничего не нашлось")
+		o.speak(ctx, "В заметках ничего не нашлось")
+		return "Asked notes: nothing found"
+	}
+
+	var b strings.Builder
+	var sources []string
+	seen := map[string]bool{}
+	for _, r := range results {
+		name := strings.TrimSuffix(r.File, ".md")
+		fmt.Fprintf(&b, "[%s]\n%s\n\n", name, r.Text)
+		if !seen[name] {
+			seen[name] = true
+			sources = append(sources, name)
+		}
+	}
+	b.WriteString("Вопрос: " + question)
+
+	answer, err := o.LLM.Chat(ctx, []llm.Message{
+		{Role: "system", Content: askNotesPrompt},
+		{Role: "user", Content: b.String()},
+	})
+	if err != nil {
+		log.Error("Notes answer error: %v", err)
+		o.Notifier.Notify(ctx, "Bobik Error", llmErrorMessage(err))
+		return ""
+	}
+
+	answer = strings.TrimSpace(answer)
+	o.Notifier.Notify(ctx, "Ответ по заметкам", truncateText(answer, 200)+"\nИсточники: "+strings.Join(sources, ", "))
+	o.speak(ctx, answer)
+	return fmt.Sprintf("Answered from notes (%s): %s", strings.Join(sources, ", "), truncateText(answer, 200))
+}
+
+// adaptNoteTool leaves named notes out of the prompt when the note service
+// can only write to the daily note.
+func (o *Orchestrator) adaptNoteTool(t Tool) Tool {
+	if _, ok := o.Obsidian.(NoteWriter); ok {
+		return t
+	}
+	t.Description = "Записать или обновить заметку в Obsidian, в ежедневную заметку."
+	t.Rules = slices.DeleteFunc(slices.Clone(t.Rules), func(r string) bool { return strings.Contains(r, "TO:") })
+	t.Examples = slices.DeleteFunc(slices.Clone(t.Examples), func(e Example) bool { return strings.Contains(e.Output, "TO:") })
+	return t
+}
+
+// handleNamedNoteAction appends to a note named by the user, the argument
+// being "[название]: [текст]".
+func (o *Orchestrator) handleNamedNoteAction(ctx context.Context, arg string) string {
+	writer, ok := o.Obsidian.(NoteWriter)
+	if !ok {
+		o.Notifier.Notify(ctx, "Bobik Error", "Запись в другие заметки недоступна")
+		return ""
+	}
+	name, content, _ := strings.Cut(arg, ":")
+	name, content = strings.TrimSpace(name), strings.TrimSpace(content)
+	if name == "" || content == "" {
+		o.Notifier.Notify(ctx, "Bobik", "Не понял, что и куда записать")
+		return ""
+	}
+
+	file, entry, err := writer.AppendToNote(name, content)
+	if errors.Is(err, obsidian.ErrAmbiguousNote) {
+		log.Warn("Note %q is ambiguous: %v", name, err)
+		o.Notifier.Notify(ctx, "Bobik", "Подходит несколько заметок, уточните название: "+name)
+		o.speak(ctx, "Уточните, в какую заметку")
+		return ""
+	}
+	if err != nil {
+		log.Error("Save error: %v", err)
+		o.Notifier.Notify(ctx, "Bobik Error", "Failed to save note")
+		return ""
+	}
+
+	title := strings.TrimSuffix(filepath.Base(file), ".md")
+	o.pushUndo(undoNote, "запись в «"+title+"»", content, func() error {
+		return writer.RemoveFromNote(file, entry)
+	})
+	o.Notifier.Notify(ctx, "Bobik", "Записано в "+file)
+	o.speak(ctx, "Записал в "+title)
+	return fmt.Sprintf("Saved note to %s: %s", strings.TrimSuffix(file, ".md"), content)
+}
@@ -0,0 +1,223 @@
+package orchestrator
+
+import (
+	"context"
+	"hey-bobik/internal/index"
+	"hey-bobik/internal/tools/obsidian"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newNotesOrchestrator(t *testing.T) (*Orchestrator, *obsidian.Service, *mockTTS, *mockLLM) {
+	notes := obsidian.New(t.TempDir(), "")
+	tts := &mockTTS{}
+	chat := &mockLLM{response: "Ты весь день обсуждал релиз."}
+	o := &Orchestrator{
+		Notifier: &mockNotifier{},
+		LLM:      &mockLLM{},
+		ChatLLM:  chat,
+		Obsidian: notes,
+		TTS:      tts,
+		Memory:   NewContextMemory(5),
+	}
+	return o, notes, tts, chat
+}
+
+func TestReadAction(t *testing.T) {
+	o, notes, tts, chat := newNotesOrchestrator(t)
+	ctx := context.Background()
+	now := time.Date(2026, 10, 18, 9, 30, 0, 0, time.Local)
+	notes.Now = func() time.Time { return now }
+	notes.AppendToDailyNote("купить хлеб")
+
+	if got := o.handleReadAction(ctx, "2026-10-18"); got != "Read 1 notes" {
+		t.Errorf("unexpected result %q", got)
+	}
+	if len(tts.spoken) != 1 || tts.spoken[0] != "09:30:00 — купить хлеб" {
+		t.Errorf("expected the entry to be read aloud, got %q", tts.spoken)
+	}
+	if chat.messages != nil {
+		t.Error("a short note should not be summarised")
+	}
+
+	// Too long to listen to, so the LLM summarises it
+	now = now.Add(time.Hour)
+	notes.AppendToDailyNote(strings.Repeat("обсудили релиз и сроки. ", 20))
+	o.handleReadAction(ctx, "2026-10-18")
+	if last := tts.spoken[len(tts.spoken)-1]; last != chat.response {
+		t.Errorf("expected the summary to be spoken, got %q", last)
+	}
+	if len(chat.messages) != 2 || !strings.Contains(chat.messages[1].Content, "купить хлеб") {
+		t.Errorf("the summary should be built from the notes, got %+v", chat.messages)
+	}
+
+	if got := o.handleReadAction(ctx, "2026-10-01"); got != "Read notes: none" {
+		t.Errorf("unexpected result %q", got)
+	}
+}
+
+func TestSearchAction(t *testing.T) {
+	o, notes, tts, _ := newNotesOrchestrator(t)
+	ctx := context.Background()
+	notes.Now = func() time.Time { return time.Date(2026, 10, 16, 11, 0, 0, 0, time.Local) }
+	notes.AppendToDailyNote("релиз перенесли на пятницу")
+	notes.AppendToDailyNote("купить хлеб")
+
+	if got := o.handleSearchAction(ctx, "релиза"); got != "Found 1 notes for релиза" {
+		t.Errorf("unexpected result %q", got)
+	}
+	if last := tts.spoken[len(tts.spoken)-1]; last != "16.10 11:00:00 — релиз перенесли на пятницу" {
+		t.Errorf("unexpected answer %q", last)
+	}
+
+	// The period limits the search to daily notes of those days
+	if got := o.handleSearchAction(ctx, "2026-10-17: релиз"); got != "Searched notes: nothing found for релиз" {
+		t.Errorf("unexpected result %q", got)
+	}
+	if got := o.handleSearchAction(ctx, "2026-10-16: хлеб"); got != "Found 1 notes for хлеб" {
+		t.Errorf("unexpected result %q", got)
+	}
+}
+
+func TestReadWithoutReader(t *testing.T) {
+	n := &mockNotifier{}
+	o := &Orchestrator{Notifier: n, Obsidian: &mockObsidian{}}
+	if got := o.handleReadAction(context.Background(), "today"); got != "" || n.message != "Чтение заметок недоступно" {
+		t.Errorf("unexpected result %q: %q", got, n.message)
+	}
+}
+
+type mockIndex struct {
+	results []index.Result
+	query   string
+}
+
+func (m *mockIndex) Search(ctx context.Context, query string, k int) ([]index.Result, error) {
+	m.query = query
+	return m.results, nil
+}
+
+func TestAskNotesAction(t *testing.T) {
+	router := &mockLLM{response: "Созвон с подрядчиком был 16 октября (2026-10-16)."}
+	idx := &mockIndex{results: []index.Result{
+		{File: "Daily/2026-10-16.md", Text: "2026-10-16\nСозвон с подрядчиком, обсудили смету", Score: 0.9},
+		{File: "Daily/2026-10-16.md", Text: "2026-10-16\nСмету согласовали", Score: 0.7},
+		{File: "Проекты/Ремонт.md", Text: "Ремонт\nПодрядчик: Иван", Score: 0.5},
+	}}
+	n := &mockNotifier{}
+	tts := &mockTTS{}
+	o := &Orchestrator{Notifier: n, LLM: router, ChatLLM: &mockLLM{}, Index: idx, TTS: tts}
+
+	got := o.handleAskNotesAction(context.Background(), "когда у нас был созвон с подрядчиком")
+	if got != "Answered from notes (Daily/2026-10-16, Проекты/Ремонт): "+router.response {
+		t.Errorf("unexpected result %q", got)
+	}
+	if idx.query != "когда у нас был созвон с подрядчиком" {
+		t.Errorf("unexpected index query %q", idx.query)
+	}
+	prompt := router.messages[1].Content
+	if !strings.Contains(prompt, "[Проекты/Ремонт]\nРемонт\nПодрядчик: Иван") || !strings.HasSuffix(prompt, "Вопрос: когда у нас был созвон с подрядчиком") {
+		t.Errorf("the router should get the chunks with their notes, got %q", prompt)
+	}
+	if !strings.HasSuffix(n.message, "Источники: Daily/2026-10-16, Проекты/Ремонт") {
+		t.Errorf("the notification should cite the notes, got %q", n.message)
+	}
+	if len(tts.spoken) != 1 || tts.spoken[0] != router.response {
+		t.Errorf("unexpected speech %q", tts.spoken)
+	}
+
+	idx.results = nil
+	if got := o.handleAskNotesAction(context.Background(), "что такое релиз"); got != "Asked notes: nothing found" {
+		t.Errorf("unexpected result %q", got)
+	}
+}
+
+func TestNamedNoteAction(t *testing.T) {
+	o, notes, tts, _ := newNotesOrchestrator(t)
+	ctx := context.Background()
+	notes.NamedFolder = "Projects"
+	os.MkdirAll(filepath.Join(notes.VaultPath, "Projects"), 0755)
+	os.WriteFile(filepath.Join(notes.VaultPath, "Projects", "Альфа.md"), []byte("# Альфа\n"), 0644)
+
+	if risk, _ := o.noteRisk("TO:проект Альфа: согласовать бюджет"); risk != RiskSafe {
+		t.Error("writing to an existing note should not ask")
+	}
+	if risk, q := o.noteRisk("TO:Гамма: созвон"); risk != RiskHigh || q != "Заметки «Гамма» нет. Создать?" {
+		t.Errorf("unexpected question %v %q", risk, q)
+	}
+
+	got := o.handleNoteAction(ctx, "TO:проект Альфа: согласовать бюджет")
+	if got != "Saved note to Projects/Альфа: согласовать бюджет" {
+		t.Errorf("unexpected result %q", got)
+	}
+	if last := tts.spoken[len(tts.spoken)-1]; last != "Записал в Альфа" {
+		t.Errorf("unexpected speech %q", last)
+	}
+	path := filepath.Join(notes.VaultPath, "Projects", "Альфа.md")
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), " согласовать бюджет\n") {
+		t.Errorf("the entry should be appended, got %q", data)
+	}
+
+	// Undo removes the entry from that note, not from the daily one
+	if got := o.handleCancelAction(ctx, "note"); !strings.Contains(got, "запись в «Альфа»") {
+		t.Errorf("unexpected cancel result %q", got)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "# Альфа\n" {
+		t.Errorf("the entry should be removed, got %q", data)
+	}
+
+	if got := o.handleNoteAction(ctx, "TO:Гамма: созвон"); got != "Saved note to Projects/Гамма: созвон" {
+		t.Errorf("a missing note should be created, got %q", got)
+	}
+	if got := o.handleNoteAction(ctx, "TO:Гамма"); got != "" {
+		t.Errorf("a note without text should not be saved, got %q", got)
+	}
+}
+
+func TestPromptWithoutVaultFiles(t *testing.T) {
+	o, _, _, _ := newNotesOrchestrator(t)
+	if prompt := buildSystemPrompt(o.tools(), false); !strings.Contains(prompt, "TO:") {
+		t.Error("a file vault should offer named notes")
+	}
+
+	// The REST backend only writes the daily note, and leaves tasks and the index out
+	o.Obsidian = &mockObsidian{}
+	prompt := buildSystemPrompt(o.tools(), false)
+	for _, unavailable := range []string{"TO:", "ACTION: TASK", "ACTION: ASK_NOTES"} {
+		if strings.Contains(prompt, unavailable) {
+			t.Errorf("the prompt should not offer %s:\n%s", unavailable, prompt)
+		}
+	}
+	if !strings.Contains(prompt, "ACTION: NOTE | ARG: UPDATE:") {
+		t.Error("rewriting the daily note should still be offered")
+	}
+	if tool, _ := o.findTool("NOTE"); len(tool.Examples) != 2 {
+		t.Errorf("the registry should keep all NOTE examples, got %v", tool.Examples)
+	}
+}
+
+func TestAskNotesWithoutIndex(t *testing.T) {
+	o, notes, _, _ := newNotesOrchestrator(t)
+	notes.Now = func() time.Time { return time.Date(2026, 10, 16, 11, 0, 0, 0, time.Local) }
+	notes.AppendToDailyNote("Созвон с подрядчиком, обсудили смету")
+
+	for _, tool := range o.tools() {
+		if tool.Name == "ASK_NOTES" {
+			t.Fatal("ASK_NOTES should not be offered without an index")
+		}
+	}
+	if prompt := buildSystemPrompt(o.tools(), false); strings.Contains(prompt, "ASK_NOTES") {
+		t.Error("the prompt should send such questions to SEARCH")
+	}
+
+	// A router still answering ASK_NOTES gets a plain search
+	if _, ok := o.findTool("ASK_NOTES"); !ok {
+		t.Fatal("ASK_NOTES should still be handled")
+	}
+	if got := o.handleAskNotesAction(context.Background(), "созвон подрядчиком"); got != "Found 1 notes for созвон подрядчиком" {
+		t.Errorf("expected a search, got %q", got)
+	}
+}
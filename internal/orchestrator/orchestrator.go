@@ -1,13 +1,18 @@
 package orchestrator
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"hey-bobik/internal/history"
+	"hey-bobik/internal/llm"
 	"hey-bobik/internal/logger"
+	"hey-bobik/internal/tools/tasks"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
-	"text/template"
+	"sync"
 	"time"
 )
 
@@ -31,19 +36,41 @@ type Notifier interface {
 
 // LLMClient defines the interface for LLM inference.
 type LLMClient interface {
-	Generate(ctx context.Context, system, prompt string) (string, error)
+	Chat(ctx context.Context, messages []llm.Message) (string, error)
+}
+
+// ToolCallingLLM is implemented by LLM clients with native tool-calling support.
+type ToolCallingLLM interface {
+	ChatWithTools(ctx context.Context, messages []llm.Message, tools []llm.ToolDefinition) (llm.Message, error)
+	SupportsTools(ctx context.Context) bool
+}
+
+// StreamingLLM is implemented by LLM clients that can stream the reply chunk by chunk.
+// onChunk may return llm.ErrStopStream to end the reply early.
+type StreamingLLM interface {
+	ChatStream(ctx context.Context, messages []llm.Message, onChunk func(string) error) (string, error)
 }
 
 // ObsidianService defines the interface for note-taking.
 type ObsidianService interface {
 	AppendToDailyNote(content string) error
-	RewriteLastNote(content string) error
+	RewriteLastNote(content string) (previous string, err error) // returns the replaced text, "" if it appended
 	DeleteLastNote() error
 }
 
+// TaskService manages checkbox tasks across the vault.
+type TaskService interface {
+	Add(text string) (tasks.Task, error) // parses due dates out of the text
+	Today() ([]tasks.Task, error)
+	Complete(query string) (tasks.Task, error) // fuzzy-matches an open task
+	Reopen(task tasks.Task) error
+	Remove(task tasks.Task) error
+}
+
 // TimerService defines the interface for setting timers.
 type TimerService interface {
 	Start(name string, duration time.Duration)
+	Cancel(name string) bool
 	CancelAll() int
 }
 
@@ -82,6 +109,21 @@ type VisionLLMClient interface {
 	GenerateWithImages(ctx context.Context, system, prompt string, images []string) (string, error)
 }
 
+// StreamingVisionLLM is implemented by vision clients that can stream the answer.
+type StreamingVisionLLM interface {
+	GenerateWithImagesStream(ctx context.Context, system, prompt string, images []string, onChunk func(string) error) (string, error)
+}
+
+// UsageRecorder aggregates LLM usage per action.
+type UsageRecorder interface {
+	Record(action string, calls map[string][]llm.Usage)
+}
+
+// HistoryStore keeps interactions across restarts.
+type HistoryStore interface {
+	Append(e history.Entry) error
+}
+
 // State represents the current internal state of the orchestrator.
 type State int
 
@@ -97,8 +139,11 @@ type Orchestrator struct {
 	STT           STTEngine
 	Notifier      Notifier
 	LLM           LLMClient
+	ChatLLM       LLMClient       // general questions (ANSWER), defaults to LLM
 	VisionLLM     VisionLLMClient // Отдельный клиент для vision модели (может быть nil)
 	Obsidian      ObsidianService
+	Tasks         TaskService // optional, TASK is unavailable without it
+	Index         NotesIndex  // optional embeddings of the vault for ASK_NOTES
 	Timer         TimerService
 	Clock         ClockService
 	TTS           TTSService
@@ -106,79 +151,39 @@ type Orchestrator struct {
 	Calc          CalcService
 	Screen        ScreenService // Инструмент для скриншотов
 	Memory        *ContextMemory
+	Tools         []Tool // registry of routable actions, defaults to the built-in tools
+	NativeTools   bool   // prefer native tool calls when the model supports them
+	SpeechLimit   int    // max runes spoken from one streamed answer, 0 is unlimited
 	OnStateChange func(State)
+	OnEvent       func(Event)   // optional event stream listener
+	Usage         UsageRecorder // optional LLM metrics per action
+	History       HistoryStore  // optional persistent log of interactions
+	// Ask "да/нет" before risky actions; no answer within ConfirmTimeout means no
+	ConfirmRisky   bool
+	ConfirmTimeout time.Duration
+	Now            func() time.Time // clock for names and relative dates, defaults to time.Now
+
+	usageMu      sync.Mutex
+	pendingUsage map[string][]llm.Usage // calls of the current command by model
+
+	undoMu    sync.Mutex
+	undoStack []undoStep // inverses of executed actions, newest last
+	timerSeq  int        // numbers timers so each can be undone on its own
 }
 
-const systemPrompt = `Ты — Бобик, интеллектуальный помощник для Linux. 
-Твоя задача: проанализировать ввод пользователя и выбрать одно действие.
-
-Доступные действия:
-1. NOTE: Записать или обновить заметку в Obsidian.
-2. TIMER: Поставить таймер (нужно указать длительность в секундах).
-3. TIME: Сообщить текущее время.
-4. CANCEL: Отменить последнее действие (удалить заметку или остановить таймер).
-5. CLIPBOARD: Работа с буфером обмена (read - прочитать, write - записать, note - записать буфер в заметку).
-6. CALC: Вычислить математическое выражение.
-7. SCREEN: Анализ экрана/скриншота (describe - описать что на экране, read - прочитать текст, window - анализ активного окна).
-
-Формат ответа: ACTION: [ACTION_NAME] | ARG: [VALUE]
-
-Правила:
-- Если просят "записать" или "заметка" -> ACTION: NOTE | ARG: [Текст заметки]
-- Если просят "исправить" или "изменить" последнюю запись -> ACTION: NOTE | ARG: UPDATE: [Новый текст]
-- Если просят "таймер" или "напомни через" -> ACTION: TIMER | ARG: [Кол-во секунд]
-- Если спрашивают "сколько времени" или "час" -> ACTION: TIME | ARG: none
-- Если просят "отменить", "удалить", "отмена" -> ACTION: CANCEL | ARG: [note/timer/all]
-- Если просят "скопировать" текст -> ACTION: CLIPBOARD | ARG: write:[текст]
-- Если просят "что в буфере" или "прочитай буфер" -> ACTION: CLIPBOARD | ARG: read
-- Если просят "вставь из буфера в заметку" -> ACTION: CLIPBOARD | ARG: note
-- Если просят "посчитать", "сколько будет", "калькулятор" -> ACTION: CALC | ARG: [выражение или процент:значение]
-- Если просят "что на экране", "опиши экран", "прочитай с экрана" -> ACTION: SCREEN | ARG: describe
-- Если просят "что в этом окне", "прочитай окно" -> ACTION: SCREEN | ARG: window
-- Если просят прочитать текст с экрана -> ACTION: SCREEN | ARG: read
-
-Примеры:
-Ввод: "запиши купить хлеб"
-Ответ: ACTION: NOTE | ARG: Купить хлеб
-
-Ввод: "поставь таймер на 5 минут"
-Ответ: ACTION: TIMER | ARG: 300
-
-Ввод: "сколько времени"
-Ответ: ACTION: TIME | ARG: none
-
-Ввод: "отмени последнюю заметку"
-Ответ: ACTION: CANCEL | ARG: note
-
-Ввод: "скопируй привет мир"
-Ответ: ACTION: CLIPBOARD | ARG: write:привет мир
-
-Ввод: "посчитай 2 плюс 2"
-Ответ: ACTION: CALC | ARG: 2+2
-
-Ввод: "сколько будет 15 процентов от 2500"
-Ответ: ACTION: CALC | ARG: 15%:2500
-
-Ввод: "посчитай 100 умножить на 5"
-Ответ: ACTION: CALC | ARG: 100*5
-
-Ввод: "что на экране"
-Ответ: ACTION: SCREEN | ARG: describe
-
-Ввод: "прочитай что написано на экране"
-Ответ: ACTION: SCREEN | ARG: read
-
-Контекст:
-{{.Context}}
-
-Ввод: {{.Input}}
-Ответ:`
-
 const (
 	wakeWordGrammar = `["эй бобик", "бобик", "запиши", "сделай", "напомни", "поставь", "[unk]"]`
 	wakeWord        = "эй бобик"
 )
 
+// now returns the current time from the configured clock.
+func (o *Orchestrator) now() time.Time {
+	if o.Now != nil {
+		return o.Now()
+	}
+	return time.Now()
+}
+
 // Start begins the main wake word detection loop.
 func (o *Orchestrator) Start(ctx context.Context) error {
 	log.Info("Bobik is listening for 'Эй, Бобик'...")
@@ -254,57 +259,52 @@ func (o *Orchestrator) handleCommand(ctx context.Context, audioChan <-chan []int
 	if text == "" {
 		return
 	}
+	o.Emit(EventCommand, text)
 
 	if o.OnStateChange != nil {
 		o.OnStateChange(StateThinking)
 	}
 
 	// 3. Process with LLM
-	history := o.Memory.GetHistory()
-	var contextStr strings.Builder
-	for _, entry := range history {
-		contextStr.WriteString(fmt.Sprintf("- Команда: %s, Действие: %s\n", entry.Command, entry.Action))
-	}
-
-	tmpl, _ := template.New("prompt").Parse(systemPrompt)
-	var promptBuf bytes.Buffer
-	tmpl.Execute(&promptBuf, map[string]string{
-		"Context": contextStr.String(),
-		"Input":   text,
-	})
-
-	rawOutput, err := o.LLM.Generate(ctx, "", promptBuf.String())
+	intent, err := o.route(ctx, text)
 	if err != nil {
 		log.Error("LLM error: %v", err)
-		o.Notifier.Notify(ctx, "Bobik Error", "LLM failed")
+		o.Notifier.Notify(ctx, "Bobik Error", llmErrorMessage(err))
+		o.flushUsage(unroutedAction)
+		o.recordHistory(history.Entry{Transcript: text, Error: err.Error()})
 		return
 	}
-	log.Debug("LLM Raw output: %s", rawOutput)
 
 	// 4. Parse Action and Argument
-	action, arg := o.parseLLMOutput(rawOutput)
-	log.Info("Parsed Action: %s, Arg: %s", action, arg)
+	log.Info("Parsed Action: %s, Arg: %s", intent.Action, intent.Arg)
+	o.Emit(EventIntent, intent.String())
 
 	// 5. Dispatch Tool
-	switch action {
-	case "NOTE":
-		o.handleNoteAction(ctx, text, arg)
-	case "TIMER":
-		o.handleTimerAction(ctx, text, arg)
-	case "TIME":
-		o.handleTimeAction(ctx, text)
-	case "CANCEL":
-		o.handleCancelAction(ctx, text, arg)
-	case "CLIPBOARD":
-		o.handleClipboardAction(ctx, text, arg)
-	case "CALC":
-		o.handleCalcAction(ctx, text, arg)
-	case "SCREEN":
-		o.handleScreenAction(ctx, text, arg)
-	default:
-		log.Warn("Unknown action: %s", action)
+	entry := history.Entry{Transcript: text, Intent: intent.String()}
+	tool, ok := o.findTool(intent.Action)
+	switch {
+	case !ok:
+		log.Warn("Unknown action: %s", intent.Action)
 		o.Notifier.Notify(ctx, "Bobik", "Не понял команду")
+		entry.Error = "unknown action " + intent.Action
+	case !o.confirm(ctx, tool, intent.Arg, audioChan):
+		log.Info("%s not confirmed", intent.Action)
+		o.Notifier.Notify(ctx, "Bobik", "Не выполняю")
+		o.speak(ctx, "Отменено")
+		entry.Error = "not confirmed"
+	default:
+		result := tool.Handle(o, ctx, intent.Arg)
+		if result == "" {
+			// The handler already told the user what went wrong
+			entry.Error = "action failed"
+			break
+		}
+		o.Memory.AddEntry(ContextEntry{Command: text, Reply: intent.String(), Action: result})
+		o.Emit(EventResult, result)
+		entry.Result = result
 	}
+	o.flushUsage(intent.Action)
+	o.recordHistory(entry)
 
 	// Drain any leftover audio from the channel to avoid "ghost" commands
 	for len(audioChan) > 0 {
@@ -312,7 +312,100 @@ func (o *Orchestrator) handleCommand(ctx context.Context, audioChan <-chan []int
 	}
 }
 
-func (o *Orchestrator) parseLLMOutput(output string) (string, string) {
+// llmErrorMessage explains an LLM failure to the user by its kind.
+func llmErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, llm.ErrCircuitOpen), errors.Is(err, llm.ErrServerDown):
+		return "Языковая модель недоступна"
+	case errors.Is(err, llm.ErrTimeout):
+		return "Модель не ответила вовремя"
+	case errors.Is(err, llm.ErrModelNotFound):
+		return "Модель не найдена"
+	default:
+		return "LLM failed"
+	}
+}
+
+// route asks the LLM which action to take, preferring native tool calls.
+func (o *Orchestrator) route(ctx context.Context, text string) (Intent, error) {
+	if tc, ok := o.LLM.(ToolCallingLLM); ok && o.NativeTools && tc.SupportsTools(ctx) {
+		intent, err := o.routeNative(ctx, tc, text)
+		if err == nil {
+			return intent, nil
+		}
+		log.Warn("Native tool call failed, falling back to prompt routing: %v", err)
+	}
+
+	messages := o.buildMessages(text, false)
+	var rawOutput string
+	var err error
+	if sc, ok := o.LLM.(StreamingLLM); ok {
+		// Dispatch as soon as the action line is complete instead of waiting for the whole reply
+		rawOutput, err = sc.ChatStream(ctx, messages, stopAfterActionLine())
+	} else {
+		rawOutput, err = o.LLM.Chat(ctx, messages)
+	}
+	if err != nil {
+		return Intent{}, err
+	}
+	log.Debug("LLM Raw output: %s", rawOutput)
+
+	if line, ok := actionLine(rawOutput, true); ok {
+		// Ignore any text the model wrote around the action
+		rawOutput = line
+	}
+	return o.parseLLMOutput(rawOutput), nil
+}
+
+// routeNative routes via Ollama's structured tool calls.
+func (o *Orchestrator) routeNative(ctx context.Context, tc ToolCallingLLM, text string) (Intent, error) {
+	msg, err := tc.ChatWithTools(ctx, o.buildMessages(text, true), toolDefinitions(o.tools()))
+	if err != nil {
+		return Intent{}, err
+	}
+
+	if len(msg.ToolCalls) == 0 {
+		// Some models still answer in text, so parse it the prompt way
+		log.Debug("LLM answered without tool call: %s", msg.Content)
+		return o.parseLLMOutput(msg.Content), nil
+	}
+
+	call := msg.ToolCalls[0].Function
+	log.Debug("LLM tool call: %s(%v)", call.Name, call.Arguments)
+	return Intent{Action: strings.ToUpper(call.Name), Arg: toolArg(call.Arguments)}, nil
+}
+
+// buildMessages turns the history into chat turns after the tool-registry system message.
+// With native tools, past actions are replayed as tool calls and their results.
+func (o *Orchestrator) buildMessages(input string, native bool) []llm.Message {
+	messages := []llm.Message{{Role: "system", Content: buildSystemPrompt(o.tools(), native)}}
+	for _, entry := range o.Memory.GetHistory() {
+		messages = append(messages, llm.Message{Role: "user", Content: entry.Command})
+
+		if native && entry.Reply != "" {
+			intent := o.parseLLMOutput(entry.Reply)
+			messages = append(messages,
+				llm.Message{Role: "assistant", ToolCalls: []llm.ToolCall{{
+					Function: llm.ToolCallFunction{
+						Name:      intent.Action,
+						Arguments: map[string]interface{}{toolArgName: intent.Arg},
+					},
+				}}},
+				llm.Message{Role: "tool", Content: entry.Action},
+			)
+			continue
+		}
+
+		reply := entry.Reply
+		if reply == "" {
+			reply = entry.Action
+		}
+		messages = append(messages, llm.Message{Role: "assistant", Content: reply})
+	}
+	return append(messages, llm.Message{Role: "user", Content: input})
+}
+
+func (o *Orchestrator) parseLLMOutput(output string) Intent {
 	// Format: ACTION: [ACTION_NAME] | ARG: [VALUE]
 	parts := strings.Split(output, "|")
 	action := ""
@@ -332,10 +425,14 @@ func (o *Orchestrator) parseLLMOutput(output string) (string, string) {
 			arg = val
 		}
 	}
-	return action, arg
+	return Intent{Action: action, Arg: arg}
 }
 
-func (o *Orchestrator) handleNoteAction(ctx context.Context, rawInput, arg string) {
+func (o *Orchestrator) handleNoteAction(ctx context.Context, arg string) string {
+	if target, ok := strings.CutPrefix(arg, "TO:"); ok {
+		return o.handleNamedNoteAction(ctx, target)
+	}
+
 	isUpdate := false
 	noteContent := arg
 	if strings.HasPrefix(arg, "UPDATE:") {
@@ -345,8 +442,9 @@ func (o *Orchestrator) handleNoteAction(ctx context.Context, rawInput, arg strin
 	}
 
 	var err error
+	var previous string
 	if isUpdate {
-		err = o.Obsidian.RewriteLastNote(noteContent)
+		previous, err = o.Obsidian.RewriteLastNote(noteContent)
 	} else {
 		err = o.Obsidian.AppendToDailyNote(noteContent)
 	}
@@ -354,79 +452,187 @@ func (o *Orchestrator) handleNoteAction(ctx context.Context, rawInput, arg strin
 	if err != nil {
 		log.Error("Save error: %v", err)
 		o.Notifier.Notify(ctx, "Bobik Error", "Failed to save note")
-		return
+		return ""
+	}
+
+	if previous != "" {
+		o.pushUndo(undoNote, "исправление заметки", noteContent, func() error {
+			_, err := o.Obsidian.RewriteLastNote(previous)
+			return err
+		})
+	} else {
+		o.pushUndo(undoNote, "заметка", noteContent, o.Obsidian.DeleteLastNote)
 	}
 
 	actionDesc := "Saved note"
 	if isUpdate {
 		actionDesc = "Updated last note"
 	}
-	o.Memory.Add(rawInput, fmt.Sprintf("%s: %s", actionDesc, noteContent))
 	o.Notifier.Notify(ctx, "Bobik", "Заметка сохранена")
 	o.speak(ctx, "Записал")
+	return fmt.Sprintf("%s: %s", actionDesc, noteContent)
 }
 
-func (o *Orchestrator) handleTimerAction(ctx context.Context, rawInput, arg string) {
+func (o *Orchestrator) handleTaskAction(ctx context.Context, arg string) string {
+	if o.Tasks == nil {
+		o.Notifier.Notify(ctx, "Bobik Error", "Задачи недоступны")
+		return ""
+	}
+
+	arg = strings.TrimSpace(arg)
+
+	switch {
+	case arg == "list":
+		today, err := o.Tasks.Today()
+		if err != nil {
+			log.Error("Task list error: %v", err)
+			o.Notifier.Notify(ctx, "Bobik Error", "Не удалось прочитать задачи")
+			return ""
+		}
+		if len(today) == 0 {
+			o.Notifier.Notify(ctx, "Bobik", "На сегодня задач нет")
+			o.speak(ctx, "На сегодня задач нет")
+			return "Listed tasks: none"
+		}
+		lines := make([]string, len(today))
+		texts := make([]string, len(today))
+		for i, t := range today {
+			lines[i] = "• " + t.Text
+			texts[i] = t.Text
+		}
+		o.Notifier.Notify(ctx, "Задачи на сегодня", strings.Join(lines, "\n"))
+		o.speak(ctx, "Задачи на сегодня: "+strings.Join(texts, ", "))
+		return fmt.Sprintf("Listed %d tasks", len(today))
+
+	case strings.HasPrefix(arg, "done:"):
+		query := strings.TrimSpace(strings.TrimPrefix(arg, "done:"))
+		task, err := o.Tasks.Complete(query)
+		switch {
+		case errors.Is(err, tasks.ErrNotFound):
+			o.Notifier.Notify(ctx, "Bobik", "Не нашёл задачу: "+query)
+			o.speak(ctx, "Не нашёл такую задачу")
+			return ""
+		case errors.Is(err, tasks.ErrAmbiguous):
+			o.Notifier.Notify(ctx, "Bobik", "Подходит несколько задач: "+query)
+			o.speak(ctx, "Подходит несколько задач, уточни")
+			return ""
+		case err != nil:
+			log.Error("Task complete error: %v", err)
+			o.Notifier.Notify(ctx, "Bobik Error", "Не удалось отметить задачу")
+			return ""
+		}
+		o.pushUndo(undoTask, "выполнение задачи", task.Text, func() error {
+			return o.Tasks.Reopen(task)
+		})
+		o.Notifier.Notify(ctx, "Bobik", "Выполнено: "+task.Text)
+		o.speak(ctx, "Отметил")
+		return "Completed task: " + task.Text
+
+	default:
+		task, err := o.Tasks.Add(arg)
+		if err != nil {
+			log.Error("Task save error: %v", err)
+			o.Notifier.Notify(ctx, "Bobik Error", "Не удалось сохранить задачу")
+			return ""
+		}
+		o.pushUndo(undoTask, "задача", task.Text, func() error {
+			return o.Tasks.Remove(task)
+		})
+		line := strings.TrimPrefix(task.Raw, "- [ ] ")
+		o.Notifier.Notify(ctx, "Bobik", "Задача добавлена: "+line)
+		o.speak(ctx, "Задача добавлена")
+		return "Added task: " + line
+	}
+}
+
+func (o *Orchestrator) handleTimerAction(ctx context.Context, arg string) string {
 	seconds, err := strconv.Atoi(arg)
 	if err != nil {
 		log.Warn("Invalid timer arg: %s", arg)
 		o.Notifier.Notify(ctx, "Bobik Error", "Ошибка времени")
-		return
+		return ""
 	}
 
 	duration := time.Duration(seconds) * time.Second
-	o.Timer.Start("Голосовой таймер", duration)
+	o.undoMu.Lock()
+	o.timerSeq++
+	name := fmt.Sprintf("Голосовой таймер %d", o.timerSeq)
+	o.undoMu.Unlock()
+	o.Timer.Start(name, duration)
+	o.pushUndo(undoTimer, "таймер", name, func() error {
+		if !o.Timer.Cancel(name) {
+			return fmt.Errorf("timer %q already finished", name)
+		}
+		return nil
+	})
 
-	o.Memory.Add(rawInput, fmt.Sprintf("Set timer for %d seconds", seconds))
 	o.Notifier.Notify(ctx, "Bobik", fmt.Sprintf("Таймер запущен на %d сек", seconds))
 	o.speak(ctx, "Таймер запущен")
+	return fmt.Sprintf("Set timer for %d seconds", seconds)
 }
 
-func (o *Orchestrator) handleTimeAction(ctx context.Context, rawInput string) {
+func (o *Orchestrator) handleTimeAction(ctx context.Context, arg string) string {
 	currentTime := o.Clock.GetCurrentTime()
 	o.Notifier.Notify(ctx, "Bobik Time", currentTime)
 	o.speak(ctx, "Сейчас "+currentTime)
-	o.Memory.Add(rawInput, "Reported current time")
+	return "Reported current time"
 }
 
-func (o *Orchestrator) handleCancelAction(ctx context.Context, rawInput, arg string) {
+func (o *Orchestrator) handleCancelAction(ctx context.Context, arg string) string {
 	arg = strings.ToLower(strings.TrimSpace(arg))
 
 	var cancelled []string
 
-	// Cancel note
+	// Undo the latest note change
 	if arg == "note" || arg == "all" {
-		if err := o.Obsidian.DeleteLastNote(); err == nil {
-			cancelled = append(cancelled, "заметка")
+		step, ok := o.popUndoKind(undoNote)
+		if !ok {
+			// Nothing recorded in this session, e.g. after a restart
+			step = undoStep{desc: "заметка", undo: o.Obsidian.DeleteLastNote}
+		}
+		if err := step.undo(); err == nil {
+			cancelled = append(cancelled, step.desc)
 		} else {
-			log.Debug("No note to cancel: %v", err)
+			log.Debug("Failed to undo %s: %v", step.desc, err)
 		}
 	}
 
-	// Cancel timer(s)
+	// Stop all timers
 	if arg == "timer" || arg == "all" {
 		count := o.Timer.CancelAll()
+		o.dropUndo(undoTimer)
 		if count > 0 {
 			cancelled = append(cancelled, fmt.Sprintf("%d таймер(ов)", count))
 		}
 	}
 
+	// Undo the latest actions of any kind
+	if n := parseUndoCount(arg); n > 0 {
+		for _, step := range o.popUndo(n) {
+			if err := step.undo(); err != nil {
+				log.Warn("Failed to undo %s: %v", step.desc, err)
+				continue
+			}
+			cancelled = append(cancelled, step.desc)
+		}
+	}
+
 	if len(cancelled) == 0 {
 		o.Notifier.Notify(ctx, "Bobik", "Нечего отменять")
 		o.speak(ctx, "Нечего отменять")
-		return
+		return ""
 	}
 
 	msg := "Отменено: " + strings.Join(cancelled, ", ")
-	o.Memory.Add(rawInput, msg)
 	o.Notifier.Notify(ctx, "Bobik", msg)
 	o.speak(ctx, "Отменено")
+	return msg
 }
 
-func (o *Orchestrator) handleClipboardAction(ctx context.Context, rawInput, arg string) {
+func (o *Orchestrator) handleClipboardAction(ctx context.Context, arg string) string {
 	if o.Clipboard == nil {
 		o.Notifier.Notify(ctx, "Bobik Error", "Буфер обмена недоступен")
-		return
+		return ""
 	}
 
 	arg = strings.TrimSpace(arg)
@@ -437,58 +643,64 @@ func (o *Orchestrator) handleClipboardAction(ctx context.Context, rawInput, arg
 		if err != nil {
 			log.Error("Clipboard read error: %v", err)
 			o.Notifier.Notify(ctx, "Bobik Error", "Не удалось прочитать буфер")
-			return
+			return ""
 		}
 		// Truncate for notification if too long
-		display := content
-		if len(display) > 100 {
-			display = display[:100] + "..."
-		}
+		display := truncateText(content, 100)
 		o.Notifier.Notify(ctx, "Буфер обмена", display)
 		o.speak(ctx, "В буфере: "+display)
-		o.Memory.Add(rawInput, "Read clipboard")
+		return "Read clipboard"
 
 	case arg == "note":
 		content, err := o.Clipboard.Read()
 		if err != nil {
 			log.Error("Clipboard read error: %v", err)
 			o.Notifier.Notify(ctx, "Bobik Error", "Не удалось прочитать буфер")
-			return
+			return ""
 		}
 		if content == "" {
 			o.Notifier.Notify(ctx, "Bobik", "Буфер пуст")
-			return
+			return ""
 		}
 		if err := o.Obsidian.AppendToDailyNote(content); err != nil {
 			log.Error("Save note error: %v", err)
 			o.Notifier.Notify(ctx, "Bobik Error", "Не удалось сохранить заметку")
-			return
+			return ""
 		}
+		o.pushUndo(undoNote, "заметка", content, o.Obsidian.DeleteLastNote)
 		o.Notifier.Notify(ctx, "Bobik", "Буфер сохранен в заметку")
 		o.speak(ctx, "Сохранено")
-		o.Memory.Add(rawInput, "Saved clipboard to note")
+		return "Saved clipboard to note"
 
 	case strings.HasPrefix(arg, "write:"):
 		content := strings.TrimPrefix(arg, "write:")
 		content = strings.TrimSpace(content)
+		previous, err := o.Clipboard.Read()
+		if err != nil {
+			log.Debug("Clipboard read before write failed, undo will clear it: %v", err)
+		}
 		if err := o.Clipboard.Write(content); err != nil {
 			log.Error("Clipboard write error: %v", err)
 			o.Notifier.Notify(ctx, "Bobik Error", "Не удалось записать в буфер")
-			return
+			return ""
 		}
+		o.pushUndo(undoClipboard, "буфер обмена", content, func() error {
+			return o.Clipboard.Write(previous)
+		})
 		o.Notifier.Notify(ctx, "Bobik", "Скопировано в буфер")
 		o.speak(ctx, "Скопировано")
-		o.Memory.Add(rawInput, "Wrote to clipboard: "+content)
+		return "Wrote to clipboard: " + content
 
 	default:
 		o.Notifier.Notify(ctx, "Bobik", "Неизвестная операция с буфером")
+		return ""
 	}
 }
 
-func (o *Orchestrator) handleCalcAction(ctx context.Context, rawInput, arg string) {
+func (o *Orchestrator) handleCalcAction(ctx context.Context, arg string) string {
 	if o.Calc == nil {
 		o.Notifier.Notify(ctx, "Bobik Error", "Калькулятор недоступен")
-		return
+		return ""
 	}
 
 	arg = strings.TrimSpace(arg)
@@ -517,13 +729,72 @@ func (o *Orchestrator) handleCalcAction(ctx context.Context, rawInput, arg strin
 		log.Error("Calc error: %v", err)
 		o.Notifier.Notify(ctx, "Bobik Error", "Ошибка вычисления")
 		o.speak(ctx, "Не могу посчитать")
-		return
+		return ""
 	}
 
 	formatted := o.Calc.FormatResult(result)
 	o.Notifier.Notify(ctx, "Результат", formatted)
 	o.speak(ctx, formatted)
-	o.Memory.Add(rawInput, fmt.Sprintf("Calculated: %s = %s", arg, formatted))
+	return fmt.Sprintf("Calculated: %s = %s", arg, formatted)
+}
+
+const answerPrompt = "Ты — Бобик, голосовой помощник. Ответь на вопрос по-русски кратко, в 2-4 предложениях, без markdown и списков."
+
+func (o *Orchestrator) handleAnswerAction(ctx context.Context, arg string) string {
+	question := strings.TrimSpace(arg)
+	if question == "" || question == "none" {
+		o.Notifier.Notify(ctx, "Bobik", "Не понял вопрос")
+		return ""
+	}
+
+	messages := []llm.Message{
+		{Role: "system", Content: answerPrompt},
+		{Role: "user", Content: question},
+	}
+
+	// Stream the answer so the first sentence is spoken while the rest is generated
+	var answer string
+	var err error
+	streamed := false
+	client := o.chatClient()
+	if sc, ok := client.(StreamingLLM); ok {
+		speaker := o.newSentenceSpeaker(ctx)
+		answer, err = sc.ChatStream(ctx, messages, speaker.Write)
+		speaker.Flush()
+		streamed = speaker.Spoke()
+	} else {
+		answer, err = client.Chat(ctx, messages)
+	}
+	if err != nil {
+		log.Error("Answer error: %v", err)
+		o.Notifier.Notify(ctx, "Bobik Error", "Не удалось ответить")
+		return ""
+	}
+
+	answer = strings.TrimSpace(answer)
+	displayText := truncateText(answer, 200)
+	o.Notifier.Notify(ctx, "Ответ", displayText)
+	if !streamed {
+		o.speak(ctx, answer)
+	}
+	return "Answered: " + displayText
+}
+
+// chatClient returns the model for free-form answers.
+func (o *Orchestrator) chatClient() LLMClient {
+	if o.ChatLLM != nil {
+		return o.ChatLLM
+	}
+	return o.LLM
+}
+
+// truncateText shortens text to maxRunes characters without splitting a rune.
+func truncateText(text string, maxRunes int) string {
+	runes := []rune(text)
+	if len(runes) <= maxRunes {
+		return text
+	}
+	return string(runes[:maxRunes]) + "..."
 }
 
 // speak uses TTS if available.
@@ -533,22 +804,31 @@ func (o *Orchestrator) speak(ctx context.Context, text string) {
 	}
 }
 
+const screenNotePrompt = "Опиши, что на этом скриншоте, в 1-2 предложениях. Затем с новой строки выпиши весь важный текст со скриншота. Без markdown и комментариев."
+
 // handleScreenAction обрабатывает команды анализа экрана с использованием vision модели.
-func (o *Orchestrator) handleScreenAction(ctx context.Context, rawInput, arg string) {
+func (o *Orchestrator) handleScreenAction(ctx context.Context, arg string) string {
 	// Проверяем доступность компонентов
 	if o.Screen == nil {
 		o.Notifier.Notify(ctx, "Bobik Error", "Скриншоты недоступны")
 		o.speak(ctx, "Скриншоты недоступны")
-		return
+		return ""
 	}
 	if o.VisionLLM == nil {
 		o.Notifier.Notify(ctx, "Bobik Error", "Vision модель не настроена")
 		o.speak(ctx, "Vision модель не настроена")
-		return
+		return ""
 	}
 
 	arg = strings.ToLower(strings.TrimSpace(arg))
 
+	// Сохранение в заметку требует хранилища, умеющего принимать вложения
+	saver, canSave := o.Obsidian.(AttachmentSaver)
+	if arg == "note" && !canSave {
+		o.Notifier.Notify(ctx, "Bobik Error", "Сохранение скриншотов в заметки недоступно")
+		return ""
+	}
+
 	o.Notifier.Notify(ctx, "Bobik", "Делаю скриншот...")
 	o.speak(ctx, "Секунду")
 
@@ -566,7 +846,7 @@ func (o *Orchestrator) handleScreenAction(ctx context.Context, rawInput, arg str
 		log.Error("Screenshot error: %v", err)
 		o.Notifier.Notify(ctx, "Bobik Error", "Не удалось сделать скриншот")
 		o.speak(ctx, "Не удалось сделать скриншот")
-		return
+		return ""
 	}
 
 	// Очистим файл после обработки
@@ -583,38 +863,88 @@ func (o *Orchestrator) handleScreenAction(ctx context.Context, rawInput, arg str
 		visionPrompt = "Прочитай весь текст, который ты видишь на этом скриншоте. Выведи только текст, без комментариев."
 	case "window":
 		visionPrompt = "Опиши содержимое этого окна. Что это за программа? Что на экране?"
+	case "note":
+		visionPrompt = screenNotePrompt
 	default: // describe
 		visionPrompt = "Опиши что ты видишь на этом скриншоте. Кратко, 2-3 предложения."
 	}
 
 	o.Notifier.Notify(ctx, "Bobik", "Анализирую изображение...")
 
-	// Отправляем в vision модель
-	response, err := o.VisionLLM.GenerateWithImages(ctx, "", visionPrompt, []string{base64Image})
+	// Отправляем в vision модель; при стриминге читаем ответ по предложениям по мере генерации
+	images := []string{base64Image}
+	var response string
+	streamed := false
+	// Для заметки ответ не читается вслух, поэтому стриминг не нужен
+	if sv, ok := o.VisionLLM.(StreamingVisionLLM); ok && arg != "note" {
+		speaker := o.newSentenceSpeaker(ctx)
+		response, err = sv.GenerateWithImagesStream(ctx, "", visionPrompt, images, speaker.Write)
+		speaker.Flush()
+		streamed = speaker.Spoke()
+	} else {
+		response, err = o.VisionLLM.GenerateWithImages(ctx, "", visionPrompt, images)
+	}
 	if err != nil {
 		log.Error("Vision LLM error: %v", err)
-		o.Notifier.Notify(ctx, "Bobik Error", "Ошибка анализа изображения")
+		o.Notifier.Notify(ctx, "Bobik Error", "Ошибка анализа изображения: "+llmErrorMessage(err))
 		o.speak(ctx, "Не удалось проанализировать")
-		return
+		return ""
 	}
 
 	response = strings.TrimSpace(response)
 
+	if arg == "note" {
+		return o.saveScreenToNote(ctx, saver, filePath, response)
+	}
+
 	// Показываем результат
 	// Ограничиваем длину для уведомления
-	displayText := response
-	if len(displayText) > 200 {
-		displayText = displayText[:200] + "..."
-	}
+	displayText := truncateText(response, 200)
 
 	o.Notifier.Notify(ctx, "Экран", displayText)
 
-	// Для TTS ограничиваем ещё больше
-	speakText := response
-	if len(speakText) > 150 {
-		speakText = speakText[:150]
+	// TTS сам нормализует текст и обрезает его по границе предложения
+	if !streamed {
+		o.speak(ctx, response)
 	}
-	o.speak(ctx, speakText)
 
-	o.Memory.Add(rawInput, fmt.Sprintf("Screen analysis: %s", displayText))
+	return fmt.Sprintf("Screen analysis: %s", displayText)
+}
+
+// saveScreenToNote копирует скриншот во вложения хранилища и добавляет в
+// ежедневную заметку запись со встроенной картинкой и её описанием.
+func (o *Orchestrator) saveScreenToNote(ctx context.Context, saver AttachmentSaver, filePath, analysis string) string {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		log.Error("Failed to read screenshot: %v", err)
+		o.Notifier.Notify(ctx, "Bobik Error", "Не удалось сохранить скриншот")
+		return ""
+	}
+	name := "Screenshot " + o.now().Format("20060102150405") + filepath.Ext(filePath)
+	attachment, err := saver.SaveAttachment(name, data)
+	if err != nil {
+		log.Error("Failed to save screenshot: %v", err)
+		o.Notifier.Notify(ctx, "Bobik Error", "Не удалось сохранить скриншот")
+		return ""
+	}
+
+	content := "![[" + attachment + "]]"
+	if analysis != "" {
+		content += "\n" + analysis
+	}
+	if err := o.Obsidian.AppendToDailyNote(content); err != nil {
+		log.Error("Save error: %v", err)
+		o.Notifier.Notify(ctx, "Bobik Error", "Failed to save note")
+		return ""
+	}
+
+	o.pushUndo(undoNote, "скриншот в заметке", analysis, func() error {
+		if err := o.Obsidian.DeleteLastNote(); err != nil {
+			return err
+		}
+		return saver.DeleteAttachment(attachment)
+	})
+	o.Notifier.Notify(ctx, "Bobik", "Скриншот сохранён: "+attachment)
+	o.speak(ctx, "Сохранил экран в заметку")
+	return fmt.Sprintf("Saved screen to note (%s): %s", attachment, truncateText(analysis, 200))
 }
@@ -2,6 +2,9 @@ package orchestrator
 
 import (
 	"context"
+	"fmt"
+	"hey-bobik/internal/llm"
+	"strings"
 	"testing"
 	"time"
 )
@@ -40,10 +43,13 @@ func (m *mockNotifier) Notify(ctx context.Context, title, message string) error
 
 type mockLLM struct {
 	response string
+	err      error
+	messages []llm.Message
 }
 
-func (m *mockLLM) Generate(ctx context.Context, system, prompt string) (string, error) {
-	return m.response, nil
+func (m *mockLLM) Chat(ctx context.Context, messages []llm.Message) (string, error) {
+	m.messages = messages
+	return m.response, m.err
 }
 
 type mockObsidian struct {
@@ -55,9 +61,10 @@ func (m *mockObsidian) AppendToDailyNote(content string) error {
 	return nil
 }
 
-func (m *mockObsidian) RewriteLastNote(content string) error {
+func (m *mockObsidian) RewriteLastNote(content string) (string, error) {
+	previous := m.content
 	m.content = "REWRITTEN: " + content
-	return nil
+	return previous, nil
 }
 
 func (m *mockObsidian) DeleteLastNote() error {
@@ -71,6 +78,8 @@ type mockTimer struct {
 
 func (m *mockTimer) Start(name string, duration time.Duration) {}
 
+func (m *mockTimer) Cancel(name string) bool { return true }
+
 func (m *mockTimer) CancelAll() int {
 	m.cancelled++
 	return 1
@@ -161,3 +170,212 @@ func TestOrchestratorUpdateFlow(t *testing.T) {
 		t.Errorf("expected 'REWRITTEN: купить кефир', got %s", obs.content)
 	}
 }
+
+func TestTruncateText(t *testing.T) {
+	got := truncateText("привет мир", 6)
+	if got != "привет..." {
+		t.Errorf("expected rune-safe truncation, got %q", got)
+	}
+	if truncateText("коротко", 100) != "коротко" {
+		t.Error("short text should be unchanged")
+	}
+}
+
+func TestChatHistoryTurns(t *testing.T) {
+	llmClient := &mockLLM{response: "ACTION: TIME | ARG: none"}
+	memory := NewContextMemory(5)
+	memory.AddEntry(ContextEntry{Command: "запиши хлеб", Reply: "ACTION: NOTE | ARG: хлеб", Action: "Saved note: хлеб"})
+
+	o := &Orchestrator{
+		Recorder: &mockRecorder{},
+		STT:      &mockSTT{transcription: "сколько времени"},
+		Notifier: &mockNotifier{},
+		LLM:      llmClient,
+		Obsidian: &mockObsidian{},
+		Timer:    &mockTimer{},
+		Clock:    &mockClock{},
+		Memory:   memory,
+	}
+
+	o.handleCommand(context.Background(), make(chan []int16, 1))
+
+	msgs := llmClient.messages
+	if len(msgs) != 4 {
+		t.Fatalf("expected system + 2 history turns + input, got %d messages", len(msgs))
+	}
+	if msgs[0].Role != "system" || !strings.Contains(msgs[0].Content, "1. NOTE:") {
+		t.Errorf("expected system message built from tool registry, got %q", msgs[0].Content)
+	}
+	if msgs[1].Role != "user" || msgs[1].Content != "запиши хлеб" {
+		t.Errorf("unexpected user turn: %+v", msgs[1])
+	}
+	if msgs[2].Role != "assistant" || msgs[2].Content != "ACTION: NOTE | ARG: хлеб" {
+		t.Errorf("unexpected assistant turn: %+v", msgs[2])
+	}
+	if msgs[3].Role != "user" || msgs[3].Content != "сколько времени" {
+		t.Errorf("unexpected input turn: %+v", msgs[3])
+	}
+
+	history := memory.GetHistory()
+	last := history[len(history)-1]
+	if last.Reply != "ACTION: TIME | ARG: none" || last.Action != "Reported current time" {
+		t.Errorf("unexpected recorded entry: %+v", last)
+	}
+}
+
+func TestSystemPromptFromRegistry(t *testing.T) {
+	prompt := buildSystemPrompt(defaultTools(), false)
+	for _, want := range []string{
+		"11. SCREEN:",
+		"Формат ответа: ACTION: [ACTION_NAME] | ARG: [VALUE]",
+		`- Если просят "таймер" или "напомни через" -> ACTION: TIMER | ARG: [Кол-во секунд]`,
+		"Ввод: \"поставь таймер на 5 минут\"\nОтвет: ACTION: TIMER | ARG: 300",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("system prompt missing %q", want)
+		}
+	}
+}
+
+type mockToolLLM struct {
+	mockLLM
+	supports  bool
+	toolCalls []llm.ToolCall
+	toolErr   error
+	chatCalls int
+}
+
+func (m *mockToolLLM) Chat(ctx context.Context, messages []llm.Message) (string, error) {
+	m.chatCalls++
+	return m.mockLLM.Chat(ctx, messages)
+}
+
+func (m *mockToolLLM) ChatWithTools(ctx context.Context, messages []llm.Message, tools []llm.ToolDefinition) (llm.Message, error) {
+	m.messages = messages
+	if m.toolErr != nil {
+		return llm.Message{}, m.toolErr
+	}
+	return llm.Message{Role: "assistant", ToolCalls: m.toolCalls}, nil
+}
+
+func (m *mockToolLLM) SupportsTools(ctx context.Context) bool { return m.supports }
+
+func TestNativeToolRouting(t *testing.T) {
+	client := &mockToolLLM{
+		supports: true,
+		toolCalls: []llm.ToolCall{{Function: llm.ToolCallFunction{
+			Name:      "note",
+			Arguments: map[string]interface{}{"arg": "купить молоко"},
+		}}},
+	}
+	obs := &mockObsidian{}
+	o := &Orchestrator{
+		Recorder:    &mockRecorder{},
+		STT:         &mockSTT{transcription: "запиши купить молоко"},
+		Notifier:    &mockNotifier{},
+		LLM:         client,
+		Obsidian:    obs,
+		Timer:       &mockTimer{},
+		Clock:       &mockClock{},
+		Memory:      NewContextMemory(5),
+		NativeTools: true,
+	}
+
+	o.handleCommand(context.Background(), make(chan []int16, 1))
+
+	if obs.content != "купить молоко" {
+		t.Errorf("expected note from tool call, got %q", obs.content)
+	}
+	if client.chatCalls != 0 {
+		t.Error("prompt routing should not be used when tool call succeeds")
+	}
+}
+
+func TestNativeToolFallback(t *testing.T) {
+	obs := &mockObsidian{}
+	newOrchestrator := func(client LLMClient, native bool) *Orchestrator {
+		return &Orchestrator{
+			Recorder:    &mockRecorder{},
+			STT:         &mockSTT{transcription: "запиши тест"},
+			Notifier:    &mockNotifier{},
+			LLM:         client,
+			Obsidian:    obs,
+			Timer:       &mockTimer{},
+			Clock:       &mockClock{},
+			Memory:      NewContextMemory(5),
+			NativeTools: native,
+		}
+	}
+
+	tests := []struct {
+		name   string
+		client *mockToolLLM
+		native bool
+	}{
+		{"unsupported model", &mockToolLLM{mockLLM: mockLLM{response: "ACTION: NOTE | ARG: тест"}}, true},
+		{"tool call error", &mockToolLLM{mockLLM: mockLLM{response: "ACTION: NOTE | ARG: тест"}, supports: true, toolErr: context.DeadlineExceeded}, true},
+		{"disabled in config", &mockToolLLM{mockLLM: mockLLM{response: "ACTION: NOTE | ARG: тест"}, supports: true}, false},
+	}
+	for _, tt := range tests {
+		obs.content = ""
+		newOrchestrator(tt.client, tt.native).handleCommand(context.Background(), make(chan []int16, 1))
+		if tt.client.chatCalls != 1 || obs.content != "тест" {
+			t.Errorf("%s: expected prompt routing fallback, got %d chat calls and note %q", tt.name, tt.client.chatCalls, obs.content)
+		}
+	}
+}
+
+func TestNativeHistoryAsToolCalls(t *testing.T) {
+	memory := NewContextMemory(5)
+	memory.AddEntry(ContextEntry{Command: "таймер на минуту", Reply: "ACTION: TIMER | ARG: 60", Action: "Set timer for 60 seconds"})
+	o := &Orchestrator{Memory: memory}
+
+	msgs := o.buildMessages("сколько времени", true)
+	if len(msgs) != 5 {
+		t.Fatalf("expected 5 messages, got %d", len(msgs))
+	}
+	call := msgs[2].ToolCalls
+	if msgs[2].Role != "assistant" || len(call) != 1 || call[0].Function.Name != "TIMER" || call[0].Function.Arguments["arg"] != "60" {
+		t.Errorf("expected replayed TIMER tool call, got %+v", msgs[2])
+	}
+	if msgs[3].Role != "tool" || msgs[3].Content != "Set timer for 60 seconds" {
+		t.Errorf("expected tool result message, got %+v", msgs[3])
+	}
+}
+
+func TestToolArg(t *testing.T) {
+	if got := toolArg(map[string]interface{}{"arg": 300.0}); got != "300" {
+		t.Errorf("expected 300, got %q", got)
+	}
+	if got := toolArg(map[string]interface{}{}); got != "" {
+		t.Errorf("expected empty arg, got %q", got)
+	}
+}
+
+func TestLLMErrorKinds(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{fmt.Errorf("%w: context deadline exceeded", llm.ErrTimeout), "Модель не ответила вовремя"},
+		{fmt.Errorf("%w: 404", llm.ErrModelNotFound), "Модель не найдена"},
+		{fmt.Errorf("%w: connection refused", llm.ErrServerDown), "Языковая модель недоступна"},
+		{llm.ErrCircuitOpen, "Языковая модель недоступна"},
+		{fmt.Errorf("bad request"), "LLM failed"},
+	}
+
+	for _, tt := range tests {
+		notif := &mockNotifier{}
+		o := &Orchestrator{
+			Recorder: &mockRecorder{},
+			STT:      &mockSTT{transcription: "запиши тест"},
+			Notifier: notif,
+			LLM:      &mockLLM{err: tt.err},
+			Memory:   NewContextMemory(5),
+		}
+		o.handleCommand(context.Background(), make(chan []int16, 1))
+		if notif.message != tt.want {
+			t.Errorf("%v: expected %q, got %q", tt.err, tt.want, notif.message)
+		}
+	}
+}
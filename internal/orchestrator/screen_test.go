@@ -0,0 +1,92 @@
+package orchestrator
+
+import (
+	"context"
+	"hey-bobik/internal/tools/obsidian"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mockScreen captures a fixed PNG file.
+type mockScreen struct {
+	path    string
+	cleaned bool
+}
+
+func (m *mockScreen) Capture() (string, string, error)       { return "UE5H", m.path, nil }
+func (m *mockScreen) CaptureWindow() (string, string, error) { return "UE5H", m.path, nil }
+func (m *mockScreen) Cleanup(filePath string) error {
+	m.cleaned = true
+	return os.Remove(filePath)
+}
+
+type mockVision struct {
+	response string
+	prompt   string
+}
+
+func (m *mockVision) GenerateWithImages(ctx context.Context, system, prompt string, images []string) (string, error) {
+	m.prompt = prompt
+	return m.response, nil
+}
+
+func TestScreenToNote(t *testing.T) {
+	shot := filepath.Join(t.TempDir(), "screenshot_1.png")
+	os.WriteFile(shot, []byte("\x89PNG"), 0644)
+	screen := &mockScreen{path: shot}
+	vision := &mockVision{response: "Терминал со сборкой.\ngo test ./... ok"}
+
+	vault := t.TempDir()
+	notes, _ := obsidian.NewWithOptions(vault, "", obsidian.Options{AttachmentFolder: "Attachments"})
+	notes.Now = func() time.Time { return time.Date(2026, 10, 18, 9, 30, 0, 0, time.Local) }
+	tts := &mockTTS{}
+	o := &Orchestrator{Notifier: &mockNotifier{}, Obsidian: notes, Screen: screen, VisionLLM: vision, TTS: tts, Now: notes.Now}
+
+	got := o.handleScreenAction(context.Background(), "note")
+	if !strings.HasPrefix(got, "Saved screen to note (Attachments/Screenshot 20261018093000.png)") {
+		t.Fatalf("unexpected result %q", got)
+	}
+	if vision.prompt != screenNotePrompt {
+		t.Errorf("expected the note prompt, got %q", vision.prompt)
+	}
+	if !screen.cleaned {
+		t.Error("the temporary screenshot should still be cleaned up")
+	}
+
+	saved := filepath.Join(vault, "Attachments", "Screenshot 20261018093000.png")
+	if _, err := os.Stat(saved); err != nil {
+		t.Fatalf("expected the screenshot in the attachments: %v", err)
+	}
+	note, _ := os.ReadFile(filepath.Join(vault, "2026-10-18.md"))
+	embed := "![[Attachments/Screenshot 20261018093000.png]]\nТерминал со сборкой.\ngo test ./... ok\n"
+	if !strings.Contains(string(note), "## 09:30:00\n"+embed) {
+		t.Errorf("expected the embed and the analysis in the note, got %q", note)
+	}
+	if len(tts.spoken) != 2 || tts.spoken[1] != "Сохранил экран в заметку" {
+		t.Errorf("the analysis should not be read aloud, got %q", tts.spoken)
+	}
+
+	// Undo takes the entry out of the note and deletes the attachment
+	o.handleCancelAction(context.Background(), "note")
+	if note, _ := os.ReadFile(filepath.Join(vault, "2026-10-18.md")); strings.Contains(string(note), "![[") {
+		t.Errorf("the entry should be removed, got %q", note)
+	}
+	if _, err := os.Stat(saved); !os.IsNotExist(err) {
+		t.Errorf("the attachment should be deleted, got %v", err)
+	}
+}
+
+func TestScreenToNoteUnsupported(t *testing.T) {
+	n := &mockNotifier{}
+	screen := &mockScreen{}
+	o := &Orchestrator{Notifier: n, Obsidian: &mockObsidian{}, Screen: screen, VisionLLM: &mockVision{}}
+	if got := o.handleScreenAction(context.Background(), "note"); got != "" || n.message != "Сохранение скриншотов в заметки недоступно" {
+		t.Errorf("unexpected result %q: %q", got, n.message)
+	}
+	if screen.cleaned {
+		t.Error("no screenshot should be taken")
+	}
+}
@@ -0,0 +1,121 @@
+package orchestrator
+
+import (
+	"context"
+	"hey-bobik/internal/llm"
+	"strings"
+	"unicode/utf8"
+)
+
+// stopAfterActionLine returns a stream callback that ends the reply once a
+// complete "ACTION: ... | ARG: ..." line has arrived.
+func stopAfterActionLine() func(string) error {
+	var buf strings.Builder
+	return func(chunk string) error {
+		buf.WriteString(chunk)
+		if _, ok := actionLine(buf.String(), false); ok {
+			return llm.ErrStopStream
+		}
+		return nil
+	}
+}
+
+// actionLine returns the first finished line of text with an action. A line
+// is finished by a newline, or at the end of the reply (eof) once it has both
+// an action and an argument, as a reply of a single line usually does.
+func actionLine(text string, eof bool) (string, bool) {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if !strings.Contains(line, "ACTION:") {
+			continue
+		}
+		if i < len(lines)-1 || (eof && strings.Contains(line, "ARG:")) {
+			return strings.TrimSpace(line), true
+		}
+	}
+	return "", false
+}
+
+// sentenceSpeaker speaks a streamed answer sentence by sentence as it arrives.
+type sentenceSpeaker struct {
+	o      *Orchestrator
+	ctx    context.Context
+	buf    string
+	spoken int // runes queued for speech so far
+	done   bool
+}
+
+func (o *Orchestrator) newSentenceSpeaker(ctx context.Context) *sentenceSpeaker {
+	return &sentenceSpeaker{o: o, ctx: ctx}
+}
+
+// Write takes the next chunk and speaks every sentence completed by it.
+// It never stops the stream: the full answer is still needed for display.
+func (s *sentenceSpeaker) Write(chunk string) error {
+	s.buf += chunk
+	if end := lastSentenceEnd(s.buf); end > 0 {
+		s.say(s.buf[:end])
+		s.buf = s.buf[end:]
+	}
+	return nil
+}
+
+// Flush speaks whatever is left once the stream has ended.
+func (s *sentenceSpeaker) Flush() {
+	s.say(s.buf)
+	s.buf = ""
+}
+
+// Spoke reports whether any part of the answer was sent to TTS.
+func (s *sentenceSpeaker) Spoke() bool {
+	return s.spoken > 0
+}
+
+func (s *sentenceSpeaker) say(text string) {
+	text = strings.TrimSpace(text)
+	if text == "" || s.done {
+		return
+	}
+
+	if limit := s.o.SpeechLimit; limit > 0 {
+		left := limit - s.spoken
+		if utf8.RuneCountInString(text) > left {
+			// Over budget: finish on a whole sentence if one fits and stay quiet afterwards
+			s.done = true
+			if end := lastSentenceEnd(truncateRunes(text, left) + " "); end > 0 {
+				text = strings.TrimSpace(text[:end])
+			} else {
+				return
+			}
+		}
+	}
+
+	s.spoken += utf8.RuneCountInString(text)
+	s.o.speak(s.ctx, text)
+}
+
+// lastSentenceEnd returns the byte offset just past the last sentence
+// terminator that is followed by whitespace, or 0 if there is none yet.
+func lastSentenceEnd(text string) int {
+	end := 0
+	prev := rune(0)
+	for i, r := range text {
+		if (r == ' ' || r == '\n') && strings.ContainsRune(".!?…", prev) {
+			end = i
+		}
+		prev = r
+	}
+	return end
+}
+
+// truncateRunes cuts text to at most maxRunes runes.
+func truncateRunes(text string, maxRunes int) string {
+	if maxRunes <= 0 {
+		return ""
+	}
+	runes := []rune(text)
+	if len(runes) <= maxRunes {
+		return text
+	}
+	return string(runes[:maxRunes])
+}
@@ -0,0 +1,251 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"hey-bobik/internal/llm"
+	"reflect"
+	"testing"
+)
+
+type mockTTS struct {
+	spoken []string
+}
+
+func (m *mockTTS) SpeakAsync(ctx context.Context, text string) {
+	m.spoken = append(m.spoken, text)
+}
+
+// mockStreamLLM streams its chunks and records how many were delivered.
+type mockStreamLLM struct {
+	mockLLM
+	chunks    []string
+	delivered int
+}
+
+func (m *mockStreamLLM) ChatStream(ctx context.Context, messages []llm.Message, onChunk func(string) error) (string, error) {
+	m.messages = messages
+	var full string
+	for _, c := range m.chunks {
+		full += c
+		m.delivered++
+		if err := onChunk(c); err != nil {
+			if errors.Is(err, llm.ErrStopStream) {
+				return full, nil
+			}
+			return full, err
+		}
+	}
+	return full, nil
+}
+
+func TestStreamingRouteStopsAfterActionLine(t *testing.T) {
+	client := &mockStreamLLM{chunks: []string{
+		"ACTION: TI", "MER | ARG: 300", "\n", "Пояснение, которое не нужно", " ждать.",
+	}}
+	o := &Orchestrator{LLM: client, Memory: NewContextMemory(5)}
+
+	intent, err := o.route(context.Background(), "таймер на 5 минут")
+	if err != nil {
+		t.Fatalf("route failed: %v", err)
+	}
+	if intent.Action != "TIMER" || intent.Arg != "300" {
+		t.Errorf("unexpected intent: %+v", intent)
+	}
+	if client.delivered != 3 {
+		t.Errorf("expected stream to stop after 3 chunks, got %d", client.delivered)
+	}
+}
+
+func TestStreamingRouteWithoutNewline(t *testing.T) {
+	client := &mockStreamLLM{chunks: []string{"ACTION: TIME", " | ARG: none"}}
+	o := &Orchestrator{LLM: client, Memory: NewContextMemory(5)}
+
+	intent, err := o.route(context.Background(), "сколько времени")
+	if err != nil {
+		t.Fatalf("route failed: %v", err)
+	}
+	if intent.Action != "TIME" || intent.Arg != "none" {
+		t.Errorf("unexpected intent: %+v", intent)
+	}
+}
+
+func TestStreamingRouteLastLine(t *testing.T) {
+	client := &mockStreamLLM{chunks: []string{"Конечно!\n", "ACTION: TIMER", " | ARG: 300"}}
+	o := &Orchestrator{LLM: client, Memory: NewContextMemory(5)}
+
+	intent, err := o.route(context.Background(), "таймер на 5 минут")
+	if err != nil {
+		t.Fatalf("route failed: %v", err)
+	}
+	if intent.Action != "TIMER" || intent.Arg != "300" {
+		t.Errorf("the action line without a newline should be found at the end, got %+v", intent)
+	}
+}
+
+func TestActionLine(t *testing.T) {
+	tests := []struct {
+		text string
+		eof  bool
+		want string
+	}{
+		{"ACTION: TIMER | ARG: 30", false, ""},
+		{"ACTION: TIMER | ARG: 300\n", false, "ACTION: TIMER | ARG: 300"},
+		{"ACTION: TIMER | ARG: 300", true, "ACTION: TIMER | ARG: 300"},
+		{"Думаю...\nACTION: TIME | ARG: none", true, "ACTION: TIME | ARG: none"},
+		{"ACTION: TI", true, ""},
+		{"Не понял", true, ""},
+	}
+	for _, tt := range tests {
+		if got, _ := actionLine(tt.text, tt.eof); got != tt.want {
+			t.Errorf("actionLine(%q, %v) = %q, want %q", tt.text, tt.eof, got, tt.want)
+		}
+	}
+}
+
+func TestSentenceSpeaker(t *testing.T) {
+	tts := &mockTTS{}
+	o := &Orchestrator{TTS: tts}
+	s := o.newSentenceSpeaker(context.Background())
+
+	for _, c := range []string{"На экране ред", "актор кода. Версия 1.2", "5 открыта"} {
+		s.Write(c)
+	}
+	if want := []string{"На экране редактор кода."}; !reflect.DeepEqual(tts.spoken, want) {
+		t.Errorf("before the version sentence ends expected %q, got %q", want, tts.spoken)
+	}
+
+	s.Write(". Курсор в конце")
+	s.Flush()
+	want := []string{"На экране редактор кода.", "Версия 1.25 открыта.", "Курсор в конце"}
+	if !reflect.DeepEqual(tts.spoken, want) {
+		t.Errorf("expected %q, got %q", want, tts.spoken)
+	}
+	if !s.Spoke() {
+		t.Error("Spoke should report true")
+	}
+}
+
+func TestSentenceSpeakerLimit(t *testing.T) {
+	tts := &mockTTS{}
+	o := &Orchestrator{TTS: tts, SpeechLimit: 30}
+	s := o.newSentenceSpeaker(context.Background())
+
+	s.Write("Первое предложение. ")
+	s.Write("Второе. Третье предложение, уже лишнее. ")
+	s.Write("Четвёртое. ")
+	s.Flush()
+
+	want := []string{"Первое предложение.", "Второе."}
+	if !reflect.DeepEqual(tts.spoken, want) {
+		t.Errorf("expected %q, got %q", want, tts.spoken)
+	}
+}
+
+func TestAnswerActionStreams(t *testing.T) {
+	tts := &mockTTS{}
+	notif := &mockNotifier{}
+	client := &mockStreamLLM{chunks: []string{"Из-за рассеяния ", "света. Синий ", "рассеивается сильнее."}}
+	o := &Orchestrator{LLM: client, TTS: tts, Notifier: notif, Memory: NewContextMemory(5)}
+
+	result := o.handleAnswerAction(context.Background(), "почему небо голубое")
+
+	if result == "" {
+		t.Fatal("expected a result description")
+	}
+	want := []string{"Из-за рассеяния света.", "Синий рассеивается сильнее."}
+	if !reflect.DeepEqual(tts.spoken, want) {
+		t.Errorf("expected %q, got %q", want, tts.spoken)
+	}
+	if notif.title != "Ответ" {
+		t.Errorf("expected answer notification, got %s: %s", notif.title, notif.message)
+	}
+	if client.messages[1].Content != "почему небо голубое" {
+		t.Errorf("question not sent to the LLM: %+v", client.messages)
+	}
+}
+
+func TestAnswerUsesChatLLM(t *testing.T) {
+	router := &mockLLM{response: "ACTION: TIME | ARG: none"}
+	chat := &mockLLM{response: "Потому что."}
+	o := &Orchestrator{LLM: router, ChatLLM: chat, TTS: &mockTTS{}, Notifier: &mockNotifier{}, Memory: NewContextMemory(5)}
+
+	o.handleAnswerAction(context.Background(), "почему")
+	if router.messages != nil {
+		t.Error("the router model should not answer general questions when a chat model is set")
+	}
+	if len(chat.messages) != 2 {
+		t.Errorf("question not sent to the chat model: %+v", chat.messages)
+	}
+}
+
+func TestEventStream(t *testing.T) {
+	var events []Event
+	o := &Orchestrator{
+		Recorder: &mockRecorder{},
+		STT:      &mockSTT{transcription: "запиши тест"},
+		Notifier: &mockNotifier{},
+		LLM:      &mockLLM{response: "ACTION: NOTE | ARG: тест"},
+		Obsidian: &mockObsidian{},
+		Memory:   NewContextMemory(5),
+		OnEvent:  func(e Event) { events = append(events, e) },
+	}
+	o.handleCommand(context.Background(), make(chan []int16, 1))
+	o.Emit(EventThinking, "рассуждение")
+
+	var types []EventType
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	want := []EventType{EventCommand, EventIntent, EventResult, EventThinking}
+	if !reflect.DeepEqual(types, want) {
+		t.Fatalf("expected events %v, got %v", want, types)
+	}
+	if events[1].Text != "ACTION: NOTE | ARG: тест" || events[0].Time.IsZero() {
+		t.Errorf("unexpected events: %+v", events)
+	}
+}
+
+type mockUsage struct {
+	action string
+	calls  map[string][]llm.Usage
+}
+
+func (m *mockUsage) Record(action string, calls map[string][]llm.Usage) {
+	m.action, m.calls = action, calls
+}
+
+// usageLLM reports usage through the orchestrator like a real client hook.
+type usageLLM struct {
+	mockLLM
+	o *Orchestrator
+}
+
+func (m *usageLLM) Chat(ctx context.Context, messages []llm.Message) (string, error) {
+	m.o.TrackUsage("qwen3:8b", llm.Usage{EvalCount: 7})
+	return m.mockLLM.Chat(ctx, messages)
+}
+
+func TestUsagePerAction(t *testing.T) {
+	usage := &mockUsage{}
+	client := &usageLLM{mockLLM: mockLLM{response: "ACTION: TIME | ARG: none"}}
+	o := &Orchestrator{
+		Recorder: &mockRecorder{},
+		STT:      &mockSTT{transcription: "сколько времени"},
+		Notifier: &mockNotifier{},
+		LLM:      client,
+		Clock:    &mockClock{},
+		Memory:   NewContextMemory(5),
+		Usage:    usage,
+	}
+	client.o = o
+
+	o.handleCommand(context.Background(), make(chan []int16, 1))
+
+	if usage.action != "TIME" || len(usage.calls["qwen3:8b"]) != 1 || usage.calls["qwen3:8b"][0].EvalCount != 7 {
+		t.Errorf("usage not attributed to the action: %s %+v", usage.action, usage.calls)
+	}
+	if o.pendingUsage != nil {
+		t.Error("pending usage should be flushed after the command")
+	}
+}
@@ -0,0 +1,50 @@
+package orchestrator
+
+import (
+	"context"
+	"hey-bobik/internal/tools/tasks"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTaskAction(t *testing.T) {
+	vault := t.TempDir()
+	service := tasks.New(vault, "")
+	service.Now = func() time.Time { return time.Date(2026, 10, 18, 10, 0, 0, 0, time.Local) }
+	n := &mockNotifier{}
+	o := &Orchestrator{Notifier: n, Tasks: service, Memory: NewContextMemory(5)}
+	ctx := context.Background()
+	read := func() string {
+		data, _ := os.ReadFile(filepath.Join(vault, tasks.DefaultFile))
+		return string(data)
+	}
+
+	if got := o.handleTaskAction(ctx, "купить молоко сегодня"); got != "Added task: купить молоко 📅 2026-10-18" {
+		t.Errorf("unexpected result %q", got)
+	}
+	o.handleTaskAction(ctx, "позвонить маме завтра")
+
+	if got := o.handleTaskAction(ctx, "list"); got != "Listed 1 tasks" || n.message != "• купить молоко" {
+		t.Errorf("unexpected list %q: %q", got, n.message)
+	}
+
+	if got := o.handleTaskAction(ctx, "done:молоко"); got != "Completed task: купить молоко" {
+		t.Errorf("unexpected result %q", got)
+	}
+	if want := "- [x] купить молоко 📅 2026-10-18 ✅ 2026-10-18\n- [ ] позвонить маме 📅 2026-10-19\n"; read() != want {
+		t.Errorf("expected %q, got %q", want, read())
+	}
+	if got := o.handleTaskAction(ctx, "done:помыть машину"); got != "" || n.message != "Не нашёл задачу: помыть машину" {
+		t.Errorf("unexpected result %q: %q", got, n.message)
+	}
+
+	// Undo reopens the task, then removes the one added last
+	if got := o.handleCancelAction(ctx, "2"); got != "Отменено: выполнение задачи, задача" {
+		t.Errorf("unexpected result %q", got)
+	}
+	if want := "- [ ] купить молоко 📅 2026-10-18\n"; read() != want {
+		t.Errorf("expected %q, got %q", want, read())
+	}
+}
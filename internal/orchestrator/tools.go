@@ -0,0 +1,330 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"hey-bobik/internal/llm"
+	"strconv"
+	"strings"
+)
+
+// Example is a sample input and the expected router answer.
+type Example struct {
+	Input  string
+	Output string
+}
+
+// Tool describes an action the router can choose and how to execute it.
+type Tool struct {
+	Name        string
+	Description string
+	Rules       []string
+	Examples    []Example
+	// Handle executes the action and returns a short description of the
+	// result for the history, or "" if it failed or nothing was done, which
+	// is recorded as an error.
+	Handle func(o *Orchestrator, ctx context.Context, arg string) string
+	// Risk rates the action with its argument and phrases the confirmation
+	// question for risky ones. Nil means the tool is always safe.
+	Risk func(o *Orchestrator, arg string) (Risk, string)
+	// Available reports whether the tool works in this setup, e.g. once its
+	// service is configured. Unavailable tools are left out of the prompt.
+	// Nil means the tool is always available.
+	Available func(o *Orchestrator) bool
+	// Adapt trims the rules and examples of an available tool to this
+	// setup, e.g. drops a mode its service lacks. Nil keeps them as is.
+	Adapt func(o *Orchestrator, t Tool) Tool
+}
+
+// Intent is the action chosen by the router together with its argument.
+type Intent struct {
+	Action string
+	Arg    string
+}
+
+// String formats the intent the way the router is asked to answer.
+func (i Intent) String() string {
+	return fmt.Sprintf("ACTION: %s | ARG: %s", i.Action, i.Arg)
+}
+
+// defaultTools returns the built-in tool registry in prompt order.
+func defaultTools() []Tool {
+	return []Tool{
+		{
+			Name:        "NOTE",
+			Description: "Записать или обновить заметку в Obsidian, в ежедневную или в названную заметку.",
+			Rules: []string{
+				`Если просят "записать" или "заметка" -> ACTION: NOTE | ARG: [Текст заметки]`,
+				`Если просят "исправить" или "изменить" последнюю запись -> ACTION: NOTE | ARG: UPDATE: [Новый текст]`,
+				`Если просят записать в конкретную заметку или проект -> ACTION: NOTE | ARG: TO:[Название заметки]: [Текст]`,
+			},
+			Examples: []Example{
+				{"запиши купить хлеб", "ACTION: NOTE | ARG: Купить хлеб"},
+				{"запиши в проект Альфа согласовать бюджет", "ACTION: NOTE | ARG: TO:Альфа: Согласовать бюджет"},
+			},
+			Handle: (*Orchestrator).handleNoteAction,
+			Risk:   (*Orchestrator).noteRisk,
+			Adapt:  (*Orchestrator).adaptNoteTool,
+		},
+		{
+			Name:        "READ",
+			Description: "Прочитать записи из ежедневных заметок за день или период.",
+			Rules: []string{
+				`Если спрашивают "что я записал сегодня/вчера" или просят прочитать заметки -> ACTION: READ | ARG: [today, yesterday, week или дата]`,
+			},
+			Examples: []Example{
+				{"что я записал сегодня", "ACTION: READ | ARG: today"},
+				{"прочитай вчерашние заметки", "ACTION: READ | ARG: yesterday"},
+			},
+			Handle: (*Orchestrator).handleReadAction,
+		},
+		{
+			Name:        "SEARCH",
+			Description: "Найти записи по словам во всех заметках или за период.",
+			Rules: []string{
+				`Если спрашивают, что записывали про что-то, или просят найти в заметках -> ACTION: SEARCH | ARG: [запрос]`,
+				`Если при этом назван период -> ACTION: SEARCH | ARG: [today, yesterday, week, month или дата]: [запрос]`,
+			},
+			Examples: []Example{
+				{"что я записывал про релиз", "ACTION: SEARCH | ARG: релиз"},
+				{"найди в заметках за неделю созвон", "ACTION: SEARCH | ARG: week: созвон"},
+			},
+			Handle: (*Orchestrator).handleSearchAction,
+		},
+		{
+			Name:        "ASK_NOTES",
+			Description: "Ответить на вопрос по содержимому заметок пользователя.",
+			Rules: []string{
+				`Если спрашивают о событиях и фактах из своих заметок ("когда у нас был", "что мы решили про") -> ACTION: ASK_NOTES | ARG: [вопрос]`,
+			},
+			Examples: []Example{
+				{"когда у нас был созвон с подрядчиком", "ACTION: ASK_NOTES | ARG: когда у нас был созвон с подрядчиком"},
+			},
+			Handle:    (*Orchestrator).handleAskNotesAction,
+			Available: func(o *Orchestrator) bool { return o.Index != nil },
+		},
+		{
+			Name:        "TASK",
+			Description: "Задачи с чекбоксами в Obsidian: добавить (можно со сроком), прочитать задачи на сегодня (list), отметить выполненной (done).",
+			Rules: []string{
+				`Если просят "напомни" что-то сделать без "через", "добавь задачу", "надо не забыть" -> ACTION: TASK | ARG: [Текст задачи со сроком, как сказано]`,
+				`Если спрашивают "какие задачи на сегодня" или "что мне сделать сегодня" -> ACTION: TASK | ARG: list`,
+				`Если говорят, что задача сделана, или просят отметить её выполненной -> ACTION: TASK | ARG: done:[текст задачи]`,
+			},
+			Examples: []Example{
+				{"напомни купить молоко завтра", "ACTION: TASK | ARG: Купить молоко завтра"},
+				{"какие задачи на сегодня", "ACTION: TASK | ARG: list"},
+				{"отметь купить молоко выполненной", "ACTION: TASK | ARG: done:купить молоко"},
+			},
+			Handle:    (*Orchestrator).handleTaskAction,
+			Available: func(o *Orchestrator) bool { return o.Tasks != nil },
+		},
+		{
+			Name:        "TIMER",
+			Description: "Поставить таймер (нужно указать длительность в секундах).",
+			Rules: []string{
+				`Если просят "таймер" или "напомни через" -> ACTION: TIMER | ARG: [Кол-во секунд]`,
+			},
+			Examples: []Example{
+				{"поставь таймер на 5 минут", "ACTION: TIMER | ARG: 300"},
+			},
+			Handle: (*Orchestrator).handleTimerAction,
+		},
+		{
+			Name:        "TIME",
+			Description: "Сообщить текущее время.",
+			Rules: []string{
+				`Если спрашивают "сколько времени" или "час" -> ACTION: TIME | ARG: none`,
+			},
+			Examples: []Example{
+				{"сколько времени", "ACTION: TIME | ARG: none"},
+			},
+			Handle: (*Orchestrator).handleTimeAction,
+		},
+		{
+			Name:        "CANCEL",
+			Description: "Отменить последние действия любого типа: заметку, её исправление, задачу, таймер, запись в буфер.",
+			Rules: []string{
+				`Если просят "отмени" или "отмена" -> ACTION: CANCEL | ARG: last`,
+				`Если просят отменить несколько последних действий -> ACTION: CANCEL | ARG: [число]`,
+				`Если просят отменить или удалить именно заметку -> ACTION: CANCEL | ARG: note`,
+				`Если просят остановить все таймеры -> ACTION: CANCEL | ARG: timer`,
+			},
+			Examples: []Example{
+				{"отмени", "ACTION: CANCEL | ARG: last"},
+				{"отмени два последних", "ACTION: CANCEL | ARG: 2"},
+				{"отмени последнюю заметку", "ACTION: CANCEL | ARG: note"},
+			},
+			Handle: (*Orchestrator).handleCancelAction,
+			Risk:   (*Orchestrator).cancelRisk,
+		},
+		{
+			Name:        "CLIPBOARD",
+			Description: "Работа с буфером обмена (read - прочитать, write - записать, note - записать буфер в заметку).",
+			Rules: []string{
+				`Если просят "скопировать" текст -> ACTION: CLIPBOARD | ARG: write:[текст]`,
+				`Если просят "что в буфере" или "прочитай буфер" -> ACTION: CLIPBOARD | ARG: read`,
+				`Если просят "вставь из буфера в заметку" -> ACTION: CLIPBOARD | ARG: note`,
+			},
+			Examples: []Example{
+				{"скопируй привет мир", "ACTION: CLIPBOARD | ARG: write:привет мир"},
+			},
+			Handle: (*Orchestrator).handleClipboardAction,
+			Risk:   (*Orchestrator).clipboardRisk,
+		},
+		{
+			Name:        "CALC",
+			Description: "Вычислить математическое выражение.",
+			Rules: []string{
+				`Если просят "посчитать", "сколько будет", "калькулятор" -> ACTION: CALC | ARG: [выражение или процент:значение]`,
+			},
+			Examples: []Example{
+				{"посчитай 2 плюс 2", "ACTION: CALC | ARG: 2+2"},
+				{"сколько будет 15 процентов от 2500", "ACTION: CALC | ARG: 15%:2500"},
+				{"посчитай 100 умножить на 5", "ACTION: CALC | ARG: 100*5"},
+			},
+			Handle: (*Orchestrator).handleCalcAction,
+		},
+		{
+			Name:        "SCREEN",
+			Description: "Анализ экрана/скриншота (describe - описать что на экране, read - прочитать текст, window - анализ активного окна, note - сохранить скриншот с описанием в заметку).",
+			Rules: []string{
+				`Если просят "что на экране", "опиши экран", "прочитай с экрана" -> ACTION: SCREEN | ARG: describe`,
+				`Если просят "что в этом окне", "прочитай окно" -> ACTION: SCREEN | ARG: window`,
+				`Если просят прочитать текст с экрана -> ACTION: SCREEN | ARG: read`,
+				`Если просят "сохрани экран в заметку", "запиши скриншот" -> ACTION: SCREEN | ARG: note`,
+			},
+			Examples: []Example{
+				{"что на экране", "ACTION: SCREEN | ARG: describe"},
+				{"прочитай что написано на экране", "ACTION: SCREEN | ARG: read"},
+				{"сохрани экран в заметку", "ACTION: SCREEN | ARG: note"},
+			},
+			Handle: (*Orchestrator).handleScreenAction,
+		},
+		{
+			Name:        "ANSWER",
+			Description: "Ответить на общий вопрос, если не подходит ни одно другое действие.",
+			Rules: []string{
+				`Если задают общий вопрос ("что такое", "почему", "как") -> ACTION: ANSWER | ARG: [вопрос]`,
+			},
+			Examples: []Example{
+				{"почему небо голубое", "ACTION: ANSWER | ARG: почему небо голубое"},
+			},
+			Handle: (*Orchestrator).handleAnswerAction,
+		},
+	}
+}
+
+// registry returns the configured tools, falling back to the built-in ones.
+func (o *Orchestrator) registry() []Tool {
+	if o.Tools != nil {
+		return o.Tools
+	}
+	return defaultTools()
+}
+
+// tools returns the tools of the registry available in this setup, the ones
+// the router is offered.
+func (o *Orchestrator) tools() []Tool {
+	var tools []Tool
+	for _, t := range o.registry() {
+		if t.Available != nil && !t.Available(o) {
+			continue
+		}
+		if t.Adapt != nil {
+			t = t.Adapt(o, t)
+		}
+		tools = append(tools, t)
+	}
+	return tools
+}
+
+// findTool looks up a tool by its action name. Unavailable tools are found
+// too, as a router may still answer with one it saw in the history.
+func (o *Orchestrator) findTool(name string) (Tool, bool) {
+	for _, t := range o.registry() {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Tool{}, false
+}
+
+// toolArgName is the single parameter every tool takes in native tool calls.
+const toolArgName = "arg"
+
+// toolDefinitions converts the registry to native tool definitions.
+func toolDefinitions(tools []Tool) []llm.ToolDefinition {
+	defs := make([]llm.ToolDefinition, 0, len(tools))
+	for _, t := range tools {
+		desc := t.Description
+		if len(t.Rules) > 0 {
+			desc += "\nПравила:\n- " + strings.Join(t.Rules, "\n- ")
+		}
+		defs = append(defs, llm.ToolDefinition{
+			Type: "function",
+			Function: llm.FunctionDefinition{
+				Name:        t.Name,
+				Description: desc,
+				Parameters: llm.ToolParameters{
+					Type: "object",
+					Properties: map[string]llm.ToolProperty{
+						toolArgName: {Type: "string", Description: "Значение ARG из правил, или none"},
+					},
+					Required: []string{toolArgName},
+				},
+			},
+		})
+	}
+	return defs
+}
+
+// toolArg extracts the argument of a native tool call as a string.
+func toolArg(args map[string]interface{}) string {
+	switch v := args[toolArgName].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		// JSON numbers, e.g. TIMER seconds
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// buildSystemPrompt renders the router instructions from the tool registry.
+// With native tools the model answers with a function call instead of a text line.
+func buildSystemPrompt(tools []Tool, native bool) string {
+	var b strings.Builder
+	b.WriteString("Ты — Бобик, интеллектуальный помощник для Linux.\n")
+	b.WriteString("Твоя задача: проанализировать ввод пользователя и выбрать одно действие.\n\n")
+
+	b.WriteString("Доступные действия:\n")
+	for i, t := range tools {
+		fmt.Fprintf(&b, "%d. %s: %s\n", i+1, t.Name, t.Description)
+	}
+
+	if native {
+		b.WriteString("\nВсегда отвечай вызовом ровно одной функции: ACTION — имя функции, ARG — её аргумент arg.\n\n")
+	} else {
+		b.WriteString("\nФормат ответа: ACTION: [ACTION_NAME] | ARG: [VALUE]\n\n")
+	}
+
+	b.WriteString("Правила:\n")
+	for _, t := range tools {
+		for _, r := range t.Rules {
+			fmt.Fprintf(&b, "- %s\n", r)
+		}
+	}
+
+	b.WriteString("\nПримеры:")
+	for _, t := range tools {
+		for _, e := range t.Examples {
+			fmt.Fprintf(&b, "\nВвод: %q\nОтвет: %s\n", e.Input, e.Output)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,125 @@
+package orchestrator
+
+import (
+	"strconv"
+	"strings"
+)
+
+// maxUndo bounds how many actions can be undone.
+const maxUndo = 20
+
+// Kinds of undo steps, so "отмени заметку" can skip other actions.
+const (
+	undoNote      = "note"
+	undoTimer     = "timer"
+	undoClipboard = "clipboard"
+	undoTask      = "task"
+)
+
+// undoStep reverts one executed action.
+type undoStep struct {
+	kind   string
+	desc   string // what is undone, e.g. "заметка"
+	detail string // text affected by the undo, quoted in confirmations
+	undo   func() error
+}
+
+// pushUndo records the inverse of an action that was just executed.
+func (o *Orchestrator) pushUndo(kind, desc, detail string, undo func() error) {
+	o.undoMu.Lock()
+	defer o.undoMu.Unlock()
+	o.undoStack = append(o.undoStack, undoStep{kind: kind, desc: desc, detail: detail, undo: undo})
+	if len(o.undoStack) > maxUndo {
+		o.undoStack = o.undoStack[len(o.undoStack)-maxUndo:]
+	}
+}
+
+// popUndo removes up to n latest steps, newest first.
+func (o *Orchestrator) popUndo(n int) []undoStep {
+	o.undoMu.Lock()
+	defer o.undoMu.Unlock()
+	if n > len(o.undoStack) {
+		n = len(o.undoStack)
+	}
+	steps := make([]undoStep, 0, n)
+	for i := 0; i < n; i++ {
+		last := len(o.undoStack) - 1
+		steps = append(steps, o.undoStack[last])
+		o.undoStack = o.undoStack[:last]
+	}
+	return steps
+}
+
+// peekUndo returns up to n latest steps, newest first, without removing them.
+func (o *Orchestrator) peekUndo(n int) []undoStep {
+	o.undoMu.Lock()
+	defer o.undoMu.Unlock()
+	var steps []undoStep
+	for i := len(o.undoStack) - 1; i >= 0 && len(steps) < n; i-- {
+		steps = append(steps, o.undoStack[i])
+	}
+	return steps
+}
+
+// peekUndoKind returns the latest step of the given kind without removing it.
+func (o *Orchestrator) peekUndoKind(kind string) (undoStep, bool) {
+	o.undoMu.Lock()
+	defer o.undoMu.Unlock()
+	for i := len(o.undoStack) - 1; i >= 0; i-- {
+		if o.undoStack[i].kind == kind {
+			return o.undoStack[i], true
+		}
+	}
+	return undoStep{}, false
+}
+
+// popUndoKind removes the latest step of the given kind.
+func (o *Orchestrator) popUndoKind(kind string) (undoStep, bool) {
+	o.undoMu.Lock()
+	defer o.undoMu.Unlock()
+	for i := len(o.undoStack) - 1; i >= 0; i-- {
+		if o.undoStack[i].kind == kind {
+			step := o.undoStack[i]
+			o.undoStack = append(o.undoStack[:i], o.undoStack[i+1:]...)
+			return step, true
+		}
+	}
+	return undoStep{}, false
+}
+
+// dropUndo forgets all steps of a kind, e.g. after every timer was stopped.
+func (o *Orchestrator) dropUndo(kind string) {
+	o.undoMu.Lock()
+	defer o.undoMu.Unlock()
+	kept := o.undoStack[:0]
+	for _, step := range o.undoStack {
+		if step.kind != kind {
+			kept = append(kept, step)
+		}
+	}
+	o.undoStack = kept
+}
+
+// undoCounts maps spoken counts to numbers for "отмени два последних".
+var undoCounts = map[string]int{
+	"одно": 1, "один": 1, "одну": 1, "последнее": 1, "последнюю": 1, "последний": 1,
+	"два": 2, "две": 2, "оба": 2, "три": 3, "четыре": 4, "пять": 5,
+}
+
+// parseUndoCount reads how many actions to undo from the CANCEL argument.
+// Returns 0 when the argument isn't a count.
+func parseUndoCount(arg string) int {
+	arg = strings.TrimSpace(arg)
+	if arg == "" || arg == "last" || arg == "none" {
+		return 1
+	}
+	if n, err := strconv.Atoi(arg); err == nil && n > 0 {
+		return n
+	}
+	for _, word := range strings.Fields(arg) {
+		if n, ok := undoCounts[word]; ok {
+			return n
+		}
+	}
+	return 0
+}
@@ -0,0 +1,126 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type mockClipboard struct {
+	content string
+}
+
+func (m *mockClipboard) Read() (string, error) { return m.content, nil }
+
+func (m *mockClipboard) Write(content string) error {
+	m.content = content
+	return nil
+}
+
+type namedTimer struct {
+	active map[string]bool
+}
+
+func (m *namedTimer) Start(name string, d time.Duration) { m.active[name] = true }
+
+func (m *namedTimer) Cancel(name string) bool {
+	ok := m.active[name]
+	delete(m.active, name)
+	return ok
+}
+
+func (m *namedTimer) CancelAll() int {
+	n := len(m.active)
+	m.active = map[string]bool{}
+	return n
+}
+
+func newUndoOrchestrator() (*Orchestrator, *mockObsidian, *mockClipboard, *namedTimer) {
+	obs := &mockObsidian{}
+	clip := &mockClipboard{content: "старый буфер"}
+	timer := &namedTimer{active: map[string]bool{}}
+	o := &Orchestrator{
+		Notifier:  &mockNotifier{},
+		Obsidian:  obs,
+		Clipboard: clip,
+		Timer:     timer,
+		Memory:    NewContextMemory(5),
+	}
+	return o, obs, clip, timer
+}
+
+func TestUndoLastActions(t *testing.T) {
+	o, obs, clip, timer := newUndoOrchestrator()
+	ctx := context.Background()
+
+	o.handleNoteAction(ctx, "купить хлеб")
+	o.handleTimerAction(ctx, "60")
+	o.handleTimerAction(ctx, "300")
+	o.handleClipboardAction(ctx, "write:привет")
+
+	if got := o.handleCancelAction(ctx, "2"); got != "Отменено: буфер обмена, таймер" {
+		t.Errorf("unexpected result %q", got)
+	}
+	if clip.content != "старый буфер" {
+		t.Errorf("clipboard not restored, got %q", clip.content)
+	}
+	if len(timer.active) != 1 || !timer.active["Голосовой таймер 1"] {
+		t.Errorf("only the latest timer should be cancelled, active: %v", timer.active)
+	}
+
+	o.handleCancelAction(ctx, "last")
+	o.handleCancelAction(ctx, "last")
+	if obs.content != "" {
+		t.Errorf("note should be deleted, got %q", obs.content)
+	}
+	if got := o.handleCancelAction(ctx, "last"); got != "" {
+		t.Errorf("stack should be empty, got %q", got)
+	}
+}
+
+func TestUndoNoteRewrite(t *testing.T) {
+	o, obs, _, _ := newUndoOrchestrator()
+	ctx := context.Background()
+
+	o.handleNoteAction(ctx, "купить хлеб")
+	o.handleNoteAction(ctx, "UPDATE: купить молоко")
+	if obs.content != "REWRITTEN: купить молоко" {
+		t.Fatalf("unexpected note %q", obs.content)
+	}
+
+	o.handleCancelAction(ctx, "last")
+	if obs.content != "REWRITTEN: купить хлеб" {
+		t.Errorf("rewrite should be undone with the original text, got %q", obs.content)
+	}
+}
+
+func TestUndoNoteSkipsOtherKinds(t *testing.T) {
+	o, obs, clip, _ := newUndoOrchestrator()
+	ctx := context.Background()
+
+	o.handleNoteAction(ctx, "купить хлеб")
+	o.handleClipboardAction(ctx, "write:привет")
+	o.handleCancelAction(ctx, "note")
+
+	if obs.content != "" || clip.content != "привет" {
+		t.Errorf("only the note should be undone: note %q, clipboard %q", obs.content, clip.content)
+	}
+}
+
+func TestParseUndoCount(t *testing.T) {
+	tests := map[string]int{
+		"":              1,
+		"last":          1,
+		"3":             3,
+		"два последних": 2,
+		"две":           2,
+		"note":          0,
+		"timer":         0,
+		"-1":            0,
+	}
+	for arg, want := range tests {
+		if got := parseUndoCount(arg); got != want {
+			t.Errorf("parseUndoCount(%q) = %d, want %d", arg, got, want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package orchestrator
+
+import (
+	"hey-bobik/internal/llm"
+	"time"
+)
+
+// unroutedAction is the stats key for calls of commands that failed to route.
+const unroutedAction = "UNROUTED"
+
+// TrackUsage collects the usage of an LLM call made for the current command.
+// Wire it to the clients' OnUsage hook.
+func (o *Orchestrator) TrackUsage(model string, u llm.Usage) {
+	o.usageMu.Lock()
+	defer o.usageMu.Unlock()
+	if o.pendingUsage == nil {
+		o.pendingUsage = map[string][]llm.Usage{}
+	}
+	o.pendingUsage[model] = append(o.pendingUsage[model], u)
+}
+
+// flushUsage attributes the collected calls to the action and logs a summary.
+func (o *Orchestrator) flushUsage(action string) {
+	o.usageMu.Lock()
+	calls := o.pendingUsage
+	o.pendingUsage = nil
+	o.usageMu.Unlock()
+
+	if len(calls) == 0 {
+		return
+	}
+
+	var n, tokens int
+	var total time.Duration
+	for _, usages := range calls {
+		for _, u := range usages {
+			n++
+			tokens += u.PromptEvalCount + u.EvalCount
+			total += u.TotalDuration
+		}
+	}
+	log.Info("%s used the LLM %d time(s): %s, %d tokens", action, n, total.Round(time.Millisecond), tokens)
+
+	if o.Usage != nil {
+		o.Usage.Record(action, calls)
+	}
+}
@@ -0,0 +1,69 @@
+// Package ru holds the Russian word forms and word matching shared by the
+// tools, so notes, tasks and speech agree on them.
+package ru
+
+import (
+	"strings"
+	"time"
+)
+
+// monthsGenitive are the month names as said in dates, "17 октября".
+var monthsGenitive = [...]string{"", "января", "февраля", "марта", "апреля", "мая", "июня", "июля",
+	"августа", "сентября", "октября", "ноября", "декабря"}
+
+// MonthGenitive returns the name of a month in the genitive case.
+func MonthGenitive(m time.Month) string {
+	if m < time.January || m > time.December {
+		return ""
+	}
+	return monthsGenitive[m]
+}
+
+// ParseMonth reads a month name in the genitive case, e.g. "октября".
+func ParseMonth(word string) (time.Month, bool) {
+	word = Fold(word)
+	for m := time.January; m <= time.December; m++ {
+		if monthsGenitive[m] == word {
+			return m, true
+		}
+	}
+	return 0, false
+}
+
+// Fold lowercases a word and replaces "ё" with "е", which speech
+// recognition and people use interchangeably.
+func Fold(word string) string {
+	return strings.ReplaceAll(strings.ToLower(word), "ё", "е")
+}
+
+// Stem cuts a likely ending off a folded word so its other forms contain
+// it: two letters of words of six or more, one of five-letter words.
+func Stem(word string) string {
+	switch r := []rune(word); {
+	case len(r) >= 6:
+		return string(r[:len(r)-2])
+	case len(r) == 5:
+		return string(r[:4])
+	}
+	return word
+}
+
+// SameWord compares folded words ignoring their endings: they match when
+// they share all but the last two letters of the shorter one, and at least
+// four. So "молоко" matches "молока" and "альфе" matches "альфа", but short
+// words like "дом" must be equal.
+func SameWord(a, b string) bool {
+	if a == b {
+		return true
+	}
+	ra, rb := []rune(a), []rune(b)
+	short := min(len(ra), len(rb))
+	if short < 4 {
+		return false
+	}
+	common := 0
+	for common < short && ra[common] == rb[common] {
+		common++
+	}
+	return common >= max(short-2, 4)
+}
@@ -0,0 +1,48 @@
+package ru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMonths(t *testing.T) {
+	if got := MonthGenitive(time.October); got != "октября" {
+		t.Errorf("MonthGenitive(October) = %q", got)
+	}
+	if m, ok := ParseMonth("Октября"); !ok || m != time.October {
+		t.Errorf("ParseMonth = %v, %v", m, ok)
+	}
+	if _, ok := ParseMonth("октябрь"); ok {
+		t.Error("only the genitive form should be read")
+	}
+}
+
+func TestStem(t *testing.T) {
+	for word, want := range map[string]string{"подрядчиком": "подрядчик", "релиз": "рели", "хлеб": "хлеб"} {
+		if got := Stem(word); got != want {
+			t.Errorf("Stem(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
+
+func TestSameWord(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"молоко", "молока", true},
+		{"альфе", "альфа", true},
+		{"покупки", "покупок", true},
+		{"отпуске", "отпуск", true},
+		{"проект", "проектов", true},
+		{"дом", "дома", false},
+		{"кот", "котлета", false},
+		{"хлеб", "хлеба", true},
+		{"купить", "позвонить", false},
+	}
+	for _, tt := range tests {
+		if got := SameWord(tt.a, tt.b); got != tt.want {
+			t.Errorf("SameWord(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
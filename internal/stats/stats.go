@@ -0,0 +1,217 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"hey-bobik/internal/llm"
+	"hey-bobik/internal/logger"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+var log = logger.New("stats")
+
+// Summary aggregates LLM calls for one model or one action.
+type Summary struct {
+	Calls              int           `json:"calls"`
+	TotalDuration      time.Duration `json:"total_duration"`
+	LoadDuration       time.Duration `json:"load_duration"`
+	PromptTokens       int           `json:"prompt_tokens"`
+	PromptEvalDuration time.Duration `json:"prompt_eval_duration"`
+	EvalTokens         int           `json:"eval_tokens"`
+	EvalDuration       time.Duration `json:"eval_duration"`
+}
+
+func (s *Summary) add(u llm.Usage) {
+	s.Calls++
+	s.TotalDuration += u.TotalDuration
+	s.LoadDuration += u.LoadDuration
+	s.PromptTokens += u.PromptEvalCount
+	s.PromptEvalDuration += u.PromptEvalDuration
+	s.EvalTokens += u.EvalCount
+	s.EvalDuration += u.EvalDuration
+}
+
+// AvgLatency returns the mean total duration of a call.
+func (s Summary) AvgLatency() time.Duration {
+	if s.Calls == 0 {
+		return 0
+	}
+	return s.TotalDuration / time.Duration(s.Calls)
+}
+
+// AvgLoad returns the mean time spent loading the model per call.
+func (s Summary) AvgLoad() time.Duration {
+	if s.Calls == 0 {
+		return 0
+	}
+	return s.LoadDuration / time.Duration(s.Calls)
+}
+
+// EvalRate returns the mean generation speed in tokens per second.
+func (s Summary) EvalRate() float64 {
+	return llm.Usage{EvalCount: s.EvalTokens, EvalDuration: s.EvalDuration}.EvalRate()
+}
+
+// PromptRate returns the mean prompt processing speed in tokens per second.
+func (s Summary) PromptRate() float64 {
+	return llm.Usage{PromptEvalCount: s.PromptTokens, PromptEvalDuration: s.PromptEvalDuration}.PromptRate()
+}
+
+// Snapshot is a copy of the aggregated metrics.
+type Snapshot struct {
+	Since   time.Time          `json:"since"`
+	Models  map[string]Summary `json:"models"`
+	Actions map[string]Summary `json:"actions"`
+}
+
+// Stats aggregates LLM usage per model and per action and keeps it on disk,
+// so `bobik stats` can read it from another process.
+type Stats struct {
+	path string
+
+	mu      sync.Mutex
+	since   time.Time
+	models  map[string]*Summary
+	actions map[string]*Summary
+}
+
+// New creates a Stats that continues the totals stored at path.
+// An empty path keeps the metrics in memory only.
+func New(path string) *Stats {
+	s := &Stats{
+		path:    path,
+		since:   time.Now(),
+		models:  map[string]*Summary{},
+		actions: map[string]*Summary{},
+	}
+	if path == "" {
+		return s
+	}
+
+	snap, err := Load(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warn("Failed to load stats from %s: %v", path, err)
+		}
+		return s
+	}
+	s.since = snap.Since
+	for k, v := range snap.Models {
+		s.models[k] = &v
+	}
+	for k, v := range snap.Actions {
+		s.actions[k] = &v
+	}
+	return s
+}
+
+// Record adds the calls made for one command, attributed to its action.
+func (s *Stats) Record(action string, calls map[string][]llm.Usage) {
+	if len(calls) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	for model, usages := range calls {
+		for _, u := range usages {
+			summary(s.models, model).add(u)
+			summary(s.actions, action).add(u)
+		}
+	}
+	s.mu.Unlock()
+
+	if err := s.Save(); err != nil {
+		log.Warn("Failed to save stats: %v", err)
+	}
+}
+
+func summary(m map[string]*Summary, key string) *Summary {
+	if m[key] == nil {
+		m[key] = &Summary{}
+	}
+	return m[key]
+}
+
+// Snapshot returns a copy of the current totals.
+func (s *Stats) Snapshot() Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := Snapshot{
+		Since:   s.since,
+		Models:  make(map[string]Summary, len(s.models)),
+		Actions: make(map[string]Summary, len(s.actions)),
+	}
+	for k, v := range s.models {
+		snap.Models[k] = *v
+	}
+	for k, v := range s.actions {
+		snap.Actions[k] = *v
+	}
+	return snap
+}
+
+// Save writes the totals to the stats file atomically.
+func (s *Stats) Save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.Snapshot(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Load reads totals saved by a running agent.
+func Load(path string) (Snapshot, error) {
+	var snap Snapshot
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return snap, err
+	}
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return snap, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return snap, nil
+}
+
+// Write prints the snapshot as two tables, per model and per action.
+func (snap Snapshot) Write(w io.Writer) {
+	fmt.Fprintf(w, "Since %s\n", snap.Since.Format("2006-01-02 15:04"))
+	writeTable(w, "MODEL", snap.Models)
+	writeTable(w, "ACTION", snap.Actions)
+}
+
+func writeTable(w io.Writer, title string, rows map[string]Summary) {
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tCALLS\tAVG LATENCY\tAVG LOAD\tPROMPT TOK\tPROMPT TOK/S\tEVAL TOK\tEVAL TOK/S\t\n", title)
+
+	keys := make([]string, 0, len(rows))
+	for k := range rows {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s := rows[k]
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%d\t%.1f\t%d\t%.1f\t\n",
+			k, s.Calls, s.AvgLatency().Round(time.Millisecond), s.AvgLoad().Round(time.Millisecond),
+			s.PromptTokens, s.PromptRate(), s.EvalTokens, s.EvalRate())
+	}
+	tw.Flush()
+}
@@ -0,0 +1,55 @@
+package stats
+
+import (
+	"bytes"
+	"hey-bobik/internal/llm"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordPerModelAndAction(t *testing.T) {
+	s := New("")
+	router := llm.Usage{TotalDuration: time.Second, LoadDuration: 300 * time.Millisecond, PromptEvalCount: 100, EvalCount: 10, EvalDuration: 500 * time.Millisecond}
+	vision := llm.Usage{TotalDuration: 3 * time.Second, EvalCount: 60, EvalDuration: 2 * time.Second}
+
+	s.Record("SCREEN", map[string][]llm.Usage{"qwen3:8b": {router}, "llava": {vision}})
+	s.Record("NOTE", map[string][]llm.Usage{"qwen3:8b": {router}})
+
+	snap := s.Snapshot()
+	if got := snap.Models["qwen3:8b"]; got.Calls != 2 || got.PromptTokens != 200 || got.EvalRate() != 20 || got.AvgLoad() != 300*time.Millisecond {
+		t.Errorf("unexpected router summary: %+v", got)
+	}
+	if got := snap.Actions["SCREEN"]; got.Calls != 2 || got.AvgLatency() != 2*time.Second {
+		t.Errorf("unexpected SCREEN summary: %+v", got)
+	}
+	if got := snap.Actions["NOTE"]; got.Calls != 1 {
+		t.Errorf("unexpected NOTE summary: %+v", got)
+	}
+}
+
+func TestPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats", "stats.json")
+	u := llm.Usage{TotalDuration: time.Second, EvalCount: 5}
+
+	New(path).Record("TIME", map[string][]llm.Usage{"qwen3:8b": {u}})
+	s := New(path)
+	s.Record("TIME", map[string][]llm.Usage{"qwen3:8b": {u}})
+
+	snap, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if snap.Actions["TIME"].Calls != 2 || snap.Models["qwen3:8b"].EvalTokens != 10 {
+		t.Errorf("totals should continue across restarts: %+v", snap)
+	}
+
+	var buf bytes.Buffer
+	snap.Write(&buf)
+	for _, want := range []string{"MODEL", "qwen3:8b", "ACTION", "TIME"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, buf.String())
+		}
+	}
+}
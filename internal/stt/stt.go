@@ -3,6 +3,7 @@ package stt
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	vosk "github.com/alphacep/vosk-api/go"
@@ -77,6 +78,48 @@ func (e *Engine) ListenForWakeWord(audioChan <-chan []int16, grammar string, wak
 	return false, nil
 }
 
+// ListenForPhrase listens for a short answer restricted to grammar, e.g. yes
+// or no, and returns the first recognized phrase. It returns "" when nothing
+// but unknown words is heard within timeout.
+func (e *Engine) ListenForPhrase(audioChan <-chan []int16, grammar string, timeout time.Duration) (string, error) {
+	rec, err := vosk.NewRecognizerGrm(e.model, defaultSampleRate, grammar)
+	if err != nil {
+		return "", fmt.Errorf("failed to create recognizer: %w", err)
+	}
+	defer rec.Free()
+
+	deadline := time.After(timeout)
+	for {
+		select {
+		case <-deadline:
+			return phrase(rec.FinalResult()), nil
+		case samples, ok := <-audioChan:
+			if !ok {
+				return "", nil
+			}
+			byteBuf := make([]byte, len(samples)*2)
+			for i, s := range samples {
+				byteBuf[i*2] = byte(s & 0xff)
+				byteBuf[i*2+1] = byte(s >> 8)
+			}
+			if rec.AcceptWaveform(byteBuf) != 0 {
+				if text := phrase(rec.Result()); text != "" {
+					return text, nil
+				}
+			}
+		}
+	}
+}
+
+// phrase extracts the recognized text from a Vosk result, dropping unknown words.
+func phrase(result string) string {
+	var res RecognitionResult
+	if err := json.Unmarshal([]byte(result), &res); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.ReplaceAll(res.Text, "[unk]", ""))
+}
+
 // Transcribe records audio for a short duration and returns the combined text.
 func (e *Engine) Transcribe(audioChan <-chan []int16) (string, error) {
 	rec, err := vosk.NewRecognizer(e.model, 16000.0)
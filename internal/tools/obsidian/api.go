@@ -0,0 +1,327 @@
+package obsidian
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hey-bobik/internal/vault"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is the HTTPS address of the Local REST API plugin. Its
+// self-signed certificate can be downloaded from /obsidian-local-rest-api.crt.
+const DefaultAPIURL = "https://127.0.0.1:27124"
+
+const (
+	apiTimeout  = 10 * time.Second
+	apiAttempts = 3 // reads of a note that changed under a rewrite
+	apiNoteType = "application/vnd.olrapi.note+json"
+	// searchContext is how many characters around a match the plugin returns.
+	searchContext = 150
+)
+
+// errAPINotFound is the plugin's answer for a missing note.
+var errAPINotFound = errors.New("note not found")
+
+// API keeps daily notes through the Obsidian Local REST API plugin, for a
+// vault on another machine or an encrypted one that can't be written
+// directly. Entries use the same Options as the files backend, but the
+// plugin decides where daily notes live and creates them from the Periodic
+// Notes template, so the path and header templates don't apply.
+type API struct {
+	URL string
+	Key string
+	Now func() time.Time
+
+	client *http.Client
+	notes  *Service // entry layout
+}
+
+// NewAPI creates a client of the plugin at url with its API key. certFile is
+// the plugin's certificate to trust; empty uses the system roots.
+func NewAPI(url, key, certFile string, opts Options) (*API, error) {
+	notes, err := NewWithOptions("", "", opts)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: apiTimeout}
+	if certFile != "" {
+		pem, err := os.ReadFile(certFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Obsidian API certificate: %w", err)
+		}
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", certFile)
+		}
+		client.Transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}
+	}
+
+	return &API{
+		URL:    strings.TrimRight(orDefault(url, DefaultAPIURL), "/"),
+		Key:    key,
+		Now:    time.Now,
+		client: client,
+		notes:  notes,
+	}, nil
+}
+
+// AppendToDailyNote appends an entry to today's daily note. With a section
+// it is patched under that heading, or the heading is added when missing.
+func (a *API) AppendToDailyNote(content string) error {
+	entry, err := a.notes.renderEntry(a.Now(), content)
+	if err != nil {
+		return err
+	}
+
+	section := a.notes.fmt().section
+	if section == "" {
+		_, err := a.do(http.MethodPost, "/periodic/daily/", nil, entry)
+		return err
+	}
+
+	heading := strings.TrimSpace(strings.TrimLeft(section, "#"))
+	_, err = a.do(http.MethodPatch, "/periodic/daily/", map[string]string{
+		"Operation":   "append",
+		"Target-Type": "heading",
+		"Target":      url.PathEscape(heading),
+	}, entry)
+	if err == nil {
+		return nil
+	}
+	// The heading is missing, or nested under another one so the plugin
+	// wants its full path: edit the note as a whole instead
+	log.Debug("Patching %q failed, rewriting the note: %v", heading, err)
+	err = a.update(func(text string) (string, error) {
+		return a.notes.appendEntry(text, entry), nil
+	})
+	if errors.Is(err, errAPINotFound) {
+		// POST creates the note from its template
+		_, err = a.do(http.MethodPost, "/periodic/daily/", nil, section+"\n"+entry)
+	}
+	return err
+}
+
+// RewriteLastNote replaces the text of the last entry of today's note and
+// returns the replaced text, or appends and returns "" without an entry.
+func (a *API) RewriteLastNote(content string) (string, error) {
+	var previous string
+	err := a.update(func(text string) (string, error) {
+		text, replaced, err := a.notes.rewriteLast(text, content)
+		previous = replaced
+		return text, err
+	})
+	if errors.Is(err, errNoEntry) || errors.Is(err, errAPINotFound) {
+		return "", a.AppendToDailyNote(content)
+	}
+	if err != nil {
+		return "", err
+	}
+	return previous, nil
+}
+
+// DeleteLastNote removes the last entry from today's note.
+func (a *API) DeleteLastNote() error {
+	err := a.update(a.notes.deleteLast)
+	if errors.Is(err, errAPINotFound) {
+		return fmt.Errorf("no daily note found")
+	}
+	return err
+}
+
+// Entries returns the entries of the daily notes from one day to another.
+func (a *API) Entries(from, to time.Time) ([]Entry, error) {
+	var entries []Entry
+	for _, day := range days(from, to) {
+		endpoint := fmt.Sprintf("/periodic/daily/%d/%d/%d/", day.Year(), day.Month(), day.Day())
+		note, err := a.note(endpoint)
+		if errors.Is(err, errAPINotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, a.notes.parseEntries(strings.Split(note.Content, "\n"), day, note.Path)...)
+	}
+	return entries, nil
+}
+
+// Search runs the plugin's simple search. With dates only daily notes of
+// those days are searched; notes without a date in the name have none.
+func (a *API) Search(query string, from, to time.Time) ([]Entry, error) {
+	q := url.Values{"query": {query}, "contextLength": {fmt.Sprint(searchContext)}}
+	body, err := a.do(http.MethodPost, "/search/simple/?"+q.Encode(), nil, "")
+	if err != nil {
+		return nil, err
+	}
+	var results []struct {
+		Filename string `json:"filename"`
+		Matches  []struct {
+			Context string `json:"context"`
+		} `json:"matches"`
+	}
+	if err := json.Unmarshal(body, &results); err != nil {
+		return nil, fmt.Errorf("failed to parse search results: %w", err)
+	}
+
+	var entries []Entry
+	for _, r := range results {
+		day, _ := time.ParseInLocation("2006-01-02", isoInName.FindString(r.Filename), time.Local)
+		if !from.IsZero() && (day.Before(startOfDay(from)) || day.After(startOfDay(to))) {
+			continue
+		}
+		for _, m := range r.Matches {
+			if text := strings.Join(strings.Fields(m.Context), " "); text != "" {
+				entries = append(entries, Entry{Date: day, Content: text, File: r.Filename})
+			}
+		}
+	}
+	return entries, nil
+}
+
+// SaveAttachment uploads a file to the attachments folder and returns its
+// vault path. A folder relative to the daily note can't be resolved through
+// the plugin, so such attachments go to the vault root.
+func (a *API) SaveAttachment(name string, data []byte) (string, error) {
+	folder := strings.Trim(a.notes.AttachmentFolder, "/")
+	if folder == "." || strings.HasPrefix(folder, "./") || strings.HasPrefix(folder, "..") {
+		folder = ""
+	}
+
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(path.Base(name), ext)
+	file := path.Join(folder, base+ext)
+	for i := 1; ; i++ {
+		_, err := a.do(http.MethodGet, vaultURL(file), nil, "")
+		if errors.Is(err, errAPINotFound) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		file = path.Join(folder, fmt.Sprintf("%s %d%s", base, i, ext))
+	}
+
+	contentType := mime.TypeByExtension(ext)
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	if _, err := a.do(http.MethodPut, vaultURL(file), map[string]string{"Content-Type": contentType}, string(data)); err != nil {
+		return "", fmt.Errorf("failed to save attachment: %w", err)
+	}
+	return file, nil
+}
+
+// DeleteAttachment removes a file uploaded by SaveAttachment.
+func (a *API) DeleteAttachment(file string) error {
+	if _, err := a.do(http.MethodDelete, vaultURL(file), nil, ""); err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+	return nil
+}
+
+// vaultURL escapes a vault path for the /vault/ endpoint.
+func vaultURL(file string) string {
+	parts := strings.Split(file, "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return "/vault/" + strings.Join(parts, "/")
+}
+
+// apiNote is the JSON form of a note.
+type apiNote struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+func (a *API) note(path string) (apiNote, error) {
+	body, err := a.do(http.MethodGet, path, map[string]string{"Accept": apiNoteType}, "")
+	if err != nil {
+		return apiNote{}, err
+	}
+	var note apiNote
+	if err := json.Unmarshal(body, &note); err != nil {
+		return apiNote{}, fmt.Errorf("failed to parse note: %w", err)
+	}
+	return note, nil
+}
+
+// update rewrites today's note with the result of change. The plugin can't
+// replace a note only if it's unchanged, so the note is read again just
+// before writing and the change is redone if it was edited meanwhile.
+func (a *API) update(change func(text string) (string, error)) error {
+	for attempt := 1; attempt <= apiAttempts; attempt++ {
+		before, err := a.note("/periodic/daily/")
+		if err != nil {
+			return err
+		}
+		text, err := change(before.Content)
+		if err != nil {
+			return err
+		}
+		now, err := a.note("/periodic/daily/")
+		if err != nil {
+			return err
+		}
+		if now.Content != before.Content {
+			log.Debug("Daily note changed while writing, retrying (%d/%d)", attempt, apiAttempts)
+			continue
+		}
+		_, err = a.do(http.MethodPut, "/periodic/daily/", nil, text)
+		return err
+	}
+	return fmt.Errorf("%w: daily note", vault.ErrConflict)
+}
+
+// do sends a request with a Markdown body and returns the response body.
+func (a *API) do(method, path string, header map[string]string, body string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, a.URL+path, bytes.NewReader([]byte(body)))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+a.Key)
+	req.Header.Set("Content-Type", "text/markdown")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("obsidian api: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("obsidian api: %w", err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, errAPINotFound
+	case resp.StatusCode >= 300:
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return nil, fmt.Errorf("obsidian api: %s: %s", resp.Status, apiErr.Message)
+		}
+		return nil, fmt.Errorf("obsidian api: %s", resp.Status)
+	}
+	return data, nil
+}
@@ -0,0 +1,235 @@
+package obsidian
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakePlugin stands in for the Local REST API plugin with daily notes kept
+// in memory by date.
+type fakePlugin struct {
+	today   string
+	notes   map[string]string
+	files   map[string]string // other vault files by path
+	patches int
+}
+
+func (f *fakePlugin) path(date string) string {
+	return "Daily/" + date + ".md"
+}
+
+func (f *fakePlugin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"errorCode":40101,"message":"Authorization required."}`)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+
+	if file, ok := strings.CutPrefix(r.URL.Path, "/vault/"); ok {
+		_, exists := f.files[file]
+		switch {
+		case r.Method == http.MethodPut:
+			f.files[file] = r.Header.Get("Content-Type") + ":" + string(body)
+		case !exists:
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodDelete:
+			delete(f.files, file)
+		}
+		return
+	}
+
+	if r.URL.Path == "/search/simple/" {
+		query := r.URL.Query().Get("query")
+		type match struct {
+			Context string `json:"context"`
+		}
+		results := []map[string]any{}
+		for date, text := range f.notes {
+			var matches []match
+			for _, line := range strings.Split(text, "\n") {
+				if strings.Contains(strings.ToLower(line), strings.ToLower(query)) {
+					matches = append(matches, match{Context: line})
+				}
+			}
+			if matches != nil {
+				results = append(results, map[string]any{"filename": f.path(date), "score": -1, "matches": matches})
+			}
+		}
+		json.NewEncoder(w).Encode(results)
+		return
+	}
+
+	date := f.today
+	if rest := strings.TrimPrefix(r.URL.Path, "/periodic/daily/"); rest != "" {
+		var y, m, d int
+		fmt.Sscanf(rest, "%d/%d/%d/", &y, &m, &d)
+		date = fmt.Sprintf("%04d-%02d-%02d", y, m, d)
+	}
+	text, exists := f.notes[date]
+
+	switch r.Method {
+	case http.MethodGet:
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"path": f.path(date), "content": text})
+	case http.MethodPost:
+		if !exists {
+			text = "---\ntemplate: daily\n---\n"
+		}
+		f.notes[date] = text + string(body)
+	case http.MethodPut:
+		f.notes[date] = string(body)
+	case http.MethodPatch:
+		f.patches++
+		target, _ := url.PathUnescape(r.Header.Get("Target"))
+		lines := strings.Split(text, "\n")
+		for i, line := range lines {
+			if headingLevel(line) == 0 || strings.TrimSpace(strings.TrimLeft(line, "#")) != target {
+				continue
+			}
+			end := len(lines)
+			for j := i + 1; j < len(lines); j++ {
+				if l := headingLevel(lines[j]); l > 0 && l <= headingLevel(line) {
+					end = j
+					break
+				}
+			}
+			for end > i+1 && lines[end-1] == "" {
+				end--
+			}
+			f.notes[date] = strings.Join(lines[:end], "\n") + "\n" + string(body) + strings.Join(lines[end:], "\n")
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"errorCode":40080,"message":"The patch you provided could not be applied to the target content. invalid-target"}`)
+	}
+}
+
+func newTestAPI(t *testing.T, opts Options) (*API, *fakePlugin) {
+	t.Helper()
+	plugin := &fakePlugin{today: "2026-10-18", notes: map[string]string{}, files: map[string]string{}}
+	server := httptest.NewServer(plugin)
+	t.Cleanup(server.Close)
+
+	api, err := NewAPI(server.URL, "secret", "", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api.Now = func() time.Time { return time.Date(2026, 10, 18, 9, 30, 0, 0, time.Local) }
+	return api, plugin
+}
+
+func TestAPIDailyNote(t *testing.T) {
+	api, plugin := newTestAPI(t, Options{})
+
+	api.AppendToDailyNote("купить хлеб")
+	api.AppendToDailyNote("позвонить маме")
+	want := "---\ntemplate: daily\n---\n## 09:30:00\nкупить хлеб\n\n## 09:30:00\nпозвонить маме\n\n"
+	if got := plugin.notes["2026-10-18"]; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	previous, err := api.RewriteLastNote("позвонить папе")
+	if err != nil || previous != "позвонить маме" {
+		t.Errorf("RewriteLastNote = %q, %v", previous, err)
+	}
+	if got := plugin.notes["2026-10-18"]; !strings.HasSuffix(got, "## 09:30:00\nпозвонить папе\n\n") {
+		t.Errorf("unexpected note after rewrite %q", got)
+	}
+
+	if err := api.DeleteLastNote(); err != nil {
+		t.Fatalf("DeleteLastNote failed: %v", err)
+	}
+	if got := plugin.notes["2026-10-18"]; got != "---\ntemplate: daily\n---\n## 09:30:00\nкупить хлеб\n\n" {
+		t.Errorf("unexpected note after delete %q", got)
+	}
+
+	api.Key = "wrong"
+	if err := api.AppendToDailyNote("тест"); err == nil || !strings.Contains(err.Error(), "Authorization required") {
+		t.Errorf("expected the plugin's error, got %v", err)
+	}
+}
+
+func TestAPISection(t *testing.T) {
+	api, plugin := newTestAPI(t, Options{
+		EntryTemplate:  "- {{time}} {{.Content}}\n",
+		EntryDelimiter: "- ",
+		Section:        "## Inbox",
+	})
+
+	// A new note gets the heading
+	api.AppendToDailyNote("купить хлеб")
+	if got := plugin.notes["2026-10-18"]; got != "---\ntemplate: daily\n---\n## Inbox\n- 09:30 купить хлеб\n" {
+		t.Errorf("unexpected new note %q", got)
+	}
+
+	// An existing heading is patched
+	plugin.notes["2026-10-18"] += "\n## Итоги\nхороший день\n"
+	plugin.patches = 0
+	api.AppendToDailyNote("позвонить маме")
+	want := "---\ntemplate: daily\n---\n## Inbox\n- 09:30 купить хлеб\n- 09:30 позвонить маме\n\n## Итоги\nхороший день\n"
+	if got := plugin.notes["2026-10-18"]; got != want || plugin.patches != 1 {
+		t.Errorf("expected one patch and %q, got %d and %q", want, plugin.patches, got)
+	}
+
+	// Rewrites stay inside the section
+	api.RewriteLastNote("позвонить папе")
+	if got := plugin.notes["2026-10-18"]; !strings.Contains(got, "- 09:30 позвонить папе\n\n## Итоги") {
+		t.Errorf("unexpected note after rewrite %q", got)
+	}
+}
+
+func TestAPIReadAndSearch(t *testing.T) {
+	api, plugin := newTestAPI(t, Options{})
+	plugin.notes["2026-10-16"] = "## 11:00:00\nрелиз перенесли на пятницу\n\n## 12:00:00\nкупить хлеб\n"
+	plugin.notes["2026-10-18"] = "## 09:00:00\nрелиз в пятницу\n"
+
+	day := time.Date(2026, 10, 16, 0, 0, 0, 0, time.Local)
+	entries, err := api.Entries(day, day.AddDate(0, 0, 1))
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %+v, %v", entries, err)
+	}
+	if e := entries[0]; e.Heading != "11:00:00" || e.Content != "релиз перенесли на пятницу" || e.File != "Daily/2026-10-16.md" {
+		t.Errorf("unexpected entry %+v", e)
+	}
+
+	found, err := api.Search("релиз", time.Time{}, time.Time{})
+	if err != nil || len(found) != 2 {
+		t.Fatalf("expected 2 matches in the vault, got %+v, %v", found, err)
+	}
+	found, _ = api.Search("релиз", day, day)
+	if len(found) != 1 || found[0].Content != "релиз перенесли на пятницу" || !found[0].Date.Equal(day) {
+		t.Errorf("the period should limit the search, got %+v", found)
+	}
+}
+
+func TestAPISaveAttachment(t *testing.T) {
+	api, plugin := newTestAPI(t, Options{AttachmentFolder: "Вложения"})
+
+	if got, err := api.SaveAttachment("Screenshot.png", []byte("PNG")); got != "Вложения/Screenshot.png" || err != nil {
+		t.Fatalf("SaveAttachment = %q, %v", got, err)
+	}
+	if got := plugin.files["Вложения/Screenshot.png"]; got != "image/png:PNG" {
+		t.Errorf("unexpected upload %q", got)
+	}
+	if got, _ := api.SaveAttachment("Screenshot.png", []byte("PNG")); got != "Вложения/Screenshot 1.png" {
+		t.Errorf("an existing file should be kept, got %q", got)
+	}
+
+	if err := api.DeleteAttachment("Вложения/Screenshot 1.png"); err != nil {
+		t.Fatalf("DeleteAttachment failed: %v", err)
+	}
+	if _, exists := plugin.files["Вложения/Screenshot 1.png"]; exists || len(plugin.files) != 1 {
+		t.Errorf("only the deleted file should be gone, got %v", plugin.files)
+	}
+}
@@ -0,0 +1,100 @@
+package obsidian
+
+import (
+	"encoding/json"
+	"fmt"
+	"hey-bobik/internal/vault"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SaveAttachment stores a file, e.g. a screenshot, in the attachments folder
+// and returns its vault path for an embed like ![[Attachments/x.png]]. An
+// existing file of the same name is kept and a number is added to the new one.
+func (s *Service) SaveAttachment(name string, data []byte) (string, error) {
+	folder, err := s.attachmentFolder()
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(s.VaultPath); err != nil {
+		return "", fmt.Errorf("vault not found: %w", err)
+	}
+	dir := filepath.Join(s.VaultPath, folder)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create attachments folder: %w", err)
+	}
+
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(filepath.Base(name), ext)
+	file := base + ext
+	for i := 1; ; i++ {
+		if _, err := os.Stat(filepath.Join(dir, file)); os.IsNotExist(err) {
+			break
+		}
+		file = fmt.Sprintf("%s %d%s", base, i, ext)
+	}
+
+	if err := vault.WriteFile(filepath.Join(dir, file), data); err != nil {
+		return "", fmt.Errorf("failed to save attachment: %w", err)
+	}
+	return filepath.ToSlash(filepath.Join(folder, file)), nil
+}
+
+// DeleteAttachment removes a file saved by SaveAttachment, e.g. to undo it.
+func (s *Service) DeleteAttachment(path string) error {
+	rel := filepath.Clean(filepath.FromSlash(path))
+	if filepath.IsAbs(rel) || rel == "." || strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("attachment path %q must be inside the vault", path)
+	}
+	if err := os.Remove(filepath.Join(s.VaultPath, rel)); err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+	return nil
+}
+
+// attachmentFolder returns the vault folder for new attachments: the
+// configured one, or else Obsidian's "Default location for new attachments".
+// "./assets" is relative to the folder of today's daily note.
+func (s *Service) attachmentFolder() (string, error) {
+	folder := s.AttachmentFolder
+	if folder == "" {
+		folder = obsidianAttachmentFolder(s.VaultPath)
+	}
+
+	folder = strings.TrimPrefix(folder, "/")
+	if folder == "." || strings.HasPrefix(folder, "./") {
+		note, err := s.dailyNotePath(s.Now())
+		if err != nil {
+			return "", err
+		}
+		rel, _ := filepath.Rel(s.VaultPath, filepath.Dir(note))
+		folder = filepath.Join(rel, folder)
+	}
+
+	folder = filepath.Clean(folder)
+	if folder == "." {
+		return "", nil
+	}
+	if filepath.IsAbs(folder) || strings.HasPrefix(folder, "..") {
+		return "", fmt.Errorf("attachments folder %q must be inside the vault", folder)
+	}
+	return folder, nil
+}
+
+// obsidianAttachmentFolder reads attachmentFolderPath from the vault's
+// settings, "" (the vault root) when it isn't set.
+func obsidianAttachmentFolder(vaultPath string) string {
+	data, err := os.ReadFile(filepath.Join(vaultPath, ".obsidian", "app.json"))
+	if err != nil {
+		return ""
+	}
+	var app struct {
+		AttachmentFolderPath string `json:"attachmentFolderPath"`
+	}
+	if err := json.Unmarshal(data, &app); err != nil {
+		log.Warn("Failed to read Obsidian settings: %v", err)
+		return ""
+	}
+	return app.AttachmentFolderPath
+}
@@ -0,0 +1,64 @@
+package obsidian
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveAttachment(t *testing.T) {
+	vault := t.TempDir()
+	s, _ := NewWithOptions(vault, "", Options{PathTemplate: `Daily/{{.Date.Format "2006-01-02"}}.md`})
+	s.Now = func() time.Time { return time.Date(2026, 10, 18, 9, 30, 0, 0, time.Local) }
+	png := []byte("\x89PNG")
+
+	// Without a setting attachments go to the vault root, like in Obsidian
+	if got, err := s.SaveAttachment("Screenshot.png", png); got != "Screenshot.png" || err != nil {
+		t.Errorf("SaveAttachment = %q, %v", got, err)
+	}
+	if got, _ := s.SaveAttachment("Screenshot.png", png); got != "Screenshot 1.png" {
+		t.Errorf("an existing file should be kept, got %q", got)
+	}
+
+	// Obsidian's "Default location for new attachments"
+	writeNote(t, vault, ".obsidian/app.json", `{"attachmentFolderPath": "Attachments", "alwaysUpdateLinks": true}`)
+	if got, _ := s.SaveAttachment("Screenshot.png", png); got != "Attachments/Screenshot.png" {
+		t.Errorf("expected the Obsidian folder, got %q", got)
+	}
+	if data, _ := os.ReadFile(filepath.Join(vault, "Attachments", "Screenshot.png")); string(data) != string(png) {
+		t.Errorf("unexpected attachment %q", data)
+	}
+
+	writeNote(t, vault, ".obsidian/app.json", `{"attachmentFolderPath": "./assets"}`)
+	if got, _ := s.SaveAttachment("Screenshot.png", png); got != "Daily/assets/Screenshot.png" {
+		t.Errorf("expected a folder next to the daily note, got %q", got)
+	}
+
+	// The configured folder wins
+	s.AttachmentFolder = "Media/Screens"
+	if got, _ := s.SaveAttachment("Screenshot.png", png); got != "Media/Screens/Screenshot.png" {
+		t.Errorf("expected the configured folder, got %q", got)
+	}
+	s.AttachmentFolder = "../outside"
+	if _, err := s.SaveAttachment("Screenshot.png", png); err == nil {
+		t.Error("a folder outside the vault should be rejected")
+	}
+}
+
+func TestDeleteAttachment(t *testing.T) {
+	vault := t.TempDir()
+	s := New(vault, "")
+	s.AttachmentFolder = "Attachments"
+	file, _ := s.SaveAttachment("Screenshot.png", []byte("\x89PNG"))
+
+	if err := s.DeleteAttachment(file); err != nil {
+		t.Fatalf("DeleteAttachment failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(vault, "Attachments", "Screenshot.png")); !os.IsNotExist(err) {
+		t.Errorf("the attachment should be gone, got %v", err)
+	}
+	if err := s.DeleteAttachment("../outside.png"); err == nil {
+		t.Error("a path outside the vault should be rejected")
+	}
+}
@@ -0,0 +1,262 @@
+package obsidian
+
+import (
+	"errors"
+	"fmt"
+	"hey-bobik/internal/ru"
+	"hey-bobik/internal/vault"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Defaults for notes written by name, e.g. "запиши в проект Альфа".
+const (
+	DefaultNamedHeaderTemplate = "---\ncreated: {{.Date.Format \"2006-01-02T15:04:05Z07:00\"}}\nsource: Bobik\n---\n\n"
+	DefaultNamedEntryTemplate  = "- {{.Date.Format \"2006-01-02 15:04\"}} {{.Content}}\n"
+)
+
+// minNameScore is how well a spoken name has to match a title or an alias.
+const minNameScore = 0.5
+
+var (
+	// ErrNoteNotFound means no note matches the spoken name.
+	ErrNoteNotFound = errors.New("note not found")
+	// ErrAmbiguousNote means several notes match the name equally well.
+	ErrAmbiguousNote = errors.New("several notes match")
+)
+
+// FindNote resolves a spoken note name to a note of the vault by its title
+// or one of its frontmatter aliases, tolerating Russian endings and extra
+// words like "проект". It returns the path relative to the vault.
+func (s *Service) FindNote(name string) (string, error) {
+	spoken := nameWords(name)
+	if len(spoken) == 0 {
+		return "", ErrNoteNotFound
+	}
+
+	var best []string
+	bestScore := 0.0
+	err := filepath.WalkDir(s.VaultPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != s.VaultPath && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(d.Name(), ".md") {
+			return nil
+		}
+
+		names := []string{strings.TrimSuffix(d.Name(), ".md")}
+		if data, err := os.ReadFile(path); err == nil {
+			names = append(names, aliases(strings.Split(string(data), "\n"))...)
+		}
+		score := 0.0
+		for _, n := range names {
+			score = max(score, nameScore(spoken, nameWords(n)))
+		}
+
+		rel, _ := filepath.Rel(s.VaultPath, path)
+		switch {
+		case score < minNameScore || score < bestScore:
+		case score > bestScore:
+			best, bestScore = []string{rel}, score
+		default:
+			best = append(best, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to read vault: %w", err)
+	}
+
+	switch len(best) {
+	case 0:
+		return "", ErrNoteNotFound
+	case 1:
+		return best[0], nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrAmbiguousNote, strings.Join(best, ", "))
+}
+
+// AppendToNote appends an entry to the note found by FindNote and returns
+// the note and the entry, so it can be removed again. A missing note is
+// created in the named notes folder from its template.
+func (s *Service) AppendToNote(name, content string) (file, entry string, err error) {
+	file, err = s.FindNote(name)
+	if errors.Is(err, ErrNoteNotFound) {
+		file, err = s.newNotePath(name)
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	now := s.Now()
+	filePath := filepath.Join(s.VaultPath, file)
+	title := strings.TrimSuffix(filepath.Base(file), ".md")
+	if entry, err = execute(s.fmt().namedEntry, s.data(now, title, content)); err != nil {
+		return "", "", fmt.Errorf("failed to render entry: %w", err)
+	}
+
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		if _, err := os.Stat(s.VaultPath); err != nil {
+			return "", "", fmt.Errorf("vault not found: %w", err)
+		}
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			return "", "", fmt.Errorf("failed to create note folder: %w", err)
+		}
+	}
+
+	err = vault.Update(filePath, func(old []byte) ([]byte, error) {
+		text := string(old)
+		if old == nil {
+			header, err := s.namedNoteHeader(now, title)
+			if err != nil {
+				return nil, err
+			}
+			text = header
+		}
+		if text != "" && !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+		return []byte(text + entry), nil
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to write entry: %w", err)
+	}
+	return file, entry, nil
+}
+
+// RemoveFromNote deletes the last occurrence of an entry added by
+// AppendToNote, e.g. to undo it.
+func (s *Service) RemoveFromNote(file, entry string) error {
+	if filepath.IsAbs(file) || strings.HasPrefix(filepath.Clean(file), "..") {
+		return fmt.Errorf("note path %q must be relative to the vault", file)
+	}
+	return vault.Update(filepath.Join(s.VaultPath, file), func(old []byte) ([]byte, error) {
+		text := string(old)
+		i := strings.LastIndex(text, entry)
+		if old == nil || i < 0 {
+			return nil, fmt.Errorf("entry not found in %s", file)
+		}
+		return []byte(text[:i] + text[i+len(entry):]), nil
+	})
+}
+
+// newNotePath turns a spoken name into the path of a new note, e.g.
+// "альфа" into "Projects/Альфа.md".
+func (s *Service) newNotePath(name string) (string, error) {
+	title := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`*"\/<>:|?#^[]`, r) {
+			return -1
+		}
+		return r
+	}, strings.Join(strings.Fields(name), " "))
+	if title == "" {
+		return "", fmt.Errorf("empty note name")
+	}
+	r, size := utf8.DecodeRuneInString(title)
+	title = string(unicode.ToUpper(r)) + title[size:]
+
+	rel := filepath.Join(s.NamedFolder, title+".md")
+	if filepath.IsAbs(rel) || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("notes folder %q must be relative to the vault", s.NamedFolder)
+	}
+	return rel, nil
+}
+
+// namedNoteHeader renders the contents of a new named note from the template
+// file or the header template.
+func (s *Service) namedNoteHeader(now time.Time, title string) (string, error) {
+	header := s.fmt().namedHeader
+	if s.NamedHeaderFile != "" {
+		data, err := os.ReadFile(filepath.Join(s.VaultPath, s.NamedHeaderFile))
+		if err != nil {
+			return "", fmt.Errorf("failed to read note template: %w", err)
+		}
+		if header, err = parse("named header file", string(data)); err != nil {
+			return "", err
+		}
+	}
+
+	out, err := execute(header, s.data(now, title, ""))
+	if err != nil {
+		return "", fmt.Errorf("failed to render note header: %w", err)
+	}
+	if out != "" && !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+	return out, nil
+}
+
+// aliases reads the aliases of a note from its frontmatter, both the inline
+// form "aliases: [Альфа, Alpha]" and a YAML list.
+func aliases(lines []string) []string {
+	end := bodyStart(lines)
+	var found []string
+	inList := false
+	for i := 1; i < end-1; i++ {
+		line := lines[i]
+		if inList {
+			if item, ok := strings.CutPrefix(strings.TrimSpace(line), "- "); ok {
+				found = append(found, unquote(item))
+				continue
+			}
+			inList = false
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || (key != "aliases" && key != "alias") {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if value == "" {
+			inList = true
+			continue
+		}
+		for _, a := range strings.Split(strings.Trim(value, "[]"), ",") {
+			if a = unquote(a); a != "" {
+				found = append(found, a)
+			}
+		}
+	}
+	return found
+}
+
+func unquote(s string) string {
+	return strings.Trim(strings.TrimSpace(s), `"'`)
+}
+
+// nameWords splits a name into lowercase words, "Альфа-2" into "альфа" and "2".
+func nameWords(name string) []string {
+	name = ru.Fold(name)
+	return strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// nameScore compares the spoken words with the words of a title, 1 for the
+// same words and less for every missing or extra one.
+func nameScore(spoken, title []string) float64 {
+	if len(title) == 0 {
+		return 0
+	}
+	matched := 0
+	for _, t := range title {
+		for _, w := range spoken {
+			if ru.SameWord(w, t) {
+				matched++
+				break
+			}
+		}
+	}
+	return 2 * float64(matched) / float64(len(spoken)+len(title))
+}
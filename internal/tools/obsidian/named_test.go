@@ -0,0 +1,113 @@
+package obsidian
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeNote(t *testing.T, vault, rel, text string) {
+	t.Helper()
+	path := filepath.Join(vault, rel)
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindNote(t *testing.T) {
+	vault := t.TempDir()
+	writeNote(t, vault, "Projects/Альфа.md", "# Альфа\n")
+	writeNote(t, vault, "Projects/Проект Бета.md", "")
+	writeNote(t, vault, "Список покупок.md", "")
+	writeNote(t, vault, "Люди/Иван Петров.md", "---\naliases: [Ваня, \"Иван\"]\n---\n")
+	writeNote(t, vault, "Отпуск.md", "---\ntags: [личное]\naliases:\n  - Поездка в Грузию\n  - Грузия\n---\n")
+	writeNote(t, vault, ".trash/Альфа.md", "")
+	s := New(vault, "")
+
+	tests := map[string]string{
+		"Альфа":            "Projects/Альфа.md",
+		"проект Альфа":     "Projects/Альфа.md",
+		"альфе":            "Projects/Альфа.md",
+		"проект бета":      "Projects/Проект Бета.md",
+		"покупки":          "Список покупок.md",
+		"список покупок":   "Список покупок.md",
+		"ваня":             "Люди/Иван Петров.md",
+		"поездка в грузию": "Отпуск.md",
+		"отпуске":          "Отпуск.md",
+	}
+	for name, want := range tests {
+		if got, err := s.FindNote(name); got != want || err != nil {
+			t.Errorf("FindNote(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+
+	if _, err := s.FindNote("гамма"); !errors.Is(err, ErrNoteNotFound) {
+		t.Errorf("expected ErrNoteNotFound, got %v", err)
+	}
+
+	writeNote(t, vault, "Archive/Альфа.md", "")
+	if _, err := s.FindNote("альфа"); !errors.Is(err, ErrAmbiguousNote) {
+		t.Errorf("expected ErrAmbiguousNote, got %v", err)
+	}
+}
+
+func TestAppendToNote(t *testing.T) {
+	vault := t.TempDir()
+	writeNote(t, vault, "Projects/Альфа.md", "# Альфа\nБюджет проекта")
+	s, err := NewWithOptions(vault, "", Options{NamedFolder: "Projects"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Now = func() time.Time { return time.Date(2026, 10, 18, 9, 30, 0, 0, time.Local) }
+
+	file, entry, err := s.AppendToNote("проект альфа", "согласовать бюджет")
+	if err != nil || file != "Projects/Альфа.md" {
+		t.Fatalf("AppendToNote = %q, %v", file, err)
+	}
+	want := "# Альфа\nБюджет проекта\n- 2026-10-18 09:30 согласовать бюджет\n"
+	if data, _ := os.ReadFile(filepath.Join(vault, file)); string(data) != want {
+		t.Errorf("expected %q, got %q", want, data)
+	}
+
+	if err := s.RemoveFromNote(file, entry); err != nil {
+		t.Fatalf("RemoveFromNote failed: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(vault, file)); string(data) != "# Альфа\nБюджет проекта\n" {
+		t.Errorf("the entry should be removed, got %q", data)
+	}
+	if err := s.RemoveFromNote(file, entry); err == nil {
+		t.Error("removing a missing entry should fail")
+	}
+}
+
+func TestAppendToNewNote(t *testing.T) {
+	vault := t.TempDir()
+	writeNote(t, vault, "Templates/Проект.md", "---\ntags: [project]\n---\n# {{title}}\n\n")
+	s, _ := NewWithOptions(vault, "", Options{NamedFolder: "Projects", NamedHeaderFile: "Templates/Проект.md"})
+	s.Now = func() time.Time { return time.Date(2026, 10, 18, 9, 30, 0, 0, time.Local) }
+
+	file, _, err := s.AppendToNote("гамма", "созвон в среду")
+	if err != nil || file != "Projects/Гамма.md" {
+		t.Fatalf("AppendToNote = %q, %v", file, err)
+	}
+	want := "---\ntags: [project]\n---\n# Гамма\n\n- 2026-10-18 09:30 созвон в среду\n"
+	if data, _ := os.ReadFile(filepath.Join(vault, file)); string(data) != want {
+		t.Errorf("expected %q, got %q", want, data)
+	}
+
+	// The new note is found by name from now on
+	if file, _, _ := s.AppendToNote("Гамме", "ещё"); file != "Projects/Гамма.md" {
+		t.Errorf("expected the same note, got %q", file)
+	}
+
+	outside, _ := NewWithOptions(vault, "", Options{NamedFolder: "../outside"})
+	if _, _, err := outside.AppendToNote("дельта", "x"); err == nil {
+		t.Error("a notes folder outside the vault should be rejected")
+	}
+	if file, _, _ := New(vault, "").AppendToNote("a/b: c", "x"); file != "Ab c.md" {
+		t.Errorf("the name should become a plain file name, got %q", file)
+	}
+}
@@ -1,136 +1,556 @@
 package obsidian
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"hey-bobik/internal/logger"
+	"hey-bobik/internal/vault"
 	"os"
 	"path/filepath"
 	"strings"
+	"text/template"
 	"time"
+	"unicode/utf8"
 )
 
+var log = logger.New("obsidian")
+
+// Default templates reproduce the original layout: {Prefix}{YYYY-MM-DD}.md in
+// the vault root, a fixed frontmatter and "## HH:MM:SS" entries.
+const (
+	DefaultPathTemplate   = `{{.Prefix}}{{.Date.Format "2006-01-02"}}.md`
+	DefaultHeaderTemplate = "---\ndate: {{.Date.Format \"2006-01-02T15:04:05Z07:00\"}}\nsource: Bobik\ntags: [voice-note, inbox]\n---\n\n"
+	DefaultEntryTemplate  = "## {{.Time.Format \"15:04:05\"}}\n{{.Content}}\n\n"
+	DefaultEntryDelimiter = "## "
+)
+
+// contentMarker stands in for the content when the entry template is analysed.
+const contentMarker = "\x00content\x00"
+
+// Options configure where daily notes live and how entries look.
+// Templates use text/template with the fields of TemplateData; empty fields
+// keep the defaults.
+type Options struct {
+	PathTemplate   string // note path relative to the vault, e.g. `Daily/{{.Date.Format "2006/01"}}/{{.Date.Format "2006-01-02"}}.md`
+	HeaderTemplate string // contents of a new note
+	HeaderFile     string // vault template file for new notes, overrides HeaderTemplate
+	EntryTemplate  string // one appended entry, must start with EntryDelimiter
+	EntryDelimiter string // a line starting with it begins an entry
+	// Section is a heading, e.g. "## Inbox", entries go under. It is created
+	// when missing, and rewrite and delete only look at entries inside it.
+	Section string
+
+	// Notes written by name, see AppendToNote
+	NamedFolder         string // folder new named notes are created in, e.g. "Projects"
+	NamedHeaderTemplate string // contents of a new named note
+	NamedHeaderFile     string // vault template file for new named notes, overrides NamedHeaderTemplate
+	NamedEntryTemplate  string // one entry appended to a named note
+
+	// AttachmentFolder is where screenshots are saved, relative to the vault
+	// or, starting with "./", to the daily note. Empty uses Obsidian's setting
+	AttachmentFolder string
+}
+
+// TemplateData is passed to the templates. Obsidian's {{date}}, {{time}} and
+// {{title}} work too, with an optional Go layout: {{date "2006/01"}}.
+type TemplateData struct {
+	Date    time.Time
+	Time    time.Time // same as Date, reads better in entry templates
+	Prefix  string
+	Title   string // note file name without extension
+	Content string // the entry text, empty for path and header
+}
+
 // Service handles interactions with the Obsidian vault.
 type Service struct {
 	VaultPath string
 	Prefix    string
 	Now       func() time.Time
+
+	HeaderFile       string // see Options.HeaderFile
+	NamedFolder      string // see Options.NamedFolder
+	NamedHeaderFile  string // see Options.NamedHeaderFile
+	AttachmentFolder string // see Options.AttachmentFolder
+
+	format *noteFormat // nil uses the defaults
 }
 
-// New creates a new Obsidian service.
+// noteFormat holds the compiled templates.
+type noteFormat struct {
+	path      *template.Template
+	header    *template.Template
+	entry     *template.Template
+	delimiter string
+	layout    entryLayout
+	section   string // heading line, "" appends at the end of the note
+
+	namedHeader *template.Template
+	namedEntry  *template.Template
+}
+
+// New creates a new Obsidian service with the default note layout.
 func New(vaultPath, prefix string) *Service {
+	s, _ := NewWithOptions(vaultPath, prefix, Options{})
+	return s
+}
+
+// NewWithOptions creates a service with templated note path, header and entries.
+func NewWithOptions(vaultPath, prefix string, opts Options) (*Service, error) {
+	f, err := compileFormat(opts)
+	if err != nil {
+		return nil, err
+	}
 	return &Service{
-		VaultPath: vaultPath,
-		Prefix:    prefix,
-		Now:       time.Now,
+		VaultPath:        vaultPath,
+		Prefix:           prefix,
+		Now:              time.Now,
+		HeaderFile:       opts.HeaderFile,
+		NamedFolder:      opts.NamedFolder,
+		NamedHeaderFile:  opts.NamedHeaderFile,
+		AttachmentFolder: opts.AttachmentFolder,
+		format:           f,
+	}, nil
+}
+
+func compileFormat(opts Options) (*noteFormat, error) {
+	f := &noteFormat{
+		delimiter: orDefault(opts.EntryDelimiter, DefaultEntryDelimiter),
+		section:   strings.TrimSpace(opts.Section),
+	}
+	if f.section != "" {
+		if headingLevel(f.section) == 0 {
+			return nil, fmt.Errorf("section %q must be a Markdown heading like \"## Inbox\"", f.section)
+		}
+		// Entries that are headings must be nested in the section, or each one
+		// would close it
+		if level := headingLevel(f.delimiter + "x"); level > 0 && level <= headingLevel(f.section) {
+			return nil, fmt.Errorf("entry delimiter %q would end section %q, use a deeper heading or e.g. \"- \"", f.delimiter, f.section)
+		}
+	}
+
+	var err error
+	if f.path, err = parse("path", orDefault(opts.PathTemplate, DefaultPathTemplate)); err != nil {
+		return nil, err
+	}
+	if f.header, err = parse("header", orDefault(opts.HeaderTemplate, DefaultHeaderTemplate)); err != nil {
+		return nil, err
 	}
+	if f.entry, err = parse("entry", orDefault(opts.EntryTemplate, DefaultEntryTemplate)); err != nil {
+		return nil, err
+	}
+	if f.layout, err = analyseEntry(f.entry, f.delimiter); err != nil {
+		return nil, err
+	}
+	if f.namedHeader, err = parse("named header", orDefault(opts.NamedHeaderTemplate, DefaultNamedHeaderTemplate)); err != nil {
+		return nil, err
+	}
+	if f.namedEntry, err = parse("named entry", orDefault(opts.NamedEntryTemplate, DefaultNamedEntryTemplate)); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// defaultFormat is used by a Service built as a literal.
+var defaultFormat, _ = compileFormat(Options{})
+
+func (s *Service) fmt() *noteFormat {
+	if s.format == nil {
+		return defaultFormat
+	}
+	return s.format
 }
 
 // AppendToDailyNote appends a note to the daily Markdown file.
 func (s *Service) AppendToDailyNote(content string) error {
 	now := s.Now()
-	fileName := fmt.Sprintf("%s%s.md", s.Prefix, now.Format("2006-01-02"))
-	filePath := filepath.Join(s.VaultPath, fileName)
+	filePath, err := s.dailyNotePath(now)
+	if err != nil {
+		return err
+	}
 
-	exists := true
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		exists = false
+		// Folders inside the vault are created, a missing vault is an error
+		if _, err := os.Stat(s.VaultPath); err != nil {
+			return fmt.Errorf("vault not found: %w", err)
+		}
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			return fmt.Errorf("failed to create note folder: %w", err)
+		}
 	}
 
-	f, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	entry, err := s.renderEntry(now, content)
 	if err != nil {
-		return fmt.Errorf("failed to open daily note: %w", err)
+		return err
 	}
-	defer f.Close()
 
-	if !exists {
-		header := fmt.Sprintf("---\ndate: %s\nsource: Bobik\ntags: [voice-note, inbox]\n---\n\n", now.Format(time.RFC3339))
-		if _, err := f.WriteString(header); err != nil {
-			return fmt.Errorf("failed to write header: %w", err)
+	err = vault.Update(filePath, func(old []byte) ([]byte, error) {
+		text := string(old)
+		if old == nil {
+			header, err := s.newNoteHeader(now, filePath)
+			if err != nil {
+				return nil, err
+			}
+			text = header
 		}
-	}
-
-	entry := fmt.Sprintf("## %s\n%s\n\n", now.Format("15:04:05"), content)
-	if _, err := f.WriteString(entry); err != nil {
+		return []byte(s.appendEntry(text, entry)), nil
+	})
+	if err != nil {
 		return fmt.Errorf("failed to write entry: %w", err)
 	}
-
 	return nil
 }
 
-// RewriteLastNote replaces the last entry in the daily note with new content.
-func (s *Service) RewriteLastNote(content string) error {
-	now := s.Now()
-	fileName := fmt.Sprintf("%s%s.md", s.Prefix, now.Format("2006-01-02"))
-	filePath := filepath.Join(s.VaultPath, fileName)
+// appendEntry adds a rendered entry to the text of a daily note.
+func (s *Service) appendEntry(text, entry string) string {
+	if section := s.fmt().section; section != "" {
+		return appendToSection(text, section, entry)
+	}
+	return text + entry
+}
 
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return s.AppendToDailyNote(content)
+// appendToSection inserts the entry after the last line of the section,
+// creating the heading at the end of the note when it's missing.
+func appendToSection(text, section, entry string) string {
+	lines := strings.Split(text, "\n")
+	start, end := findSection(lines, section, bodyStart(lines))
+	if start < 0 {
+		return attach(text, section+"\n"+entry)
 	}
+	last := start
+	for i := start + 1; i < end; i++ {
+		if strings.TrimSpace(lines[i]) != "" {
+			last = i
+		}
+	}
+	return attach(strings.Join(lines[:last+1], "\n")+"\n"+entry, strings.Join(lines[last+1:], "\n"))
+}
 
-	data, err := os.ReadFile(filePath)
+// errNoEntry makes RewriteLastNote fall back to appending.
+var errNoEntry = errors.New("no entry to rewrite")
+
+// RewriteLastNote replaces the text of the last entry in the daily note,
+// keeping its time header, and returns the replaced text so the change can be
+// undone. Without an entry to replace it appends and returns "".
+func (s *Service) RewriteLastNote(content string) (string, error) {
+	filePath, err := s.dailyNotePath(s.Now())
 	if err != nil {
-		return fmt.Errorf("failed to read daily note: %w", err)
+		return "", err
 	}
 
-	lines := strings.Split(string(data), "\n")
-	lastHeaderIdx := -1
-	for i := len(lines) - 1; i >= 0; i-- {
-		if strings.HasPrefix(lines[i], "## ") {
-			lastHeaderIdx = i
-			break
+	var previous string
+	err = vault.Update(filePath, func(old []byte) ([]byte, error) {
+		if old == nil {
+			return nil, errNoEntry
 		}
+		text, replaced, err := s.rewriteLast(string(old), content)
+		previous = replaced
+		return []byte(text), err
+	})
+	if errors.Is(err, errNoEntry) {
+		return "", s.AppendToDailyNote(content)
+	}
+	if err != nil {
+		return "", err
 	}
+	return previous, nil
+}
 
-	if lastHeaderIdx == -1 {
-		return s.AppendToDailyNote(content) // Fallback to append if no header found
+// rewriteLast replaces the text of the last entry of a note, returning the
+// new note and the replaced text, or errNoEntry.
+func (s *Service) rewriteLast(text, content string) (string, string, error) {
+	lines := strings.Split(text, "\n")
+	start, end := s.lastEntry(lines)
+	if start == -1 {
+		return "", "", errNoEntry
 	}
 
-	// Keep everything up to the last header, then write the new entry
-	newLines := append(lines[:lastHeaderIdx], fmt.Sprintf("## %s\n%s\n", now.Format("15:04:05"), content))
-	return os.WriteFile(filePath, []byte(strings.Join(newLines, "\n")),
-		0644)
+	layout := s.fmt().layout
+	head, previous := layout.split(lines[start:end])
+
+	// Keep everything around the entry and its head, then write the new text
+	text = strings.Join(lines[:start], "\n")
+	if start > 0 {
+		text += "\n"
+	}
+	return attach(text+head+content+layout.tail, strings.Join(lines[end:], "\n")), previous, nil
 }
 
 // DeleteLastNote removes the last entry from the daily note.
 func (s *Service) DeleteLastNote() error {
-	now := s.Now()
-	fileName := fmt.Sprintf("%s%s.md", s.Prefix, now.Format("2006-01-02"))
-	filePath := filepath.Join(s.VaultPath, fileName)
-
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return fmt.Errorf("no daily note found")
-	}
-
-	data, err := os.ReadFile(filePath)
+	filePath, err := s.dailyNotePath(s.Now())
 	if err != nil {
-		return fmt.Errorf("failed to read daily note: %w", err)
+		return err
 	}
 
-	lines := strings.Split(string(data), "\n")
-	lastHeaderIdx := -1
-	for i := len(lines) - 1; i >= 0; i-- {
-		if strings.HasPrefix(lines[i], "## ") {
-			lastHeaderIdx = i
-			break
+	return vault.Update(filePath, func(old []byte) ([]byte, error) {
+		if old == nil {
+			return nil, fmt.Errorf("no daily note found")
 		}
-	}
+		text, err := s.deleteLast(string(old))
+		return []byte(text), err
+	})
+}
 
-	if lastHeaderIdx == -1 {
-		return fmt.Errorf("no entries to delete")
+// deleteLast removes the last entry of a note.
+func (s *Service) deleteLast(text string) (string, error) {
+	lines := strings.Split(text, "\n")
+	start, end := s.lastEntry(lines)
+	if start == -1 {
+		return "", fmt.Errorf("no entries to delete")
 	}
 
-	// Keep everything up to the last header (excluding it)
-	newLines := lines[:lastHeaderIdx]
+	// Keep everything before the last entry
+	newLines := lines[:start]
 
 	// Remove trailing empty lines
 	for len(newLines) > 0 && strings.TrimSpace(newLines[len(newLines)-1]) == "" {
 		newLines = newLines[:len(newLines)-1]
 	}
 
-	// Add a final newline
+	// End the file the way an entry ends, so the next one lines up
 	content := strings.Join(newLines, "\n")
 	if content != "" {
-		content += "\n\n"
+		content += s.fmt().layout.ending()
+	}
+	return attach(content, strings.Join(lines[end:], "\n")), nil
+}
+
+// dailyNotePath returns the path of the daily note for the given day.
+func (s *Service) dailyNotePath(now time.Time) (string, error) {
+	rel, err := execute(s.fmt().path, s.data(now, "", ""))
+	if err != nil {
+		return "", fmt.Errorf("failed to render note path: %w", err)
+	}
+	rel = strings.TrimSpace(rel)
+	if rel == "" || filepath.IsAbs(rel) || strings.HasPrefix(filepath.Clean(rel), "..") {
+		return "", fmt.Errorf("note path %q must be relative to the vault", rel)
+	}
+	return filepath.Join(s.VaultPath, rel), nil
+}
+
+// newNoteHeader renders the header of a new note from the template file or
+// the header template. Templater tags like <% %> are left for the plugin.
+func (s *Service) newNoteHeader(now time.Time, filePath string) (string, error) {
+	header := s.fmt().header
+	if s.HeaderFile != "" {
+		data, err := os.ReadFile(filepath.Join(s.VaultPath, s.HeaderFile))
+		if err != nil {
+			return "", fmt.Errorf("failed to read note template: %w", err)
+		}
+		if header, err = parse("header file", string(data)); err != nil {
+			return "", err
+		}
+	}
+
+	title := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	out, err := execute(header, s.data(now, title, ""))
+	if err != nil {
+		return "", fmt.Errorf("failed to render note header: %w", err)
+	}
+	if out != "" && !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+	return out, nil
+}
+
+func (s *Service) renderEntry(now time.Time, content string) (string, error) {
+	out, err := execute(s.fmt().entry, s.data(now, "", content))
+	if err != nil {
+		return "", fmt.Errorf("failed to render entry: %w", err)
+	}
+	return out, nil
+}
+
+func (s *Service) data(now time.Time, title, content string) TemplateData {
+	return TemplateData{Date: now, Time: now, Prefix: s.Prefix, Title: title, Content: content}
+}
+
+// lastEntry returns the lines [start, end) of the last entry, or -1, -1.
+func (s *Service) lastEntry(lines []string) (int, int) {
+	from, to, ok := s.entryArea(lines)
+	if !ok {
+		return -1, -1
+	}
+	delimiter := s.fmt().delimiter
+	for i := to - 1; i >= from; i-- {
+		if strings.HasPrefix(lines[i], delimiter) {
+			return i, to
+		}
+	}
+	return -1, -1
+}
+
+// entryArea returns the lines [from, to) entries are kept in. Frontmatter is
+// skipped so YAML lists can't be mistaken for entries, and with a section
+// only its body counts. ok is false when the section is missing.
+func (s *Service) entryArea(lines []string) (from, to int, ok bool) {
+	from, to = bodyStart(lines), len(lines)
+	if section := s.fmt().section; section != "" {
+		start, end := findSection(lines, section, from)
+		if start < 0 {
+			return 0, 0, false
+		}
+		from, to = start+1, end
+	}
+	return from, to, true
+}
+
+// findSection returns the heading line of the section and the line where the
+// next heading of the same or a higher level starts, or -1 if it's missing.
+func findSection(lines []string, heading string, from int) (int, int) {
+	level := headingLevel(heading)
+	for i := from; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) != heading {
+			continue
+		}
+		for j := i + 1; j < len(lines); j++ {
+			if l := headingLevel(lines[j]); l > 0 && l <= level {
+				return i, j
+			}
+		}
+		return i, len(lines)
+	}
+	return -1, -1
+}
+
+// headingLevel returns the level of a Markdown heading line, or 0.
+func headingLevel(line string) int {
+	n := 0
+	for n < len(line) && line[n] == '#' {
+		n++
+	}
+	if n == 0 || n > 6 || n == len(line) || line[n] != ' ' {
+		return 0
+	}
+	return n
+}
+
+// attach appends the rest of a note, keeping one blank line before it.
+func attach(text, rest string) string {
+	rest = strings.TrimLeft(rest, "\n")
+	if rest == "" {
+		return text
+	}
+	if text == "" {
+		return rest
+	}
+	return strings.TrimRight(text, "\n") + "\n\n" + rest
+}
+
+// bodyStart returns the first line after the frontmatter.
+func bodyStart(lines []string) int {
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return 0
 	}
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "---" {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// entryLayout describes where the content sits in a rendered entry, so the
+// text of an existing entry can be replaced without touching its time.
+type entryLayout struct {
+	headLines  int    // whole lines before the content, e.g. "## 12:00:00"
+	inlineHead int    // runes before the content on its first line, e.g. "- 12:00 "
+	tail       string // text after the content, e.g. "\n\n"
+}
+
+// analyseEntry renders the entry template with a marker as content.
+// The inline head is assumed to have a fixed width, as time layouts do.
+func analyseEntry(entry *template.Template, delimiter string) (entryLayout, error) {
+	out, err := execute(entry, TemplateData{Date: time.Now(), Time: time.Now(), Content: contentMarker})
+	if err != nil {
+		return entryLayout{}, fmt.Errorf("failed to render entry template: %w", err)
+	}
+	if !strings.HasPrefix(out, delimiter) {
+		return entryLayout{}, fmt.Errorf("entry template must start with the entry delimiter %q", delimiter)
+	}
+	i := strings.Index(out, contentMarker)
+	if i < 0 {
+		return entryLayout{}, fmt.Errorf("entry template must contain {{.Content}}")
+	}
+
+	head := out[:i]
+	inline := head[strings.LastIndex(head, "\n")+1:]
+	return entryLayout{
+		headLines:  strings.Count(head, "\n"),
+		inlineHead: utf8.RuneCountInString(inline),
+		tail:       out[i+len(contentMarker):],
+	}, nil
+}
+
+// split separates an entry into its head and its trimmed content.
+func (l entryLayout) split(entry []string) (head, content string) {
+	if len(entry) <= l.headLines {
+		return strings.Join(entry, "\n") + "\n", ""
+	}
+	head = strings.Join(entry[:l.headLines], "\n")
+	if l.headLines > 0 {
+		head += "\n"
+	}
+
+	first := []rune(entry[l.headLines])
+	n := min(l.inlineHead, len(first))
+	head += string(first[:n])
+
+	rest := append([]string{string(first[n:])}, entry[l.headLines+1:]...)
+	return head, strings.TrimSpace(strings.Join(rest, "\n"))
+}
 
-	return os.WriteFile(filePath, []byte(content), 0644)
+// ending returns the line breaks an entry ends with, at least one.
+func (l entryLayout) ending() string {
+	ending := l.tail[len(strings.TrimRight(l.tail, "\n")):]
+	if ending == "" {
+		return "\n"
+	}
+	return ending
+}
+
+// parse compiles a template with Obsidian-style date, time and title helpers.
+// The helpers are bound to the note data when executed.
+func parse(name, text string) (*template.Template, error) {
+	t, err := template.New(name).Funcs(helpers(TemplateData{})).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	return t, nil
+}
+
+func execute(t *template.Template, data TemplateData) (string, error) {
+	t, err := t.Clone()
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Funcs(helpers(data)).Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func helpers(data TemplateData) template.FuncMap {
+	format := func(def string) func(...string) string {
+		return func(layout ...string) string {
+			if len(layout) > 0 {
+				return data.Date.Format(layout[0])
+			}
+			return data.Date.Format(def)
+		}
+	}
+	return template.FuncMap{
+		"date":  format("2006-01-02"),
+		"time":  format("15:04"),
+		"title": func() string { return data.Title },
+	}
+}
+
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
 }
@@ -1,9 +1,11 @@
 package obsidian
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -77,3 +79,277 @@ func TestAppendToDailyNoteError(t *testing.T) {
 		t.Error("expected error for non-existent path, got nil")
 	}
 }
+
+func TestRewriteLastNote(t *testing.T) {
+	tempDir := t.TempDir()
+	now := time.Date(2026, 1, 19, 12, 0, 0, 0, time.UTC)
+	service := &Service{VaultPath: tempDir, Now: func() time.Time { return now }}
+
+	service.AppendToDailyNote("первая")
+	service.AppendToDailyNote("вторая")
+
+	now = now.Add(time.Hour)
+	previous, err := service.RewriteLastNote("исправленная")
+	if err != nil {
+		t.Fatalf("RewriteLastNote failed: %v", err)
+	}
+	if previous != "вторая" {
+		t.Errorf("expected the replaced text, got %q", previous)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(tempDir, "2026-01-19.md"))
+	content := string(data)
+	if !strings.Contains(content, "## 12:00:00\nпервая\n\n## 12:00:00\nисправленная\n\n") || strings.Contains(content, "вторая") {
+		t.Errorf("unexpected note after rewrite:\n%s", content)
+	}
+
+	// Rewriting back with the returned text restores the note exactly
+	if _, err := service.RewriteLastNote(previous); err != nil {
+		t.Fatalf("RewriteLastNote failed: %v", err)
+	}
+	restored, _ := os.ReadFile(filepath.Join(tempDir, "2026-01-19.md"))
+	if !strings.HasSuffix(string(restored), "## 12:00:00\nвторая\n\n") {
+		t.Errorf("undo did not restore the note:\n%s", restored)
+	}
+}
+
+func TestTemplatedNote(t *testing.T) {
+	vault := t.TempDir()
+	now := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	service, err := NewWithOptions(vault, "", Options{
+		PathTemplate:   `Daily/{{.Date.Format "2006/01"}}/{{date}}.md`,
+		HeaderTemplate: "# {{title}}\n\n",
+		EntryTemplate:  "- {{time}} {{.Content}}\n",
+		EntryDelimiter: "- ",
+	})
+	if err != nil {
+		t.Fatalf("NewWithOptions failed: %v", err)
+	}
+	service.Now = func() time.Time { return now }
+
+	service.AppendToDailyNote("первая")
+	now = now.Add(5 * time.Minute)
+	service.AppendToDailyNote("вторая")
+
+	path := filepath.Join(vault, "Daily", "2026", "10", "2026-10-16.md")
+	read := func() string {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("note not created at %s: %v", path, err)
+		}
+		return string(data)
+	}
+	if got, want := read(), "# 2026-10-16\n\n- 09:30 первая\n- 09:35 вторая\n"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	now = now.Add(time.Hour)
+	previous, err := service.RewriteLastNote("исправленная")
+	if err != nil || previous != "вторая" {
+		t.Fatalf("RewriteLastNote returned %q, %v", previous, err)
+	}
+	if got, want := read(), "# 2026-10-16\n\n- 09:30 первая\n- 09:35 исправленная\n"; got != want {
+		t.Errorf("rewrite should keep the entry time: expected %q, got %q", want, got)
+	}
+
+	if err := service.DeleteLastNote(); err != nil {
+		t.Fatalf("DeleteLastNote failed: %v", err)
+	}
+	if got, want := read(), "# 2026-10-16\n\n- 09:30 первая\n"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestHeaderFile(t *testing.T) {
+	vault := t.TempDir()
+	os.MkdirAll(filepath.Join(vault, "Templates"), 0755)
+	tmpl := "---\ncreated: <% tp.date.now() %>\n---\n# {{title}}\n"
+	os.WriteFile(filepath.Join(vault, "Templates", "Daily.md"), []byte(tmpl), 0644)
+
+	service, err := NewWithOptions(vault, "", Options{HeaderFile: "Templates/Daily.md"})
+	if err != nil {
+		t.Fatalf("NewWithOptions failed: %v", err)
+	}
+	service.Now = func() time.Time { return time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC) }
+	service.AppendToDailyNote("тест")
+
+	data, _ := os.ReadFile(filepath.Join(vault, "2026-10-16.md"))
+	want := "---\ncreated: <% tp.date.now() %>\n---\n# 2026-10-16\n## 09:30:00\nтест\n\n"
+	if string(data) != want {
+		t.Errorf("expected %q, got %q", want, data)
+	}
+
+	// YAML lists in the frontmatter are never taken for entries
+	service, _ = NewWithOptions(vault, "", Options{EntryTemplate: "- {{.Content}}\n", EntryDelimiter: "- "})
+	service.Now = func() time.Time { return time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC) }
+	os.WriteFile(filepath.Join(vault, "2026-10-17.md"), []byte("---\ntags:\n- inbox\n---\n"), 0644)
+	if err := service.DeleteLastNote(); err == nil {
+		t.Error("expected no entries to delete")
+	}
+}
+
+func TestSection(t *testing.T) {
+	vault := t.TempDir()
+	now := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	service, err := NewWithOptions(vault, "", Options{
+		PathTemplate:   "{{date}}.md",
+		EntryTemplate:  "- {{time}} {{.Content}}\n",
+		EntryDelimiter: "- ",
+		Section:        "## Inbox",
+	})
+	if err != nil {
+		t.Fatalf("NewWithOptions failed: %v", err)
+	}
+	service.Now = func() time.Time { return now }
+
+	path := filepath.Join(vault, "2026-10-16.md")
+	read := func() string {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read note: %v", err)
+		}
+		return string(data)
+	}
+
+	t.Run("created when missing", func(t *testing.T) {
+		os.WriteFile(path, []byte("# Планы\n- [ ] купить хлеб\n"), 0644)
+		service.AppendToDailyNote("первая")
+		if got, want := read(), "# Планы\n- [ ] купить хлеб\n\n## Inbox\n- 09:30 первая\n"; got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	})
+
+	t.Run("keeps content after it", func(t *testing.T) {
+		os.WriteFile(path, []byte("## Inbox\n- 09:00 первая\n\n## Итоги\n- было хорошо\n"), 0644)
+		service.AppendToDailyNote("вторая")
+		want := "## Inbox\n- 09:00 первая\n- 09:30 вторая\n\n## Итоги\n- было хорошо\n"
+		if got := read(); got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+
+		previous, err := service.RewriteLastNote("исправленная")
+		if err != nil || previous != "вторая" {
+			t.Fatalf("RewriteLastNote returned %q, %v", previous, err)
+		}
+		want = "## Inbox\n- 09:00 первая\n- 09:30 исправленная\n\n## Итоги\n- было хорошо\n"
+		if got := read(); got != want {
+			t.Errorf("rewrite should stay inside the section: expected %q, got %q", want, got)
+		}
+
+		if err := service.DeleteLastNote(); err != nil {
+			t.Fatalf("DeleteLastNote failed: %v", err)
+		}
+		if got, want := read(), "## Inbox\n- 09:00 первая\n\n## Итоги\n- было хорошо\n"; got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	})
+
+	t.Run("entries outside are ignored", func(t *testing.T) {
+		os.WriteFile(path, []byte("## Inbox\n\n## Итоги\n- было хорошо\n"), 0644)
+		if err := service.DeleteLastNote(); err == nil {
+			t.Error("entries outside the section should not be deleted")
+		}
+	})
+}
+
+func TestInvalidOptions(t *testing.T) {
+	tests := []Options{
+		{PathTemplate: "{{.Nope"},
+		{EntryTemplate: "{{.Content}}\n"},                  // doesn't start with the delimiter
+		{EntryTemplate: "## {{.Time.Format \"15:04\"}}\n"}, // no content
+		{Section: "Inbox"},                                 // not a heading
+		{Section: "## Inbox"},                              // taken for an entry with the default delimiter
+		{Section: "### Inbox"},                             // closed by the default "## " entries
+		{Section: "## Inbox", EntryDelimiter: "# ", EntryTemplate: "# {{.Content}}\n"}, // closed by shallower entries
+	}
+	for _, opts := range tests {
+		if _, err := NewWithOptions(t.TempDir(), "", opts); err == nil {
+			t.Errorf("expected an error for %+v", opts)
+		}
+	}
+
+	if _, err := NewWithOptions(t.TempDir(), "", Options{Section: "### Inbox"}); err == nil || !strings.Contains(err.Error(), `"## "`) {
+		t.Errorf("expected the entry delimiter to be named, got %v", err)
+	}
+	nested := Options{Section: "## Inbox", EntryDelimiter: "### ", EntryTemplate: "### {{.Content}}\n"}
+	if _, err := NewWithOptions(t.TempDir(), "", nested); err != nil {
+		t.Errorf("entries nested in the section should be accepted, got %v", err)
+	}
+
+	service, _ := NewWithOptions(t.TempDir(), "", Options{PathTemplate: "../outside.md"})
+	if err := service.AppendToDailyNote("тест"); err == nil {
+		t.Error("a note path outside the vault should be rejected")
+	}
+}
+
+func TestConcurrentWriters(t *testing.T) {
+	vault := t.TempDir()
+	now := func() time.Time { return time.Date(2026, 10, 18, 9, 0, 0, 0, time.Local) }
+	// Two services stand in for the voice and clipboard paths
+	services := []*Service{New(vault, ""), New(vault, "")}
+	for _, s := range services {
+		s.Now = now
+	}
+	path := filepath.Join(vault, "2026-10-18.md")
+
+	done := make(chan struct{})
+	readerDone := make(chan struct{})
+	go func() {
+		defer close(readerDone)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			// A reader like Obsidian never sees a half-written note
+			if data, err := os.ReadFile(path); err == nil && !strings.HasPrefix(string(data), "---\n") {
+				t.Errorf("partially written note: %q", data)
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := services[i%2].AppendToDailyNote(fmt.Sprintf("заметка %d", i)); err != nil {
+				t.Errorf("append failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(done)
+	<-readerDone
+
+	data, _ := os.ReadFile(path)
+	text := string(data)
+	if strings.Count(text, "source: Bobik") != 1 {
+		t.Errorf("the header should be written once:\n%s", text)
+	}
+	for i := range 20 {
+		if !strings.Contains(text, fmt.Sprintf("заметка %d\n", i)) {
+			t.Errorf("entry %d was lost", i)
+		}
+	}
+
+	// Rewrites and deletes racing with appends keep the note consistent
+	for i := range 10 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			services[0].RewriteLastNote(fmt.Sprintf("исправлено %d", i))
+		}()
+		go func() {
+			defer wg.Done()
+			services[1].DeleteLastNote()
+		}()
+	}
+	wg.Wait()
+	data, _ = os.ReadFile(path)
+	if n := strings.Count(string(data), "## 09:00:00\n"); n != 10 {
+		t.Errorf("expected 10 entries after 10 deletes, got %d:\n%s", n, data)
+	}
+}
@@ -0,0 +1,266 @@
+package obsidian
+
+import (
+	"fmt"
+	"hey-bobik/internal/ru"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxReadDays bounds how many daily notes one read or search opens.
+const maxReadDays = 366
+
+// Entry is one piece of a note read back from the vault: a daily note entry,
+// or a paragraph of any other note found by a search.
+type Entry struct {
+	Date    time.Time // day of the daily note, or when another note was modified
+	Heading string    // entry header without the delimiter, usually its time
+	Content string
+	File    string // path relative to the vault
+}
+
+// Entries returns the entries of the daily notes from one day to another,
+// inclusive, oldest first. Days without a note are skipped.
+func (s *Service) Entries(from, to time.Time) ([]Entry, error) {
+	var entries []Entry
+	for _, d := range days(from, to) {
+		found, err := s.dayEntries(d)
+		if err != nil {
+			return entries, err
+		}
+		entries = append(entries, found...)
+	}
+	return entries, nil
+}
+
+// Search returns note pieces containing every word of the query, compared
+// by stem so "релиз" finds "релиза". With from and to set it only looks at
+// daily notes of those days, otherwise at every note in the vault. An empty
+// query matches everything.
+func (s *Service) Search(query string, from, to time.Time) ([]Entry, error) {
+	words := searchWords(query)
+
+	var candidates []Entry
+	var err error
+	if !from.IsZero() || !to.IsZero() {
+		candidates, err = s.Entries(from, to)
+	} else {
+		candidates, err = s.vaultParagraphs()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var found []Entry
+	for _, e := range candidates {
+		if containsAll(e.Heading+" "+e.Content, words) {
+			found = append(found, e)
+		}
+	}
+	return found, nil
+}
+
+func (s *Service) dayEntries(day time.Time) ([]Entry, error) {
+	filePath, err := s.dailyNotePath(day)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read daily note: %w", err)
+	}
+	rel, _ := filepath.Rel(s.VaultPath, filePath)
+	return s.parseEntries(strings.Split(string(data), "\n"), day, rel), nil
+}
+
+// parseEntries splits the lines of a daily note into its entries.
+func (s *Service) parseEntries(lines []string, day time.Time, rel string) []Entry {
+	from, to, ok := s.entryArea(lines)
+	if !ok {
+		return nil
+	}
+
+	f := s.fmt()
+	var entries []Entry
+	start := -1
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		head, content := f.layout.split(lines[start:end])
+		entries = append(entries, Entry{
+			Date:    day,
+			Heading: strings.TrimSpace(strings.TrimPrefix(head, f.delimiter)),
+			Content: content,
+			File:    rel,
+		})
+	}
+	for i := from; i < to; i++ {
+		if strings.HasPrefix(lines[i], f.delimiter) {
+			flush(i)
+			start = i
+		}
+	}
+	flush(to)
+	return entries
+}
+
+// vaultParagraphs splits every note into paragraphs, skipping frontmatter and
+// hidden folders such as .obsidian and .trash. Notes with a date in the name
+// are taken for daily notes and split into their entries.
+func (s *Service) vaultParagraphs() ([]Entry, error) {
+	var entries []Entry
+	err := filepath.WalkDir(s.VaultPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != s.VaultPath && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(d.Name(), ".md") {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(s.VaultPath, path)
+
+		lines := strings.Split(string(data), "\n")
+		if day, err := time.ParseInLocation("2006-01-02", isoInName.FindString(d.Name()), time.Local); err == nil {
+			if found := s.parseEntries(lines, day, rel); len(found) > 0 {
+				entries = append(entries, found...)
+				return nil
+			}
+		}
+
+		body := strings.Join(lines[bodyStart(lines):], "\n")
+		for _, p := range strings.Split(body, "\n\n") {
+			if p = strings.TrimSpace(p); p != "" {
+				entries = append(entries, Entry{Date: info.ModTime(), Content: p, File: rel})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault: %w", err)
+	}
+	return entries, nil
+}
+
+// days lists the days from one date to another, inclusive and bounded.
+func days(from, to time.Time) []time.Time {
+	from = startOfDay(from)
+	to = startOfDay(to)
+	if to.Before(from) {
+		from, to = to, from
+	}
+	var out []time.Time
+	for d := from; !d.After(to) && len(out) < maxReadDays; d = d.AddDate(0, 0, 1) {
+		out = append(out, d)
+	}
+	return out
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+var (
+	isoDate   = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	isoInName = regexp.MustCompile(`\d{4}-\d{2}-\d{2}`)
+	agoPeriod = regexp.MustCompile(`^(\d+) (дн|недел|месяц)`)
+)
+
+// ParsePeriod reads a past period like "today", "yesterday", "week",
+// "month", "2026-10-16", "вчера", "позавчера", "3 дня" or "16 октября" and
+// returns its first and last day. Dates without a year are in the past.
+func ParsePeriod(text string, now time.Time) (from, to time.Time, ok bool) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	today := startOfDay(now)
+
+	switch text {
+	case "", "today", "сегодня":
+		return today, today, true
+	case "yesterday", "вчера":
+		d := today.AddDate(0, 0, -1)
+		return d, d, true
+	case "позавчера":
+		d := today.AddDate(0, 0, -2)
+		return d, d, true
+	case "week", "неделя", "неделю", "за неделю":
+		return today.AddDate(0, 0, -6), today, true
+	case "month", "месяц", "за месяц":
+		return today.AddDate(0, -1, 1), today, true
+	}
+
+	if isoDate.MatchString(text) {
+		d, err := time.ParseInLocation("2006-01-02", text, now.Location())
+		return d, d, err == nil
+	}
+
+	text = strings.TrimPrefix(text, "за ")
+	if m := agoPeriod.FindStringSubmatch(text); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		switch m[2] {
+		case "дн":
+			return today.AddDate(0, 0, 1-n), today, n > 0
+		case "недел":
+			return today.AddDate(0, 0, 1-7*n), today, n > 0
+		case "месяц":
+			return today.AddDate(0, -n, 1), today, n > 0
+		}
+	}
+
+	if fields := strings.Fields(text); len(fields) == 2 {
+		n, err := strconv.Atoi(fields[0])
+		month, found := ru.ParseMonth(fields[1])
+		if err == nil && found && n >= 1 && n <= 31 {
+			d := time.Date(today.Year(), month, n, 0, 0, 0, 0, today.Location())
+			if d.After(today) {
+				d = d.AddDate(-1, 0, 0)
+			}
+			return d, d, true
+		}
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+func searchWords(query string) []string {
+	var words []string
+	for _, w := range strings.Fields(query) {
+		w = strings.Trim(strings.ToLower(w), ".,;:!?\"«»()")
+		if len([]rune(w)) > 1 {
+			words = append(words, ru.Fold(w))
+		}
+	}
+	return words
+}
+
+// containsAll reports whether every word appears in text, allowing a
+// different Russian ending on words of five letters or more.
+func containsAll(text string, words []string) bool {
+	text = ru.Fold(text)
+	for _, w := range words {
+		if !strings.Contains(text, ru.Stem(w)) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+package obsidian
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEntries(t *testing.T) {
+	vault := t.TempDir()
+	now := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+	service := New(vault, "")
+	service.Now = func() time.Time { return now }
+
+	service.AppendToDailyNote("созвон с подрядчиком\nобсудили смету")
+	now = now.Add(time.Hour)
+	service.AppendToDailyNote("купить хлеб")
+	now = now.AddDate(0, 0, 1)
+	service.AppendToDailyNote("релиз 1.2 вышел")
+
+	entries, err := service.Entries(now.AddDate(0, 0, -1), now)
+	if err != nil {
+		t.Fatalf("Entries failed: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %+v", entries)
+	}
+	first := entries[0]
+	if first.Heading != "09:30:00" || first.Content != "созвон с подрядчиком\nобсудили смету" || first.File != "2026-10-17.md" {
+		t.Errorf("unexpected entry %+v", first)
+	}
+	if entries[2].Content != "релиз 1.2 вышел" || !entries[2].Date.Equal(startOfDay(now)) {
+		t.Errorf("unexpected entry %+v", entries[2])
+	}
+
+	// A day without a note has no entries
+	if entries, err := service.Entries(now.AddDate(0, 0, 5), now.AddDate(0, 0, 5)); err != nil || len(entries) != 0 {
+		t.Errorf("expected no entries, got %v, %v", entries, err)
+	}
+}
+
+func TestEntriesInSection(t *testing.T) {
+	vault := t.TempDir()
+	service, _ := NewWithOptions(vault, "", Options{
+		PathTemplate:   "{{date}}.md",
+		EntryTemplate:  "- {{time}} {{.Content}}\n",
+		EntryDelimiter: "- ",
+		Section:        "## Inbox",
+	})
+	os.WriteFile(filepath.Join(vault, "2026-10-17.md"),
+		[]byte("# Планы\n- [ ] план\n\n## Inbox\n- 09:00 первая\n- 10:00 вторая\n\n## Итоги\n- итог\n"), 0644)
+
+	entries, err := service.Entries(time.Date(2026, 10, 17, 0, 0, 0, 0, time.Local), time.Date(2026, 10, 17, 0, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatalf("Entries failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Heading != "09:00" || entries[1].Content != "вторая" {
+		t.Errorf("unexpected entries %+v", entries)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	vault := t.TempDir()
+	os.MkdirAll(filepath.Join(vault, "Проекты"), 0755)
+	os.MkdirAll(filepath.Join(vault, ".trash"), 0755)
+	os.WriteFile(filepath.Join(vault, "Проекты", "Бобик.md"),
+		[]byte("---\ntags: [релиз]\n---\nПлан релиза на пятницу.\n\nПочинить микрофон.\n"), 0644)
+	os.WriteFile(filepath.Join(vault, ".trash", "old.md"), []byte("старый релиз\n"), 0644)
+	os.WriteFile(filepath.Join(vault, "2026-10-16.md"), []byte("## 10:00:00\nРелиз отложили\n\n"), 0644)
+
+	service := New(vault, "")
+	found, err := service.Search("релиз", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(found) != 2 {
+		t.Fatalf("expected 2 matches, got %+v", found)
+	}
+	for _, e := range found {
+		if e.File == filepath.Join("Проекты", "Бобик.md") && e.Content != "План релиза на пятницу." {
+			t.Errorf("unexpected paragraph %q", e.Content)
+		}
+	}
+
+	day := time.Date(2026, 10, 16, 0, 0, 0, 0, time.Local)
+	found, err = service.Search("релиз", day.AddDate(0, 0, -1), day)
+	if err != nil || len(found) != 1 || found[0].Content != "Релиз отложили" || found[0].Heading != "10:00:00" {
+		t.Errorf("unexpected daily note matches %+v, %v", found, err)
+	}
+
+	if found, _ := service.Search("микрофон пятница", time.Time{}, time.Time{}); len(found) != 0 {
+		t.Errorf("all words should match in one paragraph, got %+v", found)
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	now := time.Date(2026, 10, 18, 15, 0, 0, 0, time.Local)
+	tests := []struct {
+		text     string
+		from, to string
+	}{
+		{"today", "2026-10-18", "2026-10-18"},
+		{"вчера", "2026-10-17", "2026-10-17"},
+		{"позавчера", "2026-10-16", "2026-10-16"},
+		{"week", "2026-10-12", "2026-10-18"},
+		{"за 3 дня", "2026-10-16", "2026-10-18"},
+		{"2 недели", "2026-10-05", "2026-10-18"},
+		{"2026-10-01", "2026-10-01", "2026-10-01"},
+		{"16 октября", "2026-10-16", "2026-10-16"},
+		{"20 октября", "2025-10-20", "2025-10-20"},
+	}
+	for _, tt := range tests {
+		from, to, ok := ParsePeriod(tt.text, now)
+		if !ok || from.Format("2006-01-02") != tt.from || to.Format("2006-01-02") != tt.to {
+			t.Errorf("%q: expected %s..%s, got %s..%s (%v)", tt.text, tt.from, tt.to,
+				from.Format("2006-01-02"), to.Format("2006-01-02"), ok)
+		}
+	}
+	if _, _, ok := ParsePeriod("когда-нибудь", now); ok {
+		t.Error("expected an unknown period to fail")
+	}
+}
@@ -0,0 +1,235 @@
+package tasks
+
+import (
+	"hey-bobik/internal/ru"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dates are the due and scheduled dates found in a dictated task.
+// A zero time means the date wasn't mentioned.
+type Dates struct {
+	Due       time.Time
+	Scheduled time.Time
+}
+
+// weekdays lists the forms used after "в", "к" and "до".
+var weekdays = map[string]time.Weekday{
+	"понедельник": time.Monday, "понедельнику": time.Monday, "понедельника": time.Monday,
+	"вторник": time.Tuesday, "вторнику": time.Tuesday, "вторника": time.Tuesday,
+	"среда": time.Wednesday, "среду": time.Wednesday, "среде": time.Wednesday, "среды": time.Wednesday,
+	"четверг": time.Thursday, "четвергу": time.Thursday, "четверга": time.Thursday,
+	"пятница": time.Friday, "пятницу": time.Friday, "пятнице": time.Friday, "пятницы": time.Friday,
+	"суббота": time.Saturday, "субботу": time.Saturday, "субботе": time.Saturday, "субботы": time.Saturday,
+	"воскресенье": time.Sunday, "воскресенью": time.Sunday, "воскресенья": time.Sunday,
+}
+
+// counts are spoken numbers in "через два дня".
+var counts = map[string]int{
+	"один": 1, "одну": 1, "одна": 1, "пару": 2, "два": 2, "две": 2, "три": 3,
+	"четыре": 4, "пять": 5, "шесть": 6, "семь": 7, "восемь": 8, "девять": 9, "десять": 10,
+}
+
+// ordinals are spoken days of the month in "семнадцатого октября".
+var ordinals = map[string]int{
+	"первого": 1, "второго": 2, "третьего": 3, "четвёртого": 4, "четвертого": 4, "пятого": 5,
+	"шестого": 6, "седьмого": 7, "восьмого": 8, "девятого": 9, "десятого": 10,
+	"одиннадцатого": 11, "двенадцатого": 12, "тринадцатого": 13, "четырнадцатого": 14,
+	"пятнадцатого": 15, "шестнадцатого": 16, "семнадцатого": 17, "восемнадцатого": 18,
+	"девятнадцатого": 19, "двадцатого": 20, "тридцатого": 30,
+}
+
+// ParseDates finds Russian relative dates like "завтра", "через неделю",
+// "в пятницу" or "17 октября" in text and returns the text without them.
+// A date after "к" or "до" is the due date, one after "на" in a task that
+// is being planned ("запланируй на понедельник") is the scheduled date, any
+// other date is due.
+func ParseDates(text string, now time.Time) (string, Dates) {
+	words := strings.Fields(text)
+	lower := make([]string, len(words))
+	for i, w := range words {
+		lower[i] = normalize(w)
+	}
+	planned := strings.Contains(strings.ToLower(text), "запланир")
+
+	var dates Dates
+	var kept []string
+	for i := 0; i < len(words); i++ {
+		prep := ""
+		start := i
+		switch lower[i] {
+		case "к", "ко", "до", "на":
+			prep = lower[i]
+			start = i + 1
+		}
+
+		date, n := parseDate(lower[start:], now)
+		if n == 0 {
+			kept = append(kept, words[i])
+			continue
+		}
+
+		if prep == "на" && planned {
+			dates.Scheduled = date
+		} else {
+			dates.Due = date
+		}
+		i = start + n - 1
+	}
+
+	rest := strings.Join(kept, " ")
+	return strings.Trim(rest, " ,.;:-"), dates
+}
+
+// parseDate reads one date expression at the start of words and returns it
+// with the number of words used, or 0 if there's none.
+func parseDate(words []string, now time.Time) (time.Time, int) {
+	if len(words) == 0 {
+		return time.Time{}, 0
+	}
+	today := day(now)
+
+	switch words[0] {
+	case "сегодня":
+		return today, 1
+	case "завтра":
+		return today.AddDate(0, 0, 1), 1
+	case "послезавтра":
+		return today.AddDate(0, 0, 2), 1
+	case "через":
+		return parseAfter(words[1:], today)
+	case "в", "во":
+		if date, n := parseWeekday(words[1:], today); n > 0 {
+			return date, n + 1
+		}
+		if len(words) > 1 && words[1] == "выходные" {
+			return nextWeekday(today, time.Saturday), 2
+		}
+		return time.Time{}, 0
+	case "следующей", "следующую":
+		if len(words) > 1 && words[1] == "неделе" {
+			// "на следующей неделе" is next Monday
+			return nextWeekday(today, time.Monday), 2
+		}
+	}
+
+	if date, n := parseWeekday(words, today); n > 0 {
+		return date, n
+	}
+	return parseDayOfMonth(words, today)
+}
+
+// parseAfter reads "[число] дней/недель/месяцев" after "через".
+func parseAfter(words []string, today time.Time) (time.Time, int) {
+	count, used := 1, 0
+	if len(words) > 0 {
+		if n, ok := number(words[0]); ok {
+			count, used = n, 1
+		}
+	}
+	if len(words) <= used {
+		return time.Time{}, 0
+	}
+
+	unit := words[used]
+	switch {
+	case strings.HasPrefix(unit, "ден"), strings.HasPrefix(unit, "дн"):
+		return today.AddDate(0, 0, count), used + 2
+	case strings.HasPrefix(unit, "недел"):
+		return today.AddDate(0, 0, 7*count), used + 2
+	case strings.HasPrefix(unit, "месяц"):
+		return today.AddDate(0, count, 0), used + 2
+	}
+	return time.Time{}, 0
+}
+
+// parseWeekday reads "[следующий] пятницу" as the nearest such day after today.
+func parseWeekday(words []string, today time.Time) (time.Time, int) {
+	next := false
+	if len(words) > 0 && strings.HasPrefix(words[0], "следующ") {
+		next = true
+		words = words[1:]
+	}
+	if len(words) == 0 {
+		return time.Time{}, 0
+	}
+	wd, ok := weekdays[words[0]]
+	if !ok {
+		return time.Time{}, 0
+	}
+
+	date := nextWeekday(today, wd)
+	if next && weekStart(date).Equal(weekStart(today)) {
+		// "в следующую пятницу" on a Monday means the Friday of next week
+		date = date.AddDate(0, 0, 7)
+	}
+	if next {
+		return date, 2
+	}
+	return date, 1
+}
+
+// parseDayOfMonth reads "17 октября" or "семнадцатого октября", moving past
+// dates to the next year.
+func parseDayOfMonth(words []string, today time.Time) (time.Time, int) {
+	d, used := 0, 0
+	switch {
+	case len(words) > 0 && ordinals[words[0]] > 0:
+		d, used = ordinals[words[0]], 1
+	case len(words) > 1 && (words[0] == "двадцать" || words[0] == "тридцать") && ordinals[words[1]] > 0 && ordinals[words[1]] < 10:
+		d, used = ordinals[words[1]]+20, 2
+		if words[0] == "тридцать" {
+			d += 10
+		}
+	case len(words) > 0:
+		if n, err := strconv.Atoi(words[0]); err == nil {
+			d, used = n, 1
+		}
+	}
+	if d < 1 || d > 31 || len(words) <= used {
+		return time.Time{}, 0
+	}
+	m, ok := ru.ParseMonth(words[used])
+	if !ok {
+		return time.Time{}, 0
+	}
+
+	date := time.Date(today.Year(), m, d, 0, 0, 0, 0, today.Location())
+	if date.Before(today) {
+		date = date.AddDate(1, 0, 0)
+	}
+	return date, used + 1
+}
+
+func number(word string) (int, bool) {
+	if n, err := strconv.Atoi(word); err == nil && n > 0 {
+		return n, true
+	}
+	n, ok := counts[word]
+	return n, ok
+}
+
+// nextWeekday returns the first day after today falling on wd.
+func nextWeekday(today time.Time, wd time.Weekday) time.Time {
+	diff := (int(wd) - int(today.Weekday()) + 7) % 7
+	if diff == 0 {
+		diff = 7
+	}
+	return today.AddDate(0, 0, diff)
+}
+
+// weekStart returns the Monday of the week containing t.
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return t.AddDate(0, 0, -offset)
+}
+
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// normalize lowercases a word and drops punctuation around it.
+func normalize(word string) string {
+	return strings.Trim(strings.ToLower(word), ".,;:!?\"«»()")
+}
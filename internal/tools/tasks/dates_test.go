@@ -0,0 +1,68 @@
+package tasks
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDates(t *testing.T) {
+	// Sunday
+	now := time.Date(2026, 10, 18, 15, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		text      string
+		rest      string
+		due       string
+		scheduled string
+	}{
+		{"купить молоко завтра", "купить молоко", "2026-10-19", ""},
+		{"сегодня позвонить маме", "позвонить маме", "2026-10-18", ""},
+		{"оплатить интернет послезавтра", "оплатить интернет", "2026-10-20", ""},
+		{"продлить полис через неделю", "продлить полис", "2026-10-25", ""},
+		{"заменить фильтр через два месяца", "заменить фильтр", "2026-12-18", ""},
+		{"сдать отчёт в пятницу", "сдать отчёт", "2026-10-23", ""},
+		{"сдать отчёт к пятнице", "сдать отчёт", "2026-10-23", ""},
+		{"сдать отчёт до среды", "сдать отчёт", "2026-10-21", ""},
+		{"сдать отчёт до 25 октября", "сдать отчёт", "2026-10-25", ""},
+		{"поздравить Лену семнадцатого октября", "поздравить Лену", "2027-10-17", ""},
+		{"встреча двадцать первого ноября", "встреча", "2026-11-21", ""},
+		{"созвон в следующий вторник", "созвон", "2026-10-20", ""},
+		{"разобрать гараж на следующей неделе", "разобрать гараж", "2026-10-19", ""},
+		{"запланируй на понедельник ремонт до 30 октября", "запланируй ремонт", "2026-10-30", "2026-10-19"},
+		{"положить на полку книгу", "положить на полку книгу", "", ""},
+		{"купить хлеб", "купить хлеб", "", ""},
+	}
+
+	for _, tt := range tests {
+		rest, dates := ParseDates(tt.text, now)
+		if rest != tt.rest {
+			t.Errorf("%q: expected text %q, got %q", tt.text, tt.rest, rest)
+		}
+		if got := format(dates.Due); got != tt.due {
+			t.Errorf("%q: expected due %q, got %q", tt.text, tt.due, got)
+		}
+		if got := format(dates.Scheduled); got != tt.scheduled {
+			t.Errorf("%q: expected scheduled %q, got %q", tt.text, tt.scheduled, got)
+		}
+	}
+}
+
+func TestNextWeekSameWeekday(t *testing.T) {
+	// On a Monday "в следующую пятницу" is the Friday of next week
+	monday := time.Date(2026, 10, 19, 9, 0, 0, 0, time.Local)
+	_, dates := ParseDates("в следующую пятницу", monday)
+	if got := format(dates.Due); got != "2026-10-30" {
+		t.Errorf("expected 2026-10-30, got %s", got)
+	}
+	_, dates = ParseDates("в пятницу", monday)
+	if got := format(dates.Due); got != "2026-10-23" {
+		t.Errorf("expected 2026-10-23, got %s", got)
+	}
+}
+
+func format(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(dateLayout)
+}
@@ -0,0 +1,340 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"hey-bobik/internal/ru"
+	"hey-bobik/internal/vault"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultFile is where new tasks go, relative to the vault.
+const DefaultFile = "Tasks.md"
+
+// Obsidian Tasks plugin signifiers.
+const (
+	dueSign       = "📅"
+	scheduledSign = "⏳"
+	doneSign      = "✅"
+	dateLayout    = "2006-01-02"
+)
+
+var (
+	// ErrNotFound means no open task matches the query.
+	ErrNotFound = errors.New("task not found")
+	// ErrAmbiguous means several open tasks match the query equally well.
+	ErrAmbiguous = errors.New("several tasks match")
+)
+
+var (
+	taskLine = regexp.MustCompile(`^(\s*[-*] )\[([ xX])\] (.*)$`)
+	doneDate = regexp.MustCompile(`\s*` + doneSign + `\s*\d{4}-\d{2}-\d{2}`)
+	dateSign = regexp.MustCompile(`\s*(` + dueSign + `|` + scheduledSign + `|` + doneSign + `)\s*(\d{4}-\d{2}-\d{2})`)
+)
+
+// Task is one checkbox line in the vault.
+type Task struct {
+	Text      string    // description without the date fields
+	Due       time.Time // zero when there's no 📅
+	Scheduled time.Time // zero when there's no ⏳
+	Done      bool
+	File      string // path relative to the vault
+	Line      int    // 0-based line number
+	Raw       string // the whole line as written
+}
+
+// Service writes and finds tasks in Obsidian Tasks format across the vault.
+type Service struct {
+	VaultPath string
+	File      string // new tasks are appended here, relative to the vault
+	Now       func() time.Time
+}
+
+// New creates a service appending new tasks to file, DefaultFile if empty.
+func New(vaultPath, file string) *Service {
+	if file == "" {
+		file = DefaultFile
+	}
+	return &Service{VaultPath: vaultPath, File: file, Now: time.Now}
+}
+
+// Add parses the dates out of a dictated task and appends it as an open
+// checkbox, e.g. "- [ ] купить молоко 📅 2026-10-17".
+func (s *Service) Add(text string) (Task, error) {
+	rest, dates := ParseDates(text, s.Now())
+	if rest == "" {
+		return Task{}, fmt.Errorf("empty task")
+	}
+	task := Task{Text: rest, Due: dates.Due, Scheduled: dates.Scheduled, File: s.File}
+	task.Raw = "- [ ] " + task.format()
+
+	if _, err := os.Stat(s.VaultPath); err != nil {
+		return Task{}, fmt.Errorf("vault not found: %w", err)
+	}
+	path, err := s.path(s.File)
+	if err != nil {
+		return Task{}, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return Task{}, fmt.Errorf("failed to create tasks folder: %w", err)
+	}
+
+	err = vault.Update(path, func(old []byte) ([]byte, error) {
+		text := string(old)
+		if text != "" && !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+		task.Line = strings.Count(text, "\n")
+		return []byte(text + task.Raw + "\n"), nil
+	})
+	if err != nil {
+		return Task{}, fmt.Errorf("failed to write task: %w", err)
+	}
+	return task, nil
+}
+
+// Today returns open tasks due or scheduled today or earlier, overdue first.
+func (s *Service) Today() ([]Task, error) {
+	all, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	end := day(s.Now()).AddDate(0, 0, 1)
+
+	var today []Task
+	for _, t := range all {
+		if !t.Done && !t.When().IsZero() && t.When().Before(end) {
+			today = append(today, t)
+		}
+	}
+	sort.SliceStable(today, func(i, j int) bool { return today[i].When().Before(today[j].When()) })
+	return today, nil
+}
+
+// List returns every task in the vault's Markdown files, skipping hidden
+// folders such as .obsidian and .trash.
+func (s *Service) List() ([]Task, error) {
+	var all []Task
+	err := filepath.WalkDir(s.VaultPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != s.VaultPath && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(d.Name(), ".md") {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(s.VaultPath, path)
+		for i, line := range strings.Split(string(data), "\n") {
+			if t, ok := parseTask(line); ok {
+				t.File, t.Line = rel, i
+				all = append(all, t)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tasks: %w", err)
+	}
+	return all, nil
+}
+
+// Complete marks the open task best matching query as done with today's date.
+func (s *Service) Complete(query string) (Task, error) {
+	all, err := s.List()
+	if err != nil {
+		return Task{}, err
+	}
+	var open []Task
+	for _, t := range all {
+		if !t.Done {
+			open = append(open, t)
+		}
+	}
+	task, err := bestMatch(open, query)
+	if err != nil {
+		return task, err
+	}
+
+	m := taskLine.FindStringSubmatch(task.Raw)
+	done := fmt.Sprintf("%s[x] %s %s %s", m[1], m[3], doneSign, s.Now().Format(dateLayout))
+	if err := s.replaceLine(task, done); err != nil {
+		return Task{}, err
+	}
+	task.Done = true
+	task.Raw = done
+	return task, nil
+}
+
+// Reopen unchecks a completed task and drops its done date, e.g. to undo Complete.
+func (s *Service) Reopen(task Task) error {
+	m := taskLine.FindStringSubmatch(task.Raw)
+	if m == nil {
+		return ErrNotFound
+	}
+	text := doneDate.ReplaceAllString(m[3], "")
+	return s.replaceLine(task, m[1]+"[ ] "+text)
+}
+
+// Remove deletes a task line, e.g. to undo Add.
+func (s *Service) Remove(task Task) error {
+	return s.editLine(task, func(lines []string, i int) []string {
+		return append(lines[:i], lines[i+1:]...)
+	})
+}
+
+// replaceLine swaps the task's line for text.
+func (s *Service) replaceLine(task Task, text string) error {
+	return s.editLine(task, func(lines []string, i int) []string {
+		lines[i] = text
+		return lines
+	})
+}
+
+// editLine finds the task's line and rewrites the file with edit's result.
+func (s *Service) editLine(task Task, edit func(lines []string, i int) []string) error {
+	path, err := s.path(task.File)
+	if err != nil {
+		return err
+	}
+	return vault.Update(path, func(old []byte) ([]byte, error) {
+		if old == nil {
+			return nil, ErrNotFound
+		}
+		lines := strings.Split(string(old), "\n")
+		i := findLine(lines, task)
+		if i < 0 {
+			return nil, ErrNotFound
+		}
+		return []byte(strings.Join(edit(lines, i), "\n")), nil
+	})
+}
+
+// findLine locates the task, looking it up by text if the file has shifted
+// since it was read.
+func findLine(lines []string, task Task) int {
+	if task.Line < len(lines) && lines[task.Line] == task.Raw {
+		return task.Line
+	}
+	for i, line := range lines {
+		if line == task.Raw {
+			return i
+		}
+	}
+	return -1
+}
+
+// path resolves a vault-relative file, rejecting paths outside the vault.
+func (s *Service) path(rel string) (string, error) {
+	rel = filepath.Clean(rel)
+	if filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("tasks file %q is outside the vault", rel)
+	}
+	return filepath.Join(s.VaultPath, rel), nil
+}
+
+// When is the earliest of the scheduled and due dates, zero if there's none.
+func (t Task) When() time.Time {
+	if t.Due.IsZero() || (!t.Scheduled.IsZero() && t.Scheduled.Before(t.Due)) {
+		return t.Scheduled
+	}
+	return t.Due
+}
+
+// format renders the description with its dates in Tasks plugin order.
+func (t Task) format() string {
+	line := t.Text
+	if !t.Scheduled.IsZero() {
+		line += " " + scheduledSign + " " + t.Scheduled.Format(dateLayout)
+	}
+	if !t.Due.IsZero() {
+		line += " " + dueSign + " " + t.Due.Format(dateLayout)
+	}
+	return line
+}
+
+func parseTask(line string) (Task, bool) {
+	m := taskLine.FindStringSubmatch(line)
+	if m == nil {
+		return Task{}, false
+	}
+	t := Task{Raw: line, Done: m[2] != " "}
+	for _, d := range dateSign.FindAllStringSubmatch(m[3], -1) {
+		date, err := time.ParseInLocation(dateLayout, d[2], time.Local)
+		if err != nil {
+			continue
+		}
+		switch d[1] {
+		case dueSign:
+			t.Due = date
+		case scheduledSign:
+			t.Scheduled = date
+		}
+	}
+	t.Text = strings.TrimSpace(dateSign.ReplaceAllString(m[3], ""))
+	return t, true
+}
+
+// bestMatch picks the task sharing most words with the query. Russian
+// words are compared by their stems so "молоко" matches "молока".
+func bestMatch(tasks []Task, query string) (Task, error) {
+	want := words(query)
+	if len(want) == 0 {
+		return Task{}, ErrNotFound
+	}
+
+	best, bestScore, tie := -1, 0.0, false
+	for i, t := range tasks {
+		have := words(t.Text)
+		matched := 0
+		for _, w := range want {
+			for _, h := range have {
+				if ru.SameWord(w, h) {
+					matched++
+					break
+				}
+			}
+		}
+		score := float64(matched) / float64(len(want))
+		switch {
+		case score > bestScore:
+			best, bestScore, tie = i, score, false
+		case score == bestScore && score > 0:
+			tie = true
+		}
+	}
+
+	if best < 0 || bestScore < 0.5 {
+		return Task{}, ErrNotFound
+	}
+	if tie {
+		return tasks[best], ErrAmbiguous
+	}
+	return tasks[best], nil
+}
+
+func words(text string) []string {
+	var out []string
+	for _, w := range strings.Fields(text) {
+		w = ru.Fold(normalize(w))
+		if len([]rune(w)) > 1 {
+			out = append(out, w)
+		}
+	}
+	return out
+}
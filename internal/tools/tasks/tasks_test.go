@@ -0,0 +1,116 @@
+package tasks
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestService(t *testing.T) (*Service, string) {
+	vault := t.TempDir()
+	s := New(vault, "")
+	s.Now = func() time.Time { return time.Date(2026, 10, 18, 10, 0, 0, 0, time.Local) }
+	return s, vault
+}
+
+func TestAdd(t *testing.T) {
+	s, vault := newTestService(t)
+
+	if _, err := s.Add("купить молоко завтра"); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	task, err := s.Add("запланируй на понедельник ремонт")
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if task.Line != 1 || task.Scheduled.IsZero() {
+		t.Errorf("unexpected task %+v", task)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(vault, DefaultFile))
+	want := "- [ ] купить молоко 📅 2026-10-19\n- [ ] запланируй ремонт ⏳ 2026-10-19\n"
+	if string(data) != want {
+		t.Errorf("expected %q, got %q", want, data)
+	}
+
+	if err := s.Remove(task); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	data, _ = os.ReadFile(filepath.Join(vault, DefaultFile))
+	if string(data) != "- [ ] купить молоко 📅 2026-10-19\n" {
+		t.Errorf("task was not removed: %q", data)
+	}
+
+	if _, err := New(filepath.Join(vault, "missing"), "").Add("купить хлеб"); err == nil {
+		t.Error("expected an error for a missing vault")
+	}
+	if _, err := New(vault, "../Tasks.md").Add("купить хлеб"); err == nil {
+		t.Error("a tasks file outside the vault should be rejected")
+	}
+}
+
+func TestToday(t *testing.T) {
+	s, vault := newTestService(t)
+	os.MkdirAll(filepath.Join(vault, "Daily"), 0755)
+	os.MkdirAll(filepath.Join(vault, ".trash"), 0755)
+	os.WriteFile(filepath.Join(vault, "Daily", "2026-10-18.md"), []byte(strings.Join([]string{
+		"## 09:00",
+		"- [ ] позвонить маме ⏳ 2026-10-18",
+		"- [ ] оплатить интернет 📅 2026-10-16",
+		"- [x] купить хлеб 📅 2026-10-18 ✅ 2026-10-18",
+		"- [ ] съездить на дачу 📅 2026-10-25",
+		"- [ ] почитать книгу",
+	}, "\n")), 0644)
+	os.WriteFile(filepath.Join(vault, ".trash", "old.md"), []byte("- [ ] удалённая 📅 2026-10-18\n"), 0644)
+
+	today, err := s.Today()
+	if err != nil {
+		t.Fatalf("Today failed: %v", err)
+	}
+	var got []string
+	for _, task := range today {
+		got = append(got, task.Text)
+	}
+	if want := "оплатить интернет;позвонить маме"; strings.Join(got, ";") != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	s, vault := newTestService(t)
+	path := filepath.Join(vault, DefaultFile)
+	os.WriteFile(path, []byte("# Задачи\n- [ ] купить молока 📅 2026-10-19\n- [ ] позвонить маме\n"), 0644)
+
+	task, err := s.Complete("купил молоко")
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if task.Text != "купить молока" {
+		t.Errorf("matched the wrong task: %+v", task)
+	}
+	data, _ := os.ReadFile(path)
+	want := "# Задачи\n- [x] купить молока 📅 2026-10-19 ✅ 2026-10-18\n- [ ] позвонить маме\n"
+	if string(data) != want {
+		t.Errorf("expected %q, got %q", want, data)
+	}
+
+	if err := s.Reopen(task); err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	data, _ = os.ReadFile(path)
+	if want := "# Задачи\n- [ ] купить молока 📅 2026-10-19\n- [ ] позвонить маме\n"; string(data) != want {
+		t.Errorf("expected %q, got %q", want, data)
+	}
+
+	if _, err := s.Complete("помыть машину"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	os.WriteFile(filepath.Join(vault, "Work.md"), []byte("- [ ] позвонить врачу\n"), 0644)
+	if _, err := s.Complete("позвонить"); !errors.Is(err, ErrAmbiguous) {
+		t.Errorf("expected ErrAmbiguous, got %v", err)
+	}
+}
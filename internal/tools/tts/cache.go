@@ -0,0 +1,72 @@
+package tts
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Cache stores synthesized audio on disk so fixed phrases start instantly.
+// Each file holds the sample rate as a little-endian uint32 followed by the PCM samples.
+type Cache struct {
+	Dir string
+}
+
+// NewCache creates a new disk cache in dir.
+func NewCache(dir string) *Cache {
+	return &Cache{Dir: dir}
+}
+
+// Key derives a cache key from everything that affects the synthesized audio.
+func (c *Cache) Key(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:16])
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.Dir, key+".pcm")
+}
+
+// Load returns cached audio for key, if present.
+func (c *Cache) Load(key string) (*Audio, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil || len(data) < 4 {
+		return nil, false
+	}
+	return &Audio{
+		SampleRate: int(binary.LittleEndian.Uint32(data[:4])),
+		Samples:    bytesToSamples(data[4:]),
+	}, true
+}
+
+// Store writes audio to the cache under key.
+func (c *Cache) Store(key string, a *Audio) error {
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache dir: %w", err)
+	}
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, uint32(a.SampleRate))
+	binary.Write(&buf, binary.LittleEndian, a.Samples)
+
+	// Write to a temp file first so a concurrent Load never sees a partial file
+	tmp, err := os.CreateTemp(c.Dir, key+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create cache file: %w", err)
+	}
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	return os.Rename(tmp.Name(), c.path(key))
+}
@@ -0,0 +1,33 @@
+package tts
+
+import (
+	"testing"
+)
+
+func TestCacheRoundTrip(t *testing.T) {
+	c := NewCache(t.TempDir())
+	key := c.Key("piper", "Записал")
+
+	if _, ok := c.Load(key); ok {
+		t.Fatal("expected empty cache")
+	}
+
+	if err := c.Store(key, &Audio{SampleRate: 22050, Samples: []int16{1, -1, 32767}}); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+
+	audio, ok := c.Load(key)
+	if !ok {
+		t.Fatal("expected cached audio")
+	}
+	if audio.SampleRate != 22050 || len(audio.Samples) != 3 || audio.Samples[2] != 32767 {
+		t.Errorf("unexpected cached audio: %+v", audio)
+	}
+}
+
+func TestCacheKeyDiffers(t *testing.T) {
+	c := NewCache(t.TempDir())
+	if c.Key("espeak-ng", "Записал") == c.Key("piper", "Записал") {
+		t.Error("expected different keys for different engines")
+	}
+}
@@ -0,0 +1,248 @@
+package tts
+
+import (
+	"hey-bobik/internal/ru"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// unit describes how a measurement suffix is spoken after a number.
+type unit struct {
+	g              gender
+	one, few, many string // few doubles as the genitive singular used after fractions
+}
+
+var units = map[string]unit{
+	"%":    {masculine, "процент", "процента", "процентов"},
+	"км":   {masculine, "километр", "километра", "километров"},
+	"м":    {masculine, "метр", "метра", "метров"},
+	"см":   {masculine, "сантиметр", "сантиметра", "сантиметров"},
+	"мм":   {masculine, "миллиметр", "миллиметра", "миллиметров"},
+	"кг":   {masculine, "килограмм", "килограмма", "килограммов"},
+	"л":    {masculine, "литр", "литра", "литров"},
+	"₽":    {masculine, "рубль", "рубля", "рублей"},
+	"руб":  {masculine, "рубль", "рубля", "рублей"},
+	"$":    {masculine, "доллар", "доллара", "долларов"},
+	"€":    {neuter, "евро", "евро", "евро"},
+	"°C":   {masculine, "градус", "градуса", "градусов"},
+	"°":    {masculine, "градус", "градуса", "градусов"},
+	"ГБ":   {masculine, "гигабайт", "гигабайта", "гигабайт"},
+	"МБ":   {masculine, "мегабайт", "мегабайта", "мегабайт"},
+	"КБ":   {masculine, "килобайт", "килобайта", "килобайт"},
+	"ч":    {masculine, "час", "часа", "часов"},
+	"мин":  {feminine, "минута", "минуты", "минут"},
+	"сек":  {feminine, "секунда", "секунды", "секунд"},
+	"шт":   {feminine, "штука", "штуки", "штук"},
+	"руб.": {masculine, "рубль", "рубля", "рублей"},
+}
+
+var (
+	reCodeBlock  = regexp.MustCompile("(?s)```.*?```")
+	reInlineCode = regexp.MustCompile("`([^`]*)`")
+	reImage      = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	reLink       = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	reWikiLink   = regexp.MustCompile(`!?\[\[(?:[^\]|]*\|)?([^\]]*)\]\]`)
+	reHeading    = regexp.MustCompile(`(?m)^\s{0,3}#{1,6}\s+`)
+	reListMarker = regexp.MustCompile(`(?m)^\s*(?:[-*+]|\d+[.)])\s+(?:\[[ xX]\]\s+)?`)
+	reQuote      = regexp.MustCompile(`(?m)^\s*>\s?`)
+	reRule       = regexp.MustCompile(`(?m)^\s*(?:[-*_]\s*){3,}$`)
+	reEmphasis   = regexp.MustCompile(`(\*\*|__|~~|\*|_)([^*_~\n]+)(\*\*|__|~~|\*|_)`)
+	reURL        = regexp.MustCompile(`(?:https?://|www\.)\S+`)
+	reSpaces     = regexp.MustCompile(`[ \t]+`)
+
+	reDateDMY = regexp.MustCompile(`\b(\d{1,2})\.(\d{1,2})\.(\d{4})\b`)
+	reDateISO = regexp.MustCompile(`\b(\d{4})-(\d{2})-(\d{2})\b`)
+	reTime    = regexp.MustCompile(`\b(\d{1,2}):(\d{2})(?::(\d{2}))?\b`)
+	reNumber  = regexp.MustCompile(`(^|[^\p{L}\d.,:])(-?\d+)(?:[.,](\d+))?(?:(\s?)(%|°C|°|₽|\$|€|руб\.|[\p{L}]+))?`)
+	// reNumberRun is a separator and a digit right after a number, as in
+	// versions "1.2.3" and addresses, which are left for the voice to read.
+	reNumberRun = regexp.MustCompile(`^[.,:]\d`)
+)
+
+// Normalize rewrites text so a Russian voice reads it naturally: markdown and
+// URLs are removed, and dates, times, numbers and units are spelled out.
+// Numbers are read in the nominative case except years before "году" and
+// "года"; versions and tokens like "qwen3:8b" are kept as written.
+func Normalize(text string) string {
+	text = stripMarkdown(text)
+	text = reURL.ReplaceAllString(text, "")
+	text = reDateDMY.ReplaceAllStringFunc(text, func(m string) string {
+		p := reDateDMY.FindStringSubmatch(m)
+		return spellDate(m, p[3], p[2], p[1])
+	})
+	text = reDateISO.ReplaceAllStringFunc(text, func(m string) string {
+		p := reDateISO.FindStringSubmatch(m)
+		return spellDate(m, p[1], p[2], p[3])
+	})
+	text = reTime.ReplaceAllStringFunc(text, spellTime)
+	text = replaceNumbers(text)
+	text = reSpaces.ReplaceAllString(text, " ")
+	return strings.TrimSpace(text)
+}
+
+// stripMarkdown removes formatting and joins lines into sentences.
+func stripMarkdown(text string) string {
+	text = reCodeBlock.ReplaceAllString(text, "")
+	text = reInlineCode.ReplaceAllString(text, "$1")
+	text = reImage.ReplaceAllString(text, "$1")
+	text = reLink.ReplaceAllString(text, "$1")
+	text = reWikiLink.ReplaceAllString(text, "$1")
+	text = reRule.ReplaceAllString(text, "")
+	text = reHeading.ReplaceAllString(text, "")
+	text = reListMarker.ReplaceAllString(text, "")
+	text = reQuote.ReplaceAllString(text, "")
+	for reEmphasis.MatchString(text) {
+		text = reEmphasis.ReplaceAllString(text, "$2")
+	}
+	text = strings.ReplaceAll(text, "|", ", ")
+
+	// Headings and list items rarely end with punctuation, add a pause
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	for i := 0; i < len(lines)-1; i++ {
+		if r, _ := utf8.DecodeLastRuneInString(lines[i]); !strings.ContainsRune(".!?…:;,", r) {
+			lines[i] += "."
+		}
+	}
+	return strings.Join(lines, " ")
+}
+
+func spellDate(orig, year, month, day string) string {
+	y, _ := strconv.Atoi(year)
+	m, _ := strconv.Atoi(month)
+	d, _ := strconv.Atoi(day)
+	if m < 1 || m > 12 || d < 1 || d > 31 {
+		return orig
+	}
+	return dayOrdinal(d) + " " + ru.MonthGenitive(time.Month(m)) + " " + yearGenitive(y) + " года"
+}
+
+func spellTime(m string) string {
+	p := reTime.FindStringSubmatch(m)
+	h, _ := strconv.Atoi(p[1])
+	min, _ := strconv.Atoi(p[2])
+	if h > 23 || min > 59 {
+		return m
+	}
+
+	words := []string{
+		numberWords(int64(h), masculine), plural(int64(h), "час", "часа", "часов"),
+		numberWords(int64(min), feminine), plural(int64(min), "минута", "минуты", "минут"),
+	}
+	if p[3] != "" {
+		sec, _ := strconv.Atoi(p[3])
+		if sec > 59 {
+			return m
+		}
+		words = append(words, numberWords(int64(sec), feminine), plural(int64(sec), "секунда", "секунды", "секунд"))
+	}
+	return strings.Join(words, " ")
+}
+
+// replaceNumbers spells the numbers matched by reNumber, skipping those
+// followed by another separated number.
+func replaceNumbers(text string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range reNumber.FindAllStringIndex(text, -1) {
+		if reNumberRun.MatchString(text[loc[1]:]) {
+			continue
+		}
+		b.WriteString(text[last:loc[0]])
+		b.WriteString(spellNumber(text[loc[0]:loc[1]]))
+		last = loc[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+func spellNumber(m string) string {
+	p := reNumber.FindStringSubmatch(m)
+	prefix, intPart, fracPart, space, suffix := p[1], p[2], p[3], p[4], p[5]
+
+	n, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil {
+		return m
+	}
+
+	u, hasUnit := units[suffix]
+	tail := ""
+	if suffix != "" && !hasUnit {
+		if space == "" {
+			// Part of a token like "8b" or "mp3"
+			return m
+		}
+		// An ordinary word follows, keep it as is
+		tail = " " + suffix
+	}
+
+	if fracPart == "" && n >= 1000 && n < 3000 {
+		// "в 2026 году", "с 2020 года"
+		switch suffix {
+		case "году":
+			return prefix + yearPrepositional(int(n)) + tail
+		case "года":
+			return prefix + yearGenitive(int(n)) + tail
+		}
+	}
+
+	if fracPart != "" {
+		// 375.5 -> "триста семьдесят пять целых пять десятых"
+		frac, err := strconv.ParseInt(fracPart, 10, 64)
+		if err != nil || len(fracPart) > 3 {
+			return m
+		}
+		denominators := [][3]string{
+			{"десятая", "десятых", "десятых"},
+			{"сотая", "сотых", "сотых"},
+			{"тысячная", "тысячных", "тысячных"},
+		}
+		d := denominators[len(fracPart)-1]
+		words := numberWords(n, feminine) + " " + plural(n, "целая", "целых", "целых") + " " +
+			numberWords(frac, feminine) + " " + plural(frac, d[0], d[1], d[2])
+		if hasUnit {
+			words += " " + u.few
+		}
+		return prefix + words + tail
+	}
+
+	if hasUnit {
+		return prefix + numberWords(n, u.g) + " " + plural(n, u.one, u.few, u.many)
+	}
+	return prefix + numberWords(n, masculine) + tail
+}
+
+// TruncateSentences shortens text to at most maxRunes, cutting on a sentence
+// boundary when possible and on a word boundary otherwise.
+func TruncateSentences(text string, maxRunes int) string {
+	if maxRunes <= 0 || utf8.RuneCountInString(text) <= maxRunes {
+		return text
+	}
+
+	runes := []rune(text)[:maxRunes]
+	cut := -1
+	for i := len(runes) - 1; i > 0; i-- {
+		if strings.ContainsRune(".!?…", runes[i]) {
+			cut = i + 1
+			break
+		}
+	}
+	if cut > 0 {
+		return strings.TrimSpace(string(runes[:cut]))
+	}
+
+	// A single long sentence: stop at the last whole word
+	for i := len(runes) - 1; i > 0; i-- {
+		if runes[i] == ' ' {
+			return strings.TrimSpace(string(runes[:i]))
+		}
+	}
+	return string(runes)
+}
@@ -0,0 +1,108 @@
+package tts
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestNumberWords(t *testing.T) {
+	tests := []struct {
+		n    int64
+		g    gender
+		want string
+	}{
+		{0, masculine, "ноль"},
+		{1, feminine, "одна"},
+		{2, feminine, "две"},
+		{21, masculine, "двадцать один"},
+		{375, masculine, "триста семьдесят пять"},
+		{1000, masculine, "одна тысяча"},
+		{2500, masculine, "две тысячи пятьсот"},
+		{11000, masculine, "одиннадцать тысяч"},
+		{2000000, masculine, "два миллиона"},
+		{-5, masculine, "минус пять"},
+	}
+	for _, tt := range tests {
+		if got := numberWords(tt.n, tt.g); got != tt.want {
+			t.Errorf("numberWords(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestYearGenitive(t *testing.T) {
+	tests := map[int]string{
+		2026: "две тысячи двадцать шестого",
+		2000: "двухтысячного",
+		2010: "две тысячи десятого",
+		2030: "две тысячи тридцатого",
+		1999: "тысяча девятьсот девяносто девятого",
+		2100: "две тысячи сотого",
+	}
+	for year, want := range tests {
+		if got := yearGenitive(year); got != want {
+			t.Errorf("yearGenitive(%d) = %q, want %q", year, got, want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Сейчас 15:04", "Сейчас пятнадцать часов четыре минуты"},
+		{"Сейчас 1:01", "Сейчас один час одна минута"},
+		{"375.5", "триста семьдесят пять целых пять десятых"},
+		{"2,25 км", "две целых двадцать пять сотых километра"},
+		{"15%", "пятнадцать процентов"},
+		{"скидка 21 %", "скидка двадцать один процент"},
+		{"3 кг", "три килограмма"},
+		{"1 шт", "одна штука"},
+		{"17.10.2026", "семнадцатое октября две тысячи двадцать шестого года"},
+		{"2026-10-01", "первое октября две тысячи двадцать шестого года"},
+		{"купить 2 батона", "купить два батона"},
+		{"Записал", "Записал"},
+		{"версия 1.2.3", "версия 1.2.3"},
+		{"модель qwen3:8b", "модель qwen3:8b"},
+		{"сервер 192.168.1.10", "сервер 192.168.1.10"},
+		{"файл mp3", "файл mp3"},
+		{"в 2026 году", "в две тысячи двадцать шестом году"},
+		{"с 2020 года", "с две тысячи двадцатого года"},
+		{"в 2003 году", "в две тысячи третьем году"},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.in); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeMarkdown(t *testing.T) {
+	in := "# Заголовок\n\n- **Первый** пункт\n- второй [ссылка](https://example.com)\n\nСм. https://example.com/page и `код`."
+	got := Normalize(in)
+	want := "Заголовок. Первый пункт. второй ссылка. См. и код."
+	if got != want {
+		t.Errorf("Normalize markdown = %q, want %q", got, want)
+	}
+}
+
+func TestTruncateSentences(t *testing.T) {
+	text := "Первое предложение. Второе предложение! Третье предложение очень длинное."
+	got := TruncateSentences(text, 45)
+	if got != "Первое предложение. Второе предложение!" {
+		t.Errorf("unexpected truncation: %q", got)
+	}
+
+	long := strings.Repeat("слово ", 50)
+	got = TruncateSentences(long, 23)
+	if got != "слово слово слово" {
+		t.Errorf("expected cut on word boundary, got %q", got)
+	}
+	if !utf8.ValidString(got) {
+		t.Error("truncation produced invalid UTF-8")
+	}
+
+	if TruncateSentences("коротко", 0) != "коротко" {
+		t.Error("zero limit should keep text")
+	}
+}
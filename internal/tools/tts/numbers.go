@@ -0,0 +1,198 @@
+package tts
+
+import (
+	"strconv"
+	"strings"
+)
+
+// gender selects the grammatical gender of "один"/"два" forms.
+type gender int
+
+const (
+	masculine gender = iota
+	feminine
+	neuter
+)
+
+var (
+	unitsMasc = []string{"ноль", "один", "два", "три", "четыре", "пять", "шесть", "семь", "восемь", "девять"}
+	unitsFem  = []string{"ноль", "одна", "две", "три", "четыре", "пять", "шесть", "семь", "восемь", "девять"}
+	unitsNeut = []string{"ноль", "одно", "два", "три", "четыре", "пять", "шесть", "семь", "восемь", "девять"}
+	teens     = []string{"десять", "одиннадцать", "двенадцать", "тринадцать", "четырнадцать", "пятнадцать",
+		"шестнадцать", "семнадцать", "восемнадцать", "девятнадцать"}
+	tens     = []string{"", "", "двадцать", "тридцать", "сорок", "пятьдесят", "шестьдесят", "семьдесят", "восемьдесят", "девяносто"}
+	hundreds = []string{"", "сто", "двести", "триста", "четыреста", "пятьсот", "шестьсот", "семьсот", "восемьсот", "девятьсот"}
+
+	// Ordinal forms, neuter nominative ("первое") and genitive ("первого").
+	ordUnitsNeut = []string{"", "первое", "второе", "третье", "четвёртое", "пятое", "шестое", "седьмое", "восьмое", "девятое"}
+	ordTeensNeut = []string{"десятое", "одиннадцатое", "двенадцатое", "тринадцатое", "четырнадцатое", "пятнадцатое",
+		"шестнадцатое", "семнадцатое", "восемнадцатое", "девятнадцатое"}
+	ordTensNeut = []string{"", "", "двадцатое", "тридцатое"}
+
+	ordUnitsGen = []string{"", "первого", "второго", "третьего", "четвёртого", "пятого", "шестого", "седьмого", "восьмого", "девятого"}
+	ordTeensGen = []string{"десятого", "одиннадцатого", "двенадцатого", "тринадцатого", "четырнадцатого", "пятнадцатого",
+		"шестнадцатого", "семнадцатого", "восемнадцатого", "девятнадцатого"}
+	ordTensGen = []string{"", "", "двадцатого", "тридцатого", "сорокового", "пятидесятого", "шестидесятого",
+		"семидесятого", "восьмидесятого", "девяностого"}
+	ordHundredsGen = []string{"", "сотого", "двухсотого", "трёхсотого", "четырёхсотого", "пятисотого", "шестисотого",
+		"семисотого", "восьмисотого", "девятисотого"}
+)
+
+// plural picks the noun form agreeing with n: 1 минута, 2 минуты, 5 минут.
+func plural(n int64, one, few, many string) string {
+	n %= 100
+	if n >= 11 && n <= 14 {
+		return many
+	}
+	switch n % 10 {
+	case 1:
+		return one
+	case 2, 3, 4:
+		return few
+	}
+	return many
+}
+
+// triad spells 0-999 in words; zero yields no words.
+func triad(n int, g gender) []string {
+	var words []string
+	if h := n / 100; h > 0 {
+		words = append(words, hundreds[h])
+	}
+	n %= 100
+	switch {
+	case n >= 10 && n < 20:
+		words = append(words, teens[n-10])
+		return words
+	case n >= 20:
+		words = append(words, tens[n/10])
+		n %= 10
+	}
+	if n > 0 {
+		switch g {
+		case feminine:
+			words = append(words, unitsFem[n])
+		case neuter:
+			words = append(words, unitsNeut[n])
+		default:
+			words = append(words, unitsMasc[n])
+		}
+	}
+	return words
+}
+
+// scales are the named powers of a thousand with their gender and noun forms.
+var scales = []struct {
+	value          int64
+	g              gender
+	one, few, many string
+}{
+	{1_000_000_000, masculine, "миллиард", "миллиарда", "миллиардов"},
+	{1_000_000, masculine, "миллион", "миллиона", "миллионов"},
+	{1_000, feminine, "тысяча", "тысячи", "тысяч"},
+}
+
+// numberWords spells an integer in the nominative case with the given gender.
+func numberWords(n int64, g gender) string {
+	if n == 0 {
+		return "ноль"
+	}
+	var words []string
+	if n < 0 {
+		words = append(words, "минус")
+		n = -n
+	}
+	for _, s := range scales {
+		if n >= s.value {
+			count := n / s.value
+			if count > 999 {
+				// Beyond what we can name, read the digits one by one
+				return digitsWords(n)
+			}
+			words = append(words, triad(int(count), s.g)...)
+			words = append(words, plural(count, s.one, s.few, s.many))
+			n %= s.value
+		}
+	}
+	words = append(words, triad(int(n), g)...)
+	return strings.Join(words, " ")
+}
+
+// digitsWords reads a number digit by digit.
+func digitsWords(n int64) string {
+	var words []string
+	for _, d := range []byte(strconv.FormatInt(n, 10)) {
+		words = append(words, unitsMasc[d-'0'])
+	}
+	return strings.Join(words, " ")
+}
+
+// dayOrdinal spells a day of month as a neuter ordinal: "семнадцатое".
+func dayOrdinal(day int) string {
+	switch {
+	case day < 10:
+		return ordUnitsNeut[day]
+	case day < 20:
+		return ordTeensNeut[day-10]
+	case day%10 == 0:
+		return ordTensNeut[day/10]
+	}
+	return tens[day/10] + " " + ordUnitsNeut[day%10]
+}
+
+// yearGenitive spells a year as a genitive ordinal: "две тысячи двадцать шестого".
+func yearGenitive(year int) string {
+	thousands := year / 1000
+	rest := year % 1000
+	if rest == 0 {
+		switch thousands {
+		case 1:
+			return "тысячного"
+		case 2:
+			return "двухтысячного"
+		}
+		return numberWords(int64(year), masculine)
+	}
+
+	var words []string
+	switch {
+	case thousands == 1:
+		words = append(words, "тысяча")
+	case thousands > 1:
+		words = append(words, numberWords(int64(thousands*1000), masculine))
+	}
+	h, t, u := rest/100, rest%100/10, rest%10
+	switch {
+	case t == 0 && u == 0:
+		words = append(words, ordHundredsGen[h])
+	case t == 1:
+		if h > 0 {
+			words = append(words, hundreds[h])
+		}
+		words = append(words, ordTeensGen[u])
+	case u == 0:
+		if h > 0 {
+			words = append(words, hundreds[h])
+		}
+		words = append(words, ordTensGen[t])
+	default:
+		if h > 0 {
+			words = append(words, hundreds[h])
+		}
+		if t > 0 {
+			words = append(words, tens[t])
+		}
+		words = append(words, ordUnitsGen[u])
+	}
+	return strings.Join(words, " ")
+}
+
+// yearPrepositional spells a year as a prepositional ordinal: "две тысячи
+// двадцать шестом".
+func yearPrepositional(year int) string {
+	words := yearGenitive(year)
+	if stem, ok := strings.CutSuffix(words, "го"); ok {
+		return stem + "м"
+	}
+	return words
+}
@@ -0,0 +1,168 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const defaultPiperSampleRate = 22050
+
+// ErrRawUnsupported is returned when the configured engine cannot produce raw PCM.
+var ErrRawUnsupported = errors.New("tts engine does not support raw audio output")
+
+// Audio is a chunk of synthesized mono 16-bit PCM.
+type Audio struct {
+	SampleRate int
+	Samples    []int16
+}
+
+const (
+	engineEspeak = "espeak"
+	enginePiper  = "piper"
+)
+
+// engine returns the engine family of the configured command.
+func (s *Speaker) engine() string {
+	base := filepath.Base(s.Command)
+	switch {
+	case strings.Contains(base, "espeak"):
+		return engineEspeak
+	case strings.Contains(base, "piper"):
+		return enginePiper
+	}
+	return ""
+}
+
+// Synthesize renders text to PCM without playing it.
+func (s *Speaker) Synthesize(ctx context.Context, text string) (*Audio, error) {
+	switch s.engine() {
+	case engineEspeak:
+		args := append(append([]string{}, s.Args...), "--stdout", text)
+		out, err := exec.CommandContext(ctx, s.Command, args...).Output()
+		if err != nil {
+			return nil, fmt.Errorf("espeak synthesis failed: %w", err)
+		}
+		return parseWAV(out)
+
+	case enginePiper:
+		args := append(append([]string{}, s.Args...), "--output_raw")
+		cmd := exec.CommandContext(ctx, s.Command, args...)
+		cmd.Stdin = strings.NewReader(text)
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("piper synthesis failed: %w", err)
+		}
+		return &Audio{
+			SampleRate: s.piperSampleRate(),
+			Samples:    bytesToSamples(out),
+		}, nil
+	}
+
+	return nil, ErrRawUnsupported
+}
+
+// piperSampleRate reads the sample rate from the model's .onnx.json config
+// that piper keeps next to the model file.
+func (s *Speaker) piperSampleRate() int {
+	model := argValue(s.Args, "--model", "-m")
+	if model == "" {
+		return defaultPiperSampleRate
+	}
+
+	data, err := os.ReadFile(model + ".json")
+	if err != nil {
+		return defaultPiperSampleRate
+	}
+
+	var modelCfg struct {
+		Audio struct {
+			SampleRate int `json:"sample_rate"`
+		} `json:"audio"`
+	}
+	if err := json.Unmarshal(data, &modelCfg); err != nil || modelCfg.Audio.SampleRate == 0 {
+		return defaultPiperSampleRate
+	}
+	return modelCfg.Audio.SampleRate
+}
+
+// argValue returns the value following any of the given flags.
+func argValue(args []string, flags ...string) string {
+	for i := 0; i < len(args)-1; i++ {
+		for _, f := range flags {
+			if args[i] == f {
+				return args[i+1]
+			}
+		}
+	}
+	return ""
+}
+
+// parseWAV extracts mono 16-bit PCM from a RIFF/WAVE stream.
+// Only the first channel is kept for multi-channel input.
+func parseWAV(data []byte) (*Audio, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("not a WAV stream")
+	}
+
+	var sampleRate, channels, bits int
+	pos := 12
+	for pos+8 <= len(data) {
+		id := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		body := pos + 8
+
+		switch id {
+		case "fmt ":
+			if body+16 > len(data) {
+				return nil, fmt.Errorf("truncated fmt chunk")
+			}
+			if format := binary.LittleEndian.Uint16(data[body:]); format != 1 {
+				return nil, fmt.Errorf("unsupported WAV format %d", format)
+			}
+			channels = int(binary.LittleEndian.Uint16(data[body+2:]))
+			sampleRate = int(binary.LittleEndian.Uint32(data[body+4:]))
+			bits = int(binary.LittleEndian.Uint16(data[body+14:]))
+
+		case "data":
+			if sampleRate == 0 {
+				return nil, fmt.Errorf("data chunk before fmt chunk")
+			}
+			if bits != 16 {
+				return nil, fmt.Errorf("unsupported bit depth %d", bits)
+			}
+			// Engines writing to a pipe cannot seek back to fix up the size
+			end := body + size
+			if size == 0 || end > len(data) || end < body {
+				end = len(data)
+			}
+			samples := bytesToSamples(data[body:end])
+			if channels > 1 {
+				mono := make([]int16, 0, len(samples)/channels)
+				for i := 0; i+channels <= len(samples); i += channels {
+					mono = append(mono, samples[i])
+				}
+				samples = mono
+			}
+			return &Audio{SampleRate: sampleRate, Samples: samples}, nil
+		}
+
+		pos = body + size + size%2
+	}
+
+	return nil, fmt.Errorf("no data chunk in WAV stream")
+}
+
+// bytesToSamples converts little-endian 16-bit PCM bytes to samples.
+func bytesToSamples(data []byte) []int16 {
+	samples := make([]int16, len(data)/2)
+	binary.Read(bytes.NewReader(data[:len(samples)*2]), binary.LittleEndian, samples)
+	return samples
+}
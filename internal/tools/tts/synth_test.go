@@ -0,0 +1,96 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func makeWAV(sampleRate, channels int, samples []int16, dataSize uint32) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(0))
+	buf.WriteString("WAVE")
+	buf.WriteString("fmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(16))
+	binary.Write(&buf, binary.LittleEndian, uint16(1))
+	binary.Write(&buf, binary.LittleEndian, uint16(channels))
+	binary.Write(&buf, binary.LittleEndian, uint32(sampleRate))
+	binary.Write(&buf, binary.LittleEndian, uint32(sampleRate*channels*2))
+	binary.Write(&buf, binary.LittleEndian, uint16(channels*2))
+	binary.Write(&buf, binary.LittleEndian, uint16(16))
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, dataSize)
+	binary.Write(&buf, binary.LittleEndian, samples)
+	return buf.Bytes()
+}
+
+func TestParseWAV(t *testing.T) {
+	samples := []int16{1, -2, 300, -400}
+	audio, err := parseWAV(makeWAV(22050, 1, samples, uint32(len(samples)*2)))
+	if err != nil {
+		t.Fatalf("parseWAV failed: %v", err)
+	}
+	if audio.SampleRate != 22050 {
+		t.Errorf("expected sample rate 22050, got %d", audio.SampleRate)
+	}
+	if len(audio.Samples) != 4 || audio.Samples[2] != 300 {
+		t.Errorf("unexpected samples: %v", audio.Samples)
+	}
+}
+
+func TestParseWAVStreamedSize(t *testing.T) {
+	// espeak writing to a pipe leaves a placeholder data size
+	samples := []int16{5, 6, 7}
+	audio, err := parseWAV(makeWAV(16000, 1, samples, 0xffffffff))
+	if err != nil {
+		t.Fatalf("parseWAV failed: %v", err)
+	}
+	if len(audio.Samples) != 3 {
+		t.Errorf("expected 3 samples, got %d", len(audio.Samples))
+	}
+}
+
+func TestParseWAVStereo(t *testing.T) {
+	audio, err := parseWAV(makeWAV(8000, 2, []int16{1, 9, 2, 9, 3, 9}, 12))
+	if err != nil {
+		t.Fatalf("parseWAV failed: %v", err)
+	}
+	if len(audio.Samples) != 3 || audio.Samples[0] != 1 || audio.Samples[2] != 3 {
+		t.Errorf("expected first channel only, got %v", audio.Samples)
+	}
+}
+
+func TestParseWAVInvalid(t *testing.T) {
+	if _, err := parseWAV([]byte("not a wav file")); err == nil {
+		t.Error("expected error for invalid WAV")
+	}
+}
+
+func TestSynthesizeUnsupported(t *testing.T) {
+	s := New(true, "festival")
+	if _, err := s.Synthesize(context.Background(), "test"); err != ErrRawUnsupported {
+		t.Errorf("expected ErrRawUnsupported, got %v", err)
+	}
+}
+
+func TestPiperSampleRate(t *testing.T) {
+	dir := t.TempDir()
+	model := filepath.Join(dir, "voice.onnx")
+	if err := os.WriteFile(model+".json", []byte(`{"audio":{"sample_rate":16000}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Speaker{Command: "piper", Args: []string{"--model", model}}
+	if rate := s.piperSampleRate(); rate != 16000 {
+		t.Errorf("expected 16000, got %d", rate)
+	}
+
+	s.Args = []string{"--model", filepath.Join(dir, "missing.onnx")}
+	if rate := s.piperSampleRate(); rate != defaultPiperSampleRate {
+		t.Errorf("expected default rate, got %d", rate)
+	}
+}
@@ -2,37 +2,144 @@ package tts
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"hey-bobik/internal/logger"
+	"math"
+	"os"
 	"os/exec"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 )
 
+var log = logger.New("tts")
+
+// Player plays synthesized PCM on an output device.
+type Player interface {
+	Play(ctx context.Context, samples []int16, sampleRate int) error
+}
+
 // Speaker handles text-to-speech output.
 type Speaker struct {
 	Enabled bool
 	Command string // e.g., "espeak-ng", "piper", "festival"
 	Args    []string
+	Volume  float64 // output gain, 0 is treated as 1.0
+	// MaxLength caps spoken text in runes after normalization, 0 is unlimited.
+	MaxLength int
+
+	// Player plays audio in-process. When nil, the engine speaks by itself.
+	Player Player
+	// Cache stores audio for CachePhrases. Optional.
+	Cache        *Cache
+	CachePhrases []string
+
+	mu    sync.Mutex // serializes playback so phrases don't overlap
+	once  sync.Once
+	queue chan utterance
+}
+
+// utterance is a phrase waiting in the SpeakAsync queue.
+type utterance struct {
+	ctx  context.Context
+	text string
+	done chan<- error // receives the result for SpeakAndWait, nil for SpeakAsync
+}
+
+// speakQueueSize bounds phrases waiting to be spoken; streamed answers enqueue one per sentence.
+const speakQueueSize = 32
+
+// Options tune the voice of the TTS engine.
+type Options struct {
+	Voice     string   // espeak voice name or piper model path
+	Rate      float64  // speaking rate multiplier, 1.0 is the engine default
+	Pitch     int      // espeak pitch 0-99, 0 keeps the engine default (ignored by piper)
+	Volume    float64  // output gain, 1.0 is unchanged
+	ExtraArgs []string // appended verbatim to the engine arguments
 }
 
+const (
+	defaultEspeakVoice = "ru"
+	defaultPiperModel  = "ru_RU-dmitri-medium"
+	espeakDefaultWPM   = 175
+)
+
 // New creates a new TTS speaker.
 func New(enabled bool, command string) *Speaker {
+	return NewWithOptions(enabled, command, Options{})
+}
+
+// NewWithOptions creates a TTS speaker with voice settings.
+func NewWithOptions(enabled bool, command string, opts Options) *Speaker {
 	args := []string{}
 
 	// Default arguments for common TTS engines
 	switch {
 	case strings.Contains(command, "espeak"):
-		args = []string{"-v", "ru"} // Russian voice
+		voice := opts.Voice
+		if voice == "" {
+			voice = defaultEspeakVoice // Russian voice
+		}
+		args = []string{"-v", voice}
+		if opts.Rate > 0 && opts.Rate != 1 {
+			args = append(args, "-s", strconv.Itoa(int(espeakDefaultWPM*opts.Rate)))
+		}
+		if opts.Pitch > 0 {
+			args = append(args, "-p", strconv.Itoa(opts.Pitch))
+		}
 	case strings.Contains(command, "piper"):
-		args = []string{"--model", "ru_RU-dmitri-medium"}
+		model := opts.Voice
+		if model == "" {
+			model = defaultPiperModel
+		}
+		args = []string{"--model", model}
+		if opts.Rate > 0 && opts.Rate != 1 {
+			// piper stretches phoneme length, so a faster rate is a smaller scale
+			args = append(args, "--length_scale", strconv.FormatFloat(1/opts.Rate, 'f', 2, 64))
+		}
+	}
+	args = append(args, opts.ExtraArgs...)
+
+	volume := opts.Volume
+	if volume <= 0 {
+		volume = 1
 	}
 
 	return &Speaker{
 		Enabled: enabled,
 		Command: command,
 		Args:    args,
+		Volume:  volume,
 	}
 }
 
+// Validate checks that the configured voice settings can work.
+func (s *Speaker) Validate() error {
+	if s.Volume < 0 || s.Volume > 2 {
+		return fmt.Errorf("volume %.2f out of range 0-2", s.Volume)
+	}
+	if p := argValue(s.Args, "-p"); p != "" && s.engine() == engineEspeak {
+		if v, err := strconv.Atoi(p); err != nil || v < 0 || v > 99 {
+			return fmt.Errorf("espeak pitch %q out of range 0-99", p)
+		}
+	}
+	if s.engine() == enginePiper {
+		model := argValue(s.Args, "--model", "-m")
+		if model == "" {
+			return fmt.Errorf("piper model not configured")
+		}
+		// A bare voice name is resolved by piper itself, only paths are checked
+		if strings.ContainsRune(model, os.PathSeparator) || strings.HasSuffix(model, ".onnx") {
+			if _, err := os.Stat(model); err != nil {
+				return fmt.Errorf("piper model %s: %w", model, err)
+			}
+		}
+	}
+	return nil
+}
+
 // Speak synthesizes and plays the given text.
 func (s *Speaker) Speak(ctx context.Context, text string) error {
 	if !s.Enabled {
@@ -43,18 +150,155 @@ func (s *Speaker) Speak(ctx context.Context, text string) error {
 		return fmt.Errorf("TTS command not configured")
 	}
 
-	args := append(s.Args, text)
+	text = TruncateSentences(Normalize(text), s.MaxLength)
+	if text == "" {
+		return nil
+	}
+
+	if s.Player == nil {
+		return s.runCommand(ctx, text)
+	}
+
+	audio, err := s.audioFor(ctx, text)
+	if errors.Is(err, ErrRawUnsupported) {
+		return s.runCommand(ctx, text)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Player.Play(ctx, applyGain(audio.Samples, s.Volume), audio.SampleRate)
+}
+
+// applyGain scales samples by gain, clipping to the int16 range.
+func applyGain(samples []int16, gain float64) []int16 {
+	if gain <= 0 || gain == 1 {
+		return samples
+	}
+	out := make([]int16, len(samples))
+	for i, v := range samples {
+		scaled := float64(v) * gain
+		switch {
+		case scaled > math.MaxInt16:
+			scaled = math.MaxInt16
+		case scaled < math.MinInt16:
+			scaled = math.MinInt16
+		}
+		out[i] = int16(scaled)
+	}
+	return out
+}
+
+// runCommand lets the engine play the text itself.
+func (s *Speaker) runCommand(ctx context.Context, text string) error {
+	args := append([]string{}, s.Args...)
+	if s.engine() == engineEspeak && s.Volume > 0 && s.Volume != 1 {
+		args = append(args, "-a", strconv.Itoa(int(100*s.Volume)))
+	}
+	args = append(args, text)
 	cmd := exec.CommandContext(ctx, s.Command, args...)
 
 	return cmd.Run()
 }
 
-// SpeakAsync synthesizes and plays text in the background.
+// audioFor returns synthesized audio for text, using the cache for fixed phrases.
+func (s *Speaker) audioFor(ctx context.Context, text string) (*Audio, error) {
+	if s.Cache == nil || !slices.Contains(s.CachePhrases, text) {
+		return s.Synthesize(ctx, text)
+	}
+
+	key := s.cacheKey(text)
+	if audio, ok := s.Cache.Load(key); ok {
+		return audio, nil
+	}
+
+	audio, err := s.Synthesize(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Cache.Store(key, audio); err != nil {
+		log.Warn("Failed to cache phrase %q: %v", text, err)
+	}
+	return audio, nil
+}
+
+func (s *Speaker) cacheKey(text string) string {
+	return s.Cache.Key(append([]string{s.Command, text}, s.Args...)...)
+}
+
+// Prewarm synthesizes all cache phrases that are not cached yet.
+func (s *Speaker) Prewarm(ctx context.Context) {
+	if !s.Enabled || s.Cache == nil || s.engine() == "" {
+		return
+	}
+	for _, phrase := range s.CachePhrases {
+		if _, ok := s.Cache.Load(s.cacheKey(phrase)); ok {
+			continue
+		}
+		if _, err := s.audioFor(ctx, phrase); err != nil {
+			log.Warn("Failed to prewarm phrase %q: %v", phrase, err)
+		}
+	}
+}
+
+// SpeakAsync synthesizes and plays text in the background. Phrases are
+// spoken one after another in the order they were queued.
 func (s *Speaker) SpeakAsync(ctx context.Context, text string) {
 	if !s.Enabled {
 		return
 	}
-	go s.Speak(ctx, text)
+	s.startQueue()
+	select {
+	case s.queue <- utterance{ctx: ctx, text: text}:
+	default:
+		log.Warn("TTS queue is full, dropping %q", text)
+	}
+}
+
+// SpeakAndWait queues text after the phrases already waiting and returns
+// once it has been played, so the microphone no longer hears it.
+func (s *Speaker) SpeakAndWait(ctx context.Context, text string) error {
+	if !s.Enabled {
+		return nil
+	}
+	s.startQueue()
+	done := make(chan error, 1)
+	select {
+	case s.queue <- utterance{ctx: ctx, text: text, done: done}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Speaker) startQueue() {
+	s.once.Do(func() {
+		s.queue = make(chan utterance, speakQueueSize)
+		go s.speakQueued()
+	})
+}
+
+// speakQueued plays queued phrases until the process exits.
+func (s *Speaker) speakQueued() {
+	for u := range s.queue {
+		err := u.ctx.Err()
+		if err == nil {
+			err = s.Speak(u.ctx, u.text)
+			if err != nil {
+				log.Debug("Speak failed: %v", err)
+			}
+		}
+		if u.done != nil {
+			u.done <- err
+		}
+	}
 }
 
 // IsAvailable checks if the TTS engine is installed.
@@ -2,7 +2,11 @@ package tts
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestSpeakerDisabled(t *testing.T) {
@@ -64,3 +68,191 @@ func TestDefaultArgs(t *testing.T) {
 		t.Error("piper should have --model args")
 	}
 }
+
+type mockPlayer struct {
+	plays      int
+	sampleRate int
+	samples    []int16
+}
+
+func (m *mockPlayer) Play(ctx context.Context, samples []int16, sampleRate int) error {
+	m.plays++
+	m.samples = samples
+	m.sampleRate = sampleRate
+	return nil
+}
+
+// fakePiper writes a script that emits two raw samples and counts its runs.
+func fakePiper(t *testing.T) (command, counter string) {
+	dir := t.TempDir()
+	command = filepath.Join(dir, "piper")
+	counter = filepath.Join(dir, "runs")
+	script := "#!/bin/sh\necho run >> " + counter + "\nprintf '\\001\\000\\002\\000'\n"
+	if err := os.WriteFile(command, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return command, counter
+}
+
+func TestSpeakWithPlayer(t *testing.T) {
+	command, _ := fakePiper(t)
+	player := &mockPlayer{}
+	s := New(true, command)
+	s.Player = player
+
+	if err := s.Speak(context.Background(), "привет"); err != nil {
+		t.Fatalf("Speak failed: %v", err)
+	}
+	if player.plays != 1 {
+		t.Fatalf("expected 1 play, got %d", player.plays)
+	}
+	if player.sampleRate != defaultPiperSampleRate {
+		t.Errorf("expected sample rate %d, got %d", defaultPiperSampleRate, player.sampleRate)
+	}
+	if len(player.samples) != 2 || player.samples[1] != 2 {
+		t.Errorf("unexpected samples: %v", player.samples)
+	}
+}
+
+func TestSpeakCachesFixedPhrases(t *testing.T) {
+	command, counter := fakePiper(t)
+	s := New(true, command)
+	s.Player = &mockPlayer{}
+	s.Cache = NewCache(t.TempDir())
+	s.CachePhrases = []string{"Записал"}
+
+	for i := 0; i < 3; i++ {
+		if err := s.Speak(context.Background(), "Записал"); err != nil {
+			t.Fatalf("Speak failed: %v", err)
+		}
+	}
+	s.Speak(context.Background(), "не кэшируется")
+
+	runs, _ := os.ReadFile(counter)
+	if n := strings.Count(string(runs), "run"); n != 2 {
+		t.Errorf("expected 2 synthesizer runs (1 cached phrase + 1 dynamic), got %d", n)
+	}
+}
+
+func TestSpeakFallsBackToCommand(t *testing.T) {
+	player := &mockPlayer{}
+	s := &Speaker{Enabled: true, Command: "echo", Player: player}
+
+	if err := s.Speak(context.Background(), "test"); err != nil {
+		t.Fatalf("Speak failed: %v", err)
+	}
+	if player.plays != 0 {
+		t.Error("unsupported engine should not use the player")
+	}
+}
+
+func TestSpeakAsyncKeepsOrder(t *testing.T) {
+	dir := t.TempDir()
+	command := filepath.Join(dir, "say")
+	spoken := filepath.Join(dir, "spoken")
+	script := "#!/bin/sh\necho \"$1\" >> " + spoken + "\n"
+	if err := os.WriteFile(command, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(true, command)
+	phrases := []string{"Первое.", "Второе.", "Третье."}
+	for _, p := range phrases {
+		s.SpeakAsync(context.Background(), p)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, _ := os.ReadFile(spoken)
+		lines := strings.Fields(string(data))
+		if len(lines) == len(phrases) {
+			for i, p := range phrases {
+				if lines[i] != p {
+					t.Fatalf("expected %v in order, got %v", phrases, lines)
+				}
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out, spoken so far: %v", lines)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSpeakAndWaitAfterQueue(t *testing.T) {
+	dir := t.TempDir()
+	command := filepath.Join(dir, "say")
+	spoken := filepath.Join(dir, "spoken")
+	script := "#!/bin/sh\nsleep 0.05\necho \"$1\" >> " + spoken + "\n"
+	if err := os.WriteFile(command, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(true, command)
+	s.SpeakAsync(context.Background(), "Первое.")
+	if err := s.SpeakAndWait(context.Background(), "Второе?"); err != nil {
+		t.Fatalf("SpeakAndWait failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(spoken)
+	if lines := strings.Fields(string(data)); strings.Join(lines, " ") != "Первое. Второе?" {
+		t.Errorf("expected both phrases played before returning, got %v", lines)
+	}
+}
+
+func TestVoiceOptions(t *testing.T) {
+	s := NewWithOptions(true, "espeak-ng", Options{
+		Voice:     "ru+f3",
+		Rate:      1.2,
+		Pitch:     60,
+		ExtraArgs: []string{"-g", "5"},
+	})
+	want := []string{"-v", "ru+f3", "-s", "210", "-p", "60", "-g", "5"}
+	if strings.Join(s.Args, " ") != strings.Join(want, " ") {
+		t.Errorf("expected args %v, got %v", want, s.Args)
+	}
+	if s.Volume != 1 {
+		t.Errorf("expected default volume 1, got %v", s.Volume)
+	}
+
+	p := NewWithOptions(true, "piper", Options{Voice: "/voices/ru.onnx", Rate: 2})
+	want = []string{"--model", "/voices/ru.onnx", "--length_scale", "0.50"}
+	if strings.Join(p.Args, " ") != strings.Join(want, " ") {
+		t.Errorf("expected args %v, got %v", want, p.Args)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := New(true, "piper").Validate(); err != nil {
+		t.Errorf("a voice name is left to piper, got %v", err)
+	}
+
+	model := filepath.Join(t.TempDir(), "ru.onnx")
+	if err := NewWithOptions(true, "piper", Options{Voice: model}).Validate(); err == nil {
+		t.Error("expected error for missing piper model file")
+	}
+	if err := NewWithOptions(true, "piper", Options{Voice: "ru.onnx"}).Validate(); err == nil {
+		t.Error("expected error for a missing model file in the working directory")
+	}
+	if err := os.WriteFile(model, []byte("onnx"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewWithOptions(true, "piper", Options{Voice: model}).Validate(); err != nil {
+		t.Errorf("expected valid piper settings, got %v", err)
+	}
+
+	if err := NewWithOptions(true, "espeak-ng", Options{Volume: 3}).Validate(); err == nil {
+		t.Error("expected error for volume out of range")
+	}
+	if err := NewWithOptions(true, "espeak-ng", Options{Pitch: 120}).Validate(); err == nil {
+		t.Error("expected error for pitch out of range")
+	}
+}
+
+func TestApplyGain(t *testing.T) {
+	out := applyGain([]int16{100, -100, 30000}, 2)
+	if out[0] != 200 || out[1] != -200 || out[2] != 32767 {
+		t.Errorf("unexpected gain result: %v", out)
+	}
+}
@@ -8,6 +8,9 @@ import (
 	"image/draw"
 	"image/png"
 	"log"
+	"sort"
+	"strings"
+	"sync"
 )
 
 // State represents the current visual state of the tray icon.
@@ -17,11 +20,19 @@ const (
 	StateIdle State = iota
 	StateListening
 	StateThinking
+	StateError
+	StateDegraded
 )
 
+const defaultTooltip = "Bobik: Linux Voice Agent"
+
 // Manager handles the system tray icon and menu.
 type Manager struct {
 	onExit func()
+
+	mu       sync.Mutex
+	errMsg   string            // shown instead of idle until cleared
+	degraded map[string]string // role -> fallback model answering for it
 }
 
 // New creates a new tray manager.
@@ -38,7 +49,7 @@ func (m *Manager) Run() {
 
 func (m *Manager) onReady() {
 	systray.SetTitle("Bobik")
-	systray.SetTooltip("Bobik: Linux Voice Agent")
+	systray.SetTooltip(defaultTooltip)
 
 	m.SetState(StateIdle)
 
@@ -50,8 +61,83 @@ func (m *Manager) onReady() {
 	}()
 }
 
+// SetError shows a persistent problem, e.g. a missing model, in the tray.
+func (m *Manager) SetError(msg string) {
+	m.mu.Lock()
+	m.errMsg = msg
+	m.mu.Unlock()
+	systray.SetTooltip("Bobik: " + msg)
+	m.SetState(StateError)
+}
+
+// ClearError returns the tray to normal once the problem is gone.
+func (m *Manager) ClearError() {
+	m.mu.Lock()
+	hadError := m.errMsg != ""
+	m.errMsg = ""
+	m.mu.Unlock()
+	if hadError {
+		systray.SetTooltip(m.tooltip())
+		m.SetState(StateIdle)
+	}
+}
+
+// SetDegraded shows that a role answers with a fallback model.
+func (m *Manager) SetDegraded(role, model string) {
+	m.mu.Lock()
+	if m.degraded == nil {
+		m.degraded = map[string]string{}
+	}
+	m.degraded[role] = model
+	m.mu.Unlock()
+	m.refreshIdle()
+}
+
+// ClearDegraded is called once the role's primary model answers again.
+func (m *Manager) ClearDegraded(role string) {
+	m.mu.Lock()
+	_, ok := m.degraded[role]
+	delete(m.degraded, role)
+	m.mu.Unlock()
+	if ok {
+		m.refreshIdle()
+	}
+}
+
+func (m *Manager) refreshIdle() {
+	systray.SetTooltip(m.tooltip())
+	m.SetState(StateIdle)
+}
+
+// tooltip describes the error or the degraded roles, if any.
+func (m *Manager) tooltip() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.errMsg != "" {
+		return "Bobik: " + m.errMsg
+	}
+	if len(m.degraded) == 0 {
+		return defaultTooltip
+	}
+	roles := make([]string, 0, len(m.degraded))
+	for role, model := range m.degraded {
+		roles = append(roles, role+" → "+model)
+	}
+	sort.Strings(roles)
+	return "Bobik: резервные модели (" + strings.Join(roles, ", ") + ")"
+}
+
 // SetState updates the tray icon based on the provided state.
 func (m *Manager) SetState(state State) {
+	m.mu.Lock()
+	if state == StateIdle && m.errMsg != "" {
+		// Idle keeps showing the error until it's cleared
+		state = StateError
+	} else if state == StateIdle && len(m.degraded) > 0 {
+		state = StateDegraded
+	}
+	m.mu.Unlock()
+
 	var c color.Color
 	var label string
 	switch state {
@@ -59,13 +145,19 @@ func (m *Manager) SetState(state State) {
 		c = color.RGBA{128, 128, 128, 255} // Gray
 		label = "IDLE"
 	case StateListening:
-		c = color.RGBA{0, 200, 0, 255}   // Darker Green
+		c = color.RGBA{0, 200, 0, 255} // Darker Green
 		label = "LISTENING"
 	case StateThinking:
 		c = color.RGBA{0, 100, 255, 255} // Strong Blue
 		label = "THINKING"
+	case StateError:
+		c = color.RGBA{220, 0, 0, 255} // Red
+		label = "ERROR"
+	case StateDegraded:
+		c = color.RGBA{255, 160, 0, 255} // Orange
+		label = "DEGRADED"
 	}
-	
+
 	log.Printf("Tray: Changing state to %s", label)
 	systray.SetIcon(createCircleIcon(c))
 }
@@ -73,20 +165,20 @@ func (m *Manager) SetState(state State) {
 func createCircleIcon(c color.Color) []byte {
 	size := 64
 	img := image.NewRGBA(image.Rect(0, 0, size, size))
-	
+
 	// Transparent background
 	draw.Draw(img, img.Bounds(), &image.Uniform{color.Transparent}, image.Point{}, draw.Src)
-	
+
 	// Draw a colored circle
 	centerX, centerY := size/2, size/2
 	radius := size/2 - 4
 	innerRadius := 8 // Small black dot in the center
-	
+
 	for y := 0; y < size; y++ {
 		for x := 0; x < size; x++ {
 			dx := x - centerX
 			dy := y - centerY
-			distSq := dx*dx+dy*dy
+			distSq := dx*dx + dy*dy
 			if distSq <= radius*radius {
 				if distSq <= innerRadius*innerRadius {
 					img.Set(x, y, color.Black)
@@ -100,4 +192,4 @@ func createCircleIcon(c color.Color) []byte {
 	var buf bytes.Buffer
 	png.Encode(&buf, img)
 	return buf.Bytes()
-}
\ No newline at end of file
+}
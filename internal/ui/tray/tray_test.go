@@ -14,4 +14,28 @@ func TestState(t *testing.T) {
 	if StateThinking != 2 {
 		t.Errorf("expected StateThinking to be 2, got %d", StateThinking)
 	}
+	if StateError != 3 {
+		t.Errorf("expected StateError to be 3, got %d", StateError)
+	}
+	if StateDegraded != 4 {
+		t.Errorf("expected StateDegraded to be 4, got %d", StateDegraded)
+	}
+}
+
+func TestDegradedTooltip(t *testing.T) {
+	m := New(nil)
+	if got := m.tooltip(); got != defaultTooltip {
+		t.Errorf("expected default tooltip, got %q", got)
+	}
+
+	m.degraded = map[string]string{"vision": "llava:7b", "router": "qwen3:4b"}
+	want := "Bobik: резервные модели (router → qwen3:4b, vision → llava:7b)"
+	if got := m.tooltip(); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	m.errMsg = "Ollama недоступна"
+	if got := m.tooltip(); got != "Bobik: Ollama недоступна" {
+		t.Errorf("error should take precedence, got %q", got)
+	}
 }
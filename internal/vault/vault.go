@@ -0,0 +1,151 @@
+// Package vault writes Obsidian vault files safely: atomically, one writer
+// per file within the process, and without losing edits made by Obsidian or
+// a sync client in the meantime.
+package vault
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hey-bobik/internal/logger"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var log = logger.New("vault")
+
+// maxAttempts is how many times Update re-reads a file that changed under it.
+const maxAttempts = 3
+
+// ErrConflict means the file kept changing while it was being rewritten.
+var ErrConflict = errors.New("file changed while writing")
+
+var (
+	locksMu sync.Mutex
+	locks   = map[string]*sync.Mutex{}
+)
+
+// lock serialises writers of one file within the process.
+func lock(path string) func() {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	locksMu.Lock()
+	mu, ok := locks[path]
+	if !ok {
+		mu = &sync.Mutex{}
+		locks[path] = mu
+	}
+	locksMu.Unlock()
+
+	mu.Lock()
+	return mu.Unlock
+}
+
+// snapshot identifies a version of a file.
+type snapshot struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+	sum     [sha256.Size]byte
+}
+
+func read(path string) ([]byte, snapshot, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, snapshot{}, nil
+	}
+	if err != nil {
+		return nil, snapshot{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, snapshot{}, err
+	}
+	return data, snapshot{exists: true, modTime: info.ModTime(), size: info.Size(), sum: sha256.Sum256(data)}, nil
+}
+
+// Update rewrites a file with the result of change, which gets the current
+// contents, nil when the file doesn't exist. If the file is modified by
+// someone else between reading and writing, change runs again on the new
+// contents, up to a few times. An error from change aborts the update.
+//
+// Another process can still write in the short window between the final
+// check and the rename; there is no portable way to lock a file Obsidian
+// doesn't know about.
+func Update(path string, change func(old []byte) ([]byte, error)) error {
+	unlock := lock(path)
+	defer unlock()
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		old, before, err := read(path)
+		if err != nil {
+			return err
+		}
+		data, err := change(old)
+		if err != nil {
+			return err
+		}
+
+		_, now, err := read(path)
+		if err != nil {
+			return err
+		}
+		if now != before {
+			log.Debug("%s changed while writing, retrying (%d/%d)", path, attempt, maxAttempts)
+			continue
+		}
+		if before.exists && bytes.Equal(old, data) {
+			return nil
+		}
+		return WriteFile(path, data)
+	}
+	return fmt.Errorf("%w: %s", ErrConflict, path)
+}
+
+// WriteFile replaces a file atomically: the data goes to a hidden temporary
+// file in the same folder, is synced to disk and renamed over the original,
+// so a crash leaves either the old or the new contents. The permissions of an
+// existing file are kept.
+func WriteFile(path string, data []byte) error {
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	// Cleans up after a failure, a no-op once renamed
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// Persist the rename itself
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func appendLine(line string) func([]byte) ([]byte, error) {
+	return func(old []byte) ([]byte, error) {
+		return append(old, line+"\n"...), nil
+	}
+}
+
+func TestUpdateConcurrentWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "2026-10-18.md")
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := Update(path, appendLine(fmt.Sprintf("entry %d", i))); err != nil {
+				t.Errorf("Update failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	data, _ := os.ReadFile(path)
+	for i := range 50 {
+		if !strings.Contains(string(data), fmt.Sprintf("entry %d\n", i)) {
+			t.Errorf("entry %d was lost", i)
+		}
+	}
+	if files, _ := os.ReadDir(filepath.Dir(path)); len(files) != 1 {
+		t.Errorf("temporary files were left behind: %v", files)
+	}
+}
+
+func TestUpdateRetriesAfterExternalChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "note.md")
+	os.WriteFile(path, []byte("первая\n"), 0600)
+
+	// Obsidian saves the note while we're preparing our version
+	calls := 0
+	err := Update(path, func(old []byte) ([]byte, error) {
+		calls++
+		if calls == 1 {
+			os.WriteFile(path, []byte("первая\nиз Obsidian\n"), 0600)
+		}
+		return append(old, "от Бобика\n"...), nil
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if calls != 2 || string(data) != "первая\nиз Obsidian\nот Бобика\n" {
+		t.Errorf("the external edit should be kept, got %d calls and %q", calls, data)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("permissions should be kept, got %v", info.Mode())
+	}
+}
+
+func TestUpdateConflict(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "note.md")
+	os.WriteFile(path, []byte("0"), 0644)
+
+	calls := 0
+	err := Update(path, func(old []byte) ([]byte, error) {
+		calls++
+		os.WriteFile(path, []byte(fmt.Sprint(calls)), 0644)
+		return []byte("от Бобика"), nil
+	})
+	if !errors.Is(err, ErrConflict) || calls != maxAttempts {
+		t.Errorf("expected a conflict after %d attempts, got %v after %d", maxAttempts, err, calls)
+	}
+	if data, _ := os.ReadFile(path); string(data) != fmt.Sprint(maxAttempts) {
+		t.Errorf("the external version should win, got %q", data)
+	}
+}
+
+func TestUpdateAborted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "note.md")
+	os.WriteFile(path, []byte("текст\n"), 0644)
+
+	abort := errors.New("no entries")
+	if err := Update(path, func([]byte) ([]byte, error) { return nil, abort }); err != abort {
+		t.Errorf("expected the change error, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "текст\n" {
+		t.Errorf("the file should be untouched, got %q", data)
+	}
+
+	missing := filepath.Join(t.TempDir(), "missing.md")
+	Update(missing, func(old []byte) ([]byte, error) {
+		if old != nil {
+			t.Errorf("a missing file should give nil, got %q", old)
+		}
+		return []byte("новая\n"), nil
+	})
+	if data, _ := os.ReadFile(missing); string(data) != "новая\n" {
+		t.Errorf("expected the file to be created, got %q", data)
+	}
+}